@@ -118,3 +118,53 @@ func Test_CheckPasswordValid(t *testing.T) {
 		assert.Equal(t, errCode, passwordOK)
 	}
 }
+
+func Test_PasswordPolicy(t *testing.T) {
+	getCodes := func(violations []*PasswordViolation) []passwordErrorCode {
+		var codes []passwordErrorCode
+		for _, v := range violations {
+			codes = append(codes, v.Code)
+		}
+		return codes
+	}
+
+	policy := &PasswordPolicy{
+		MinLength:      8,
+		MinCharClasses: 3,
+		MaxRepeat:      2,
+		HistorySize:    3,
+		CheckUsername:  true,
+		DictionaryFile: "testdata/dict",
+	}
+	isReused := func(passwd string) bool {
+		return passwd == "Old-pass1"
+	}
+
+	assert.Empty(t, policy.Check("test1", "Good-pass1", isReused))
+	assert.Equal(t, getCodes(policy.Check("test1", "aaa", isReused)),
+		[]passwordErrorCode{passwordErrCodeShort, passwordErrCodeSimple, passwordErrCodeRepeat})
+	assert.Equal(t, getCodes(policy.Check("test1", "Test1-word", isReused)),
+		[]passwordErrorCode{passwordErrCodeUsername})
+	assert.Equal(t, getCodes(policy.Check("test1", "1tset-Word", isReused)),
+		[]passwordErrorCode{passwordErrCodeUsername})
+	assert.Equal(t, getCodes(policy.Check("test1", "Sunshine2020!", isReused)),
+		[]passwordErrorCode{passwordErrCodeDictionary})
+	assert.Equal(t, getCodes(policy.Check("test1", "Old-pass1", isReused)),
+		[]passwordErrorCode{passwordErrCodeReused})
+	// 不检查用户名和历史密码
+	assert.Empty(t, policy.Check("", "Test1-word", nil))
+
+	violations := policy.Check("test1", "abc", nil)
+	assert.Equal(t, violations[0].Message(nil), "Please enter a password not less than 8 characters")
+	assert.Equal(t, violations[0].Message(func(str string) string {
+		return "[" + str + "]"
+	}), "[Please enter a password not less than 8 characters]")
+
+	policy, err := LoadPasswordPolicy("testdata/not-exist", "Desktop")
+	assert.Nil(t, err)
+	assert.Equal(t, policy, GetDefaultPasswordPolicy("Desktop"))
+	assert.Empty(t, policy.Check("test1", "a", nil))
+	assert.False(t, policy.HasRules())
+	assert.True(t, GetDefaultPasswordPolicy("Server").HasRules())
+	assert.True(t, (&PasswordPolicy{HistorySize: 1}).HasRules())
+}
@@ -39,6 +39,10 @@ const (
 	passwordOK passwordErrorCode = iota
 	passwordErrCodeShort
 	passwordErrCodeSimple
+	passwordErrCodeRepeat
+	passwordErrCodeUsername
+	passwordErrCodeDictionary
+	passwordErrCodeReused
 )
 
 func (code passwordErrorCode) IsOk() bool {
@@ -76,27 +80,11 @@ func (p password) hasUpperAndLowerAlphabet() bool {
 		passwordLowerAlphabetRegexp.MatchString(str)
 }
 
+// CheckPasswordValid 使用默认策略检查密码，返回违反的第一条规则
 func CheckPasswordValid(releaseType, passwd string) passwordErrorCode {
-	if releaseType != "Server" {
+	violations := GetDefaultPasswordPolicy(releaseType).Check("", passwd, nil)
+	if len(violations) == 0 {
 		return passwordOK
 	}
-
-	if len(passwd) < passwordMinLength {
-		return passwordErrCodeShort
-	}
-
-	p := password(passwd)
-	if !p.hasAnyNumber() {
-		return passwordErrCodeSimple
-	}
-
-	if !p.hasAnySpecialChar() {
-		return passwordErrCodeSimple
-	}
-
-	if !p.hasUpperAndLowerAlphabet() {
-		return passwordErrCodeSimple
-	}
-
-	return passwordOK
+	return violations[0].Code
 }
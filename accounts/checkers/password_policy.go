@@ -0,0 +1,293 @@
+package checkers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// PasswordPolicy 是密码策略，由管理员在策略文件中配置，文件不存在时使用 GetDefaultPasswordPolicy
+type PasswordPolicy struct {
+	MinLength int
+
+	// 必须包含的字符类型
+	RequireDigit   bool
+	RequireUpper   bool
+	RequireLower   bool
+	RequireSpecial bool
+	// 至少包含几种字符类型，0 表示不限制
+	MinCharClasses int
+	// 特殊字符的范围，为空时使用默认的特殊字符
+	SpecialChars string
+
+	// 同一字符最多连续出现的次数，0 表示不限制
+	MaxRepeat int
+	// 不能与最近几次使用过的密码相同，0 表示不限制
+	HistorySize int
+	// 不能包含用户名或倒序的用户名
+	CheckUsername bool
+	// 字典文件，每行一个单词，去掉首尾的数字和符号后不能是字典中的单词
+	DictionaryFile string
+}
+
+// GetDefaultPasswordPolicy 返回没有策略文件时的默认策略，与原来的规则相同，只在服务器版中检查
+func GetDefaultPasswordPolicy(releaseType string) *PasswordPolicy {
+	if releaseType != "Server" {
+		return &PasswordPolicy{}
+	}
+	return &PasswordPolicy{
+		MinLength:      passwordMinLength,
+		RequireDigit:   true,
+		RequireUpper:   true,
+		RequireLower:   true,
+		RequireSpecial: true,
+	}
+}
+
+// HasRules 判断策略是否有需要检查的规则
+func (p *PasswordPolicy) HasRules() bool {
+	return p.MinLength > 0 || p.RequireDigit || p.RequireUpper || p.RequireLower ||
+		p.RequireSpecial || p.MinCharClasses > 0 || p.MaxRepeat > 0 || p.HistorySize > 0 ||
+		p.CheckUsername || p.DictionaryFile != ""
+}
+
+// LoadPasswordPolicy 从 file 加载策略，文件不存在时返回默认策略
+func LoadPasswordPolicy(file, releaseType string) (*PasswordPolicy, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return GetDefaultPasswordPolicy(releaseType), nil
+		}
+		return nil, err
+	}
+	var policy PasswordPolicy
+	err = json.Unmarshal(content, &policy)
+	if err != nil {
+		return nil, err
+	}
+	if policy.MinLength < 0 || policy.MinCharClasses < 0 || policy.MinCharClasses > 4 ||
+		policy.MaxRepeat < 0 || policy.HistorySize < 0 {
+		return nil, fmt.Errorf("invalid password policy %q", file)
+	}
+	return &policy, nil
+}
+
+// PasswordViolation 是密码违反的一条规则，消息在 Message 中翻译后再格式化
+type PasswordViolation struct {
+	Code   passwordErrorCode
+	format string
+	args   []interface{}
+}
+
+// Message 返回违反规则的提示，tr 用于翻译，为 nil 时不翻译
+func (v *PasswordViolation) Message(tr func(string) string) string {
+	format := v.format
+	if tr != nil {
+		format = tr(format)
+	}
+	if len(v.args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, v.args...)
+}
+
+func newPasswordViolation(code passwordErrorCode, format string, args ...interface{}) *PasswordViolation {
+	return &PasswordViolation{
+		Code:   code,
+		format: format,
+		args:   args,
+	}
+}
+
+func (p *PasswordPolicy) getSpecialChars() string {
+	if p.SpecialChars == "" {
+		return passwordSpecialChars
+	}
+	return p.SpecialChars
+}
+
+// Check 检查密码，返回违反的所有规则。
+// username 为空时不检查用户名，isReused 为 nil 时不检查历史密码。
+func (p *PasswordPolicy) Check(username, passwd string, isReused func(string) bool) []*PasswordViolation {
+	var result []*PasswordViolation
+	if len(passwd) < p.MinLength {
+		result = append(result, newPasswordViolation(passwordErrCodeShort,
+			Tr("Please enter a password not less than %d characters"), p.MinLength))
+	}
+
+	result = append(result, p.checkCharClasses(passwd)...)
+
+	if p.MaxRepeat > 0 && getMaxRepeat(passwd) > p.MaxRepeat {
+		result = append(result, newPasswordViolation(passwordErrCodeRepeat,
+			Tr("The same character cannot be repeated more than %d times in a row"), p.MaxRepeat))
+	}
+
+	if p.CheckUsername && username != "" && isSimilarToUsername(passwd, username) {
+		result = append(result, newPasswordViolation(passwordErrCodeUsername,
+			Tr("The password cannot contain the username")))
+	}
+
+	if p.DictionaryFile != "" {
+		// 字典文件不可读时跳过字典检查
+		inDict, _ := isDictionaryWord(p.DictionaryFile, passwd)
+		if inDict {
+			result = append(result, newPasswordViolation(passwordErrCodeDictionary,
+				Tr("The password is a common word and can be easily guessed")))
+		}
+	}
+
+	if p.HistorySize > 0 && isReused != nil && isReused(passwd) {
+		result = append(result, newPasswordViolation(passwordErrCodeReused,
+			Tr("The password cannot be the same as the last %d passwords"), p.HistorySize))
+	}
+	return result
+}
+
+func (p *PasswordPolicy) checkCharClasses(passwd string) []*PasswordViolation {
+	pw := password(passwd)
+	hasDigit := pw.hasAnyNumber()
+	hasUpper := passwordUpperAlphabetRegexp.MatchString(passwd)
+	hasLower := passwordLowerAlphabetRegexp.MatchString(passwd)
+	specialChars := p.getSpecialChars()
+	hasSpecial := strings.ContainsAny(passwd, specialChars)
+
+	var result []*PasswordViolation
+	if p.RequireDigit && !hasDigit {
+		result = append(result, newPasswordViolation(passwordErrCodeSimple,
+			Tr("The password must contain numbers")))
+	}
+	if p.RequireUpper && !hasUpper {
+		result = append(result, newPasswordViolation(passwordErrCodeSimple,
+			Tr("The password must contain uppercase letters")))
+	}
+	if p.RequireLower && !hasLower {
+		result = append(result, newPasswordViolation(passwordErrCodeSimple,
+			Tr("The password must contain lowercase letters")))
+	}
+	if p.RequireSpecial && !hasSpecial {
+		result = append(result, newPasswordViolation(passwordErrCodeSimple,
+			Tr("The password must contain special symbols (%s)"), specialChars))
+	}
+
+	if p.MinCharClasses > 0 {
+		classes := 0
+		for _, has := range []bool{hasDigit, hasUpper, hasLower, hasSpecial} {
+			if has {
+				classes++
+			}
+		}
+		if classes < p.MinCharClasses {
+			result = append(result, newPasswordViolation(passwordErrCodeSimple,
+				Tr("The password must contain at least %d of uppercase letters, lowercase letters, numbers and special symbols"),
+				p.MinCharClasses))
+		}
+	}
+	return result
+}
+
+// getMaxRepeat 返回同一字符连续出现的最大次数
+func getMaxRepeat(str string) int {
+	var result, count int
+	var last rune
+	for i, r := range str {
+		if i > 0 && r == last {
+			count++
+		} else {
+			count = 1
+		}
+		last = r
+		if count > result {
+			result = count
+		}
+	}
+	return result
+}
+
+func reverseString(str string) string {
+	runes := []rune(str)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+// isSimilarToUsername 判断密码是否包含用户名或倒序的用户名，忽略大小写
+func isSimilarToUsername(passwd, username string) bool {
+	if len(username) < userNameMinLength {
+		return false
+	}
+	passwd = strings.ToLower(passwd)
+	username = strings.ToLower(username)
+	return strings.Contains(passwd, username) ||
+		strings.Contains(passwd, reverseString(username))
+}
+
+// 字典在文件修改后重新加载
+var passwordDict struct {
+	mu      sync.Mutex
+	file    string
+	modTime time.Time
+	words   map[string]struct{}
+}
+
+func loadPasswordDict(file string) (map[string]struct{}, error) {
+	fileInfo, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordDict.mu.Lock()
+	defer passwordDict.mu.Unlock()
+	if passwordDict.file == file && passwordDict.modTime.Equal(fileInfo.ModTime()) {
+		return passwordDict.words, nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	words := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if word != "" {
+			words[word] = struct{}{}
+		}
+	}
+	err = scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+	passwordDict.file = file
+	passwordDict.modTime = fileInfo.ModTime()
+	passwordDict.words = words
+	return words, nil
+}
+
+// isDictionaryWord 判断密码去掉首尾的数字和符号后是否是字典中的单词，忽略大小写
+func isDictionaryWord(file, passwd string) (bool, error) {
+	words, err := loadPasswordDict(file)
+	if err != nil {
+		return false, err
+	}
+	passwd = strings.ToLower(passwd)
+	if _, ok := words[passwd]; ok {
+		return true, nil
+	}
+	core := strings.TrimFunc(passwd, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if core == "" {
+		return false, nil
+	}
+	_, ok := words[core]
+	return ok, nil
+}
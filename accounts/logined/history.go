@@ -0,0 +1,206 @@
+package logined
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// 每个用户一个文件，保存登录记录和失败的登录尝试
+	historyDir        = "/var/lib/AccountsService/deepin/login-history"
+	maxHistoryRecords = 100
+)
+
+// LoginRecord 是一次登录，时间为 Unix 时间戳，LogoutTime 为 0 表示还没有注销或者未知
+type LoginRecord struct {
+	SessionId string
+	// x11、wayland 或 tty
+	SessionType string
+	Seat        string
+	TTY         string
+	RemoteHost  string
+	// PAM 服务名，如 lightdm、sshd
+	Service    string
+	LoginTime  int64
+	LogoutTime int64
+}
+
+// FailedAttempt 是 dde-authority 报告的一次认证失败
+type FailedAttempt struct {
+	Time     int64
+	AuthType string
+	// 请求认证的程序名，如 dde-lock
+	App string
+}
+
+type userHistory struct {
+	Logins         []*LoginRecord
+	FailedAttempts []*FailedAttempt
+}
+
+// History 保存所有用户的登录历史，记录按时间从旧到新排列，每种最多保留 maxHistoryRecords 条
+type History struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func NewHistory(dir string) *History {
+	return &History{dir: dir}
+}
+
+// DefaultHistory 由 Manager 记录，accounts 的 User 通过它查询
+var DefaultHistory = NewHistory(historyDir)
+
+func (h *History) getFile(username string) (string, error) {
+	if username == "" || strings.ContainsAny(username, "/\x00") || strings.HasPrefix(username, ".") {
+		return "", errors.New("invalid username")
+	}
+	return filepath.Join(h.dir, username), nil
+}
+
+func (h *History) load(username string) (*userHistory, error) {
+	file, err := h.getFile(username)
+	if err != nil {
+		return nil, err
+	}
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &userHistory{}, nil
+		}
+		return nil, err
+	}
+	var uh userHistory
+	err = json.Unmarshal(content, &uh)
+	if err != nil {
+		return nil, err
+	}
+	return &uh, nil
+}
+
+func (h *History) save(username string, uh *userHistory) error {
+	file, err := h.getFile(username)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(h.dir, 0700)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(uh)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, content, 0600)
+}
+
+func (h *History) update(username string, fn func(uh *userHistory) bool) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	uh, err := h.load(username)
+	if err != nil {
+		return err
+	}
+	if !fn(uh) {
+		return nil
+	}
+	return h.save(username, uh)
+}
+
+// AddLogin 添加登录记录，重启后重新添加已有的会话时忽略
+func (h *History) AddLogin(username string, record *LoginRecord) error {
+	return h.update(username, func(uh *userHistory) bool {
+		for _, r := range uh.Logins {
+			if r.SessionId == record.SessionId && r.LoginTime == record.LoginTime {
+				return false
+			}
+		}
+		uh.Logins = append(uh.Logins, record)
+		if len(uh.Logins) > maxHistoryRecords {
+			uh.Logins = uh.Logins[len(uh.Logins)-maxHistoryRecords:]
+		}
+		return true
+	})
+}
+
+// SetLogout 设置会话 sessionId 最近一次登录的注销时间
+func (h *History) SetLogout(username, sessionId string, t int64) error {
+	return h.update(username, func(uh *userHistory) bool {
+		for i := len(uh.Logins) - 1; i >= 0; i-- {
+			r := uh.Logins[i]
+			if r.SessionId == sessionId && r.LogoutTime == 0 {
+				r.LogoutTime = t
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (h *History) AddFailedAttempt(username string, attempt *FailedAttempt) error {
+	return h.update(username, func(uh *userHistory) bool {
+		uh.FailedAttempts = append(uh.FailedAttempts, attempt)
+		if len(uh.FailedAttempts) > maxHistoryRecords {
+			uh.FailedAttempts = uh.FailedAttempts[len(uh.FailedAttempts)-maxHistoryRecords:]
+		}
+		return true
+	})
+}
+
+// GetLogins 返回最近的 limit 条登录记录，最新的在前面，limit 为 0 时返回全部
+func (h *History) GetLogins(username string, limit int) ([]*LoginRecord, error) {
+	h.mu.Lock()
+	uh, err := h.load(username)
+	h.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	n := len(uh.Logins)
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	result := make([]*LoginRecord, n)
+	for i := range result {
+		result[i] = uh.Logins[len(uh.Logins)-1-i]
+	}
+	return result, nil
+}
+
+// GetFailedAttempts 返回最近的 limit 次失败的登录尝试，最新的在前面，limit 为 0 时返回全部
+func (h *History) GetFailedAttempts(username string, limit int) ([]*FailedAttempt, error) {
+	h.mu.Lock()
+	uh, err := h.load(username)
+	h.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	n := len(uh.FailedAttempts)
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	result := make([]*FailedAttempt, n)
+	for i := range result {
+		result[i] = uh.FailedAttempts[len(uh.FailedAttempts)-1-i]
+	}
+	return result, nil
+}
+
+// Remove 删除用户的登录历史，新建同名用户时不应该继承
+func (h *History) Remove(username string) error {
+	file, err := h.getFile(username)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	err = os.Remove(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
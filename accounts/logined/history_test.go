@@ -0,0 +1,56 @@
+package logined
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "login-history")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	h := NewHistory(dir)
+
+	logins, err := h.GetLogins("test1", 0)
+	assert.Nil(t, err)
+	assert.Empty(t, logins)
+
+	assert.Nil(t, h.AddLogin("test1", &LoginRecord{SessionId: "1", LoginTime: 100}))
+	assert.Nil(t, h.AddLogin("test1", &LoginRecord{SessionId: "2", LoginTime: 200, RemoteHost: "10.0.0.2"}))
+	// 重启后重新添加已有的会话
+	assert.Nil(t, h.AddLogin("test1", &LoginRecord{SessionId: "1", LoginTime: 100}))
+	assert.Nil(t, h.SetLogout("test1", "1", 150))
+	assert.Nil(t, h.SetLogout("test1", "3", 150))
+
+	logins, err = h.GetLogins("test1", 0)
+	assert.Nil(t, err)
+	assert.Len(t, logins, 2)
+	assert.Equal(t, logins[0].SessionId, "2")
+	assert.Equal(t, logins[0].RemoteHost, "10.0.0.2")
+	assert.Equal(t, logins[0].LogoutTime, int64(0))
+	assert.Equal(t, logins[1].LogoutTime, int64(150))
+
+	logins, err = h.GetLogins("test1", 1)
+	assert.Nil(t, err)
+	assert.Len(t, logins, 1)
+
+	for i := 0; i < maxHistoryRecords+10; i++ {
+		assert.Nil(t, h.AddFailedAttempt("test1", &FailedAttempt{Time: int64(i), AuthType: "keyboard"}))
+	}
+	attempts, err := h.GetFailedAttempts("test1", 0)
+	assert.Nil(t, err)
+	assert.Len(t, attempts, maxHistoryRecords)
+	assert.Equal(t, attempts[0].Time, int64(maxHistoryRecords+9))
+
+	_, err = h.GetLogins("../test1", 0)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, h.Remove("test1"))
+	logins, err = h.GetLogins("test1", 0)
+	assert.Nil(t, err)
+	assert.Empty(t, logins)
+	assert.Nil(t, h.Remove("test1"))
+}
@@ -41,6 +41,11 @@ type Manager struct {
 	userSessions map[uint32]SessionInfos
 	locker       sync.Mutex
 
+	history *History
+	// 会话 id 到用户名，注销时用于更新登录记录
+	loginSessions   map[string]string
+	loginSessionsMu sync.Mutex
+
 	UserList       string
 	LastLogoutUser uint32
 }
@@ -64,10 +69,14 @@ func Register(logger *log.Logger, service *dbusutil.Service) (*Manager, error) {
 		logger:       logger,
 		userSessions: make(map[uint32]SessionInfos),
 		sysSigLoop:   sysSigLoop,
+
+		history:       DefaultHistory,
+		loginSessions: make(map[string]string),
 	}
 
 	go m.init()
 	m.handleChanged()
+	m.listenAuthFailed()
 	return m, nil
 }
 
@@ -97,6 +106,7 @@ func (m *Manager) init() {
 
 	for _, session := range sessions {
 		m.addSession(session.Path)
+		m.recordLogin(session.Path)
 	}
 	m.setPropUserList()
 }
@@ -109,6 +119,7 @@ func (m *Manager) handleChanged() {
 		if added {
 			m.setPropUserList()
 		}
+		m.recordLogin(sessionPath)
 	})
 	_, _ = m.core.ConnectSessionRemoved(func(id string, sessionPath dbus.ObjectPath) {
 		m.logger.Debug("[Event] session remove:", id, sessionPath)
@@ -116,6 +127,7 @@ func (m *Manager) handleChanged() {
 		if deleted {
 			m.setPropUserList()
 		}
+		m.recordLogout(id)
 	})
 }
 
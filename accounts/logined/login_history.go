@@ -0,0 +1,153 @@
+package logined
+
+import (
+	"os/user"
+	"time"
+
+	"github.com/godbus/dbus"
+	"github.com/linuxdeepin/go-dbus-factory/org.freedesktop.login1"
+	"pkg.deepin.io/lib/dbusutil"
+)
+
+const (
+	authorityServiceName = "com.deepin.daemon.Authority"
+	authorityPath        = "/com/deepin/daemon/Authority"
+	authorityInterface   = authorityServiceName
+)
+
+// getLoginRecord 返回会话的用户名和登录记录，不是用户会话时返回 nil，如 greeter 会话
+func getLoginRecord(sessionPath dbus.ObjectPath) (string, *LoginRecord, error) {
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return "", nil, err
+	}
+	core, err := login1.NewSession(systemBus, sessionPath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	class, err := core.Class().Get(0)
+	if err != nil {
+		return "", nil, err
+	}
+	if class != "user" {
+		return "", nil, nil
+	}
+	username, err := core.Name().Get(0)
+	if err != nil {
+		return "", nil, err
+	}
+	id, err := core.Id().Get(0)
+	if err != nil {
+		return "", nil, err
+	}
+	// 单位为微秒
+	timestamp, err := core.Timestamp().Get(0)
+	if err != nil {
+		return "", nil, err
+	}
+
+	sessionType, _ := core.Type().Get(0)
+	seat, _ := core.Seat().Get(0)
+	tty, _ := core.TTY().Get(0)
+	remoteHost, _ := core.RemoteHost().Get(0)
+	service, _ := core.Service().Get(0)
+	return username, &LoginRecord{
+		SessionId:   id,
+		SessionType: sessionType,
+		Seat:        seat.Id,
+		TTY:         tty,
+		RemoteHost:  remoteHost,
+		Service:     service,
+		LoginTime:   int64(timestamp / uint64(time.Second/time.Microsecond)),
+	}, nil
+}
+
+func (m *Manager) recordLogin(sessionPath dbus.ObjectPath) {
+	username, record, err := getLoginRecord(sessionPath)
+	if err != nil {
+		m.logger.Warning("Failed to get login record:", sessionPath, err)
+		return
+	}
+	if record == nil {
+		return
+	}
+
+	m.loginSessionsMu.Lock()
+	m.loginSessions[record.SessionId] = username
+	m.loginSessionsMu.Unlock()
+
+	err = m.history.AddLogin(username, record)
+	if err != nil {
+		m.logger.Warning("Failed to add login record:", username, err)
+	}
+}
+
+func (m *Manager) recordLogout(sessionId string) {
+	m.loginSessionsMu.Lock()
+	username, ok := m.loginSessions[sessionId]
+	delete(m.loginSessions, sessionId)
+	m.loginSessionsMu.Unlock()
+	if !ok {
+		return
+	}
+
+	err := m.history.SetLogout(username, sessionId, time.Now().Unix())
+	if err != nil {
+		m.logger.Warning("Failed to set logout time:", username, err)
+	}
+}
+
+// listenAuthFailed 记录 dde-authority 报告的认证失败，只接受 dde-authority 发送的信号
+func (m *Manager) listenAuthFailed() {
+	err := dbusutil.NewMatchRuleBuilder().
+		ExtSignal(authorityPath, authorityInterface, "AuthFailed").
+		Sender(authorityServiceName).Build().
+		AddTo(m.sysSigLoop.Conn())
+	if err != nil {
+		m.logger.Warning("Failed to add match rule:", err)
+		return
+	}
+
+	m.sysSigLoop.AddHandler(&dbusutil.SignalRule{
+		Path: authorityPath,
+		Name: authorityInterface + ".AuthFailed",
+	}, func(sig *dbus.Signal) {
+		if !m.isAuthoritySender(sig.Sender) {
+			m.logger.Warning("Ignore AuthFailed signal from", sig.Sender)
+			return
+		}
+		var username, authType, app string
+		err := dbus.Store(sig.Body, &username, &authType, &app)
+		if err != nil {
+			m.logger.Warning(err)
+			return
+		}
+		// 用户名是输入的，只记录存在的用户
+		_, err = user.Lookup(username)
+		if err != nil {
+			m.logger.Debug("Ignore auth failed of unknown user:", username)
+			return
+		}
+
+		err = m.history.AddFailedAttempt(username, &FailedAttempt{
+			Time:     time.Now().Unix(),
+			AuthType: authType,
+			App:      app,
+		})
+		if err != nil {
+			m.logger.Warning("Failed to add failed attempt:", username, err)
+		}
+	})
+}
+
+func (m *Manager) isAuthoritySender(sender string) bool {
+	var owner string
+	err := m.sysSigLoop.Conn().BusObject().Call("org.freedesktop.DBus.GetNameOwner", 0,
+		authorityServiceName).Store(&owner)
+	if err != nil {
+		m.logger.Warning(err)
+		return false
+	}
+	return owner == sender
+}
@@ -74,17 +74,18 @@ type Manager struct {
 	}
 	//nolint
 	methods *struct {
-		CreateUser         func() `in:"name,fullName,accountType" out:"user"`
-		DeleteUser         func() `in:"name,rmFiles"`
-		FindUserById       func() `in:"uid" out:"user"`
-		FindUserByName     func() `in:"name" out:"user"`
-		RandUserIcon       func() `out:"iconFile"`
-		IsUsernameValid    func() `in:"name" out:"ok,errReason,errCode"`
-		IsPasswordValid    func() `in:"password" out:"ok,errReason,errCode"`
-		AllowGuestAccount  func() `in:"allow"`
-		CreateGuestAccount func() `out:"user"`
-		GetGroups          func() `out:"groups"`
-		GetPresetGroups    func() `in:"accountType" out:"groups"`
+		CreateUser           func() `in:"name,fullName,accountType" out:"user"`
+		DeleteUser           func() `in:"name,rmFiles"`
+		FindUserById         func() `in:"uid" out:"user"`
+		FindUserByName       func() `in:"name" out:"user"`
+		RandUserIcon         func() `out:"iconFile"`
+		IsUsernameValid      func() `in:"name" out:"ok,errReason,errCode"`
+		IsPasswordValid      func() `in:"password" out:"ok,errReason,errCode"`
+		GetPasswordPublicKey func() `out:"publicKey"`
+		AllowGuestAccount    func() `in:"allow"`
+		CreateGuestAccount   func() `out:"user"`
+		GetGroups            func() `out:"groups"`
+		GetPresetGroups      func() `in:"accountType" out:"groups"`
 	}
 }
 
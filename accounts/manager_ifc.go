@@ -23,14 +23,15 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"os"
+	"strings"
 	"time"
 
 	dbus "github.com/godbus/dbus"
 	"pkg.deepin.io/dde/daemon/accounts/checkers"
+	"pkg.deepin.io/dde/daemon/accounts/logined"
 	"pkg.deepin.io/dde/daemon/accounts/users"
 	"pkg.deepin.io/lib/dbusutil"
-	"pkg.deepin.io/lib/gettext"
-	"pkg.deepin.io/lib/procfs"
 	dutils "pkg.deepin.io/lib/utils"
 )
 
@@ -147,6 +148,16 @@ func (m *Manager) DeleteUser(sender dbus.Sender,
 		_ = users.SetAutoLoginUser("", "")
 	}
 
+	// 新建同名用户时不应该继承历史密码
+	err = os.Remove(getPasswordHistoryFile(name))
+	if err != nil && !os.IsNotExist(err) {
+		logger.Warning("failed to remove password history:", err)
+	}
+	err = logined.DefaultHistory.Remove(name)
+	if err != nil {
+		logger.Warning("failed to remove login history:", err)
+	}
+
 	//delete user config and icons
 	if rmFiles {
 		user.clearData()
@@ -207,19 +218,11 @@ func (m *Manager) IsUsernameValid(sender dbus.Sender, name string) (valid bool,
 		busErr = dbusutil.ToError(err)
 	}()
 
-	pid, err := m.service.GetConnPID(string(sender))
+	locale, err := getSenderLocale(m.service, sender)
 	if err != nil {
 		return
 	}
 
-	p := procfs.Process(pid)
-	environ, err := p.Environ()
-	if err != nil {
-		return
-	}
-
-	locale := environ.Get("LANG")
-
 	info := checkers.CheckUsernameValid(name)
 	if info == nil {
 		valid = true
@@ -229,8 +232,7 @@ func (m *Manager) IsUsernameValid(sender dbus.Sender, name string) (valid bool,
 	msg = info.Error.Error()
 	logger.Debug("locale:", locale)
 	if locale != "" {
-		gettext.SetLocale(gettext.LcAll, locale)
-		msg = gettext.Tr(msg)
+		msg = trLocale(locale, msg)
 	}
 	code = int32(info.Code)
 	return
@@ -243,11 +245,31 @@ func (m *Manager) IsUsernameValid(sender dbus.Sender, name string) (valid bool,
 // ret1: 提示信息
 //
 // ret2: 不合法代码
-func (m *Manager) IsPasswordValid(password string) (bool, string, int32, *dbus.Error) {
-	releaseType := getDeepinReleaseType()
-	logger.Infof("release type %q", releaseType)
-	errCode := checkers.CheckPasswordValid(releaseType, password)
-	return errCode.IsOk(), errCode.Prompt(), int32(errCode), nil
+//
+// 按密码策略检查，不包括用户名和历史密码，提示信息包含违反的所有规则，每行一条，
+// 不合法代码为违反的第一条规则的代码
+func (m *Manager) IsPasswordValid(sender dbus.Sender, password string) (bool, string, int32, *dbus.Error) {
+	violations := getPasswordPolicy().Check("", password, nil)
+	if len(violations) == 0 {
+		return true, "", 0, nil
+	}
+
+	locale, err := getSenderLocale(m.service, sender)
+	if err != nil {
+		logger.Warning(err)
+	}
+	msgs := localizePasswordViolations(violations, locale)
+	return false, strings.Join(msgs, "\n"), int32(violations[0].Code), nil
+}
+
+// GetPasswordPublicKey 返回 PEM 格式的 RSA 公钥，用于加密 User 的 SetSealedPassword 传输的密码
+func (m *Manager) GetPasswordPublicKey() (string, *dbus.Error) {
+	key, err := getPasswordPublicKeyPEM()
+	if err != nil {
+		logger.Warning(err)
+		return "", dbusutil.ToError(err)
+	}
+	return key, nil
 }
 
 func (m *Manager) AllowGuestAccount(sender dbus.Sender, allow bool) *dbus.Error {
@@ -0,0 +1,135 @@
+package accounts
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	dbus "github.com/godbus/dbus"
+	"pkg.deepin.io/dde/daemon/accounts/checkers"
+	"pkg.deepin.io/dde/daemon/accounts/users"
+	"pkg.deepin.io/lib/dbusutil"
+	"pkg.deepin.io/lib/procfs"
+)
+
+const (
+	passwordPolicyFile = "/etc/deepin/dde-daemon/password-policy.json"
+	// 每个用户一个文件，保存最近使用过的已加密的密码
+	passwordHistoryDir = "/var/lib/AccountsService/deepin/password-history"
+)
+
+// isPasswordPolicyConfigured 判断管理员是否配置了策略文件
+func isPasswordPolicyConfigured() bool {
+	_, err := os.Stat(passwordPolicyFile)
+	return err == nil
+}
+
+func getPasswordPolicy() *checkers.PasswordPolicy {
+	releaseType := getDeepinReleaseType()
+	policy, err := checkers.LoadPasswordPolicy(passwordPolicyFile, releaseType)
+	if err != nil {
+		logger.Warning("failed to load password policy:", err)
+		return checkers.GetDefaultPasswordPolicy(releaseType)
+	}
+	return policy
+}
+
+func getSenderLocale(service *dbusutil.Service, sender dbus.Sender) (string, error) {
+	pid, err := service.GetConnPID(string(sender))
+	if err != nil {
+		return "", err
+	}
+
+	p := procfs.Process(pid)
+	environ, err := p.Environ()
+	if err != nil {
+		return "", err
+	}
+	return environ.Get("LANG"), nil
+}
+
+// localizePasswordViolations 返回违反规则的提示，locale 为空时不翻译
+func localizePasswordViolations(violations []*checkers.PasswordViolation, locale string) []string {
+	var tr func(string) string
+	if locale != "" {
+		tr = func(msgid string) string {
+			return trLocale(locale, msgid)
+		}
+	}
+	result := make([]string, len(violations))
+	for i, v := range violations {
+		result[i] = v.Message(tr)
+	}
+	return result
+}
+
+type passwordViolationInfo struct {
+	Code    int32
+	Message string
+}
+
+func getPasswordHistoryFile(username string) string {
+	return filepath.Join(passwordHistoryDir, username)
+}
+
+// loadPasswordHistory 返回用户最近使用过的已加密的密码，最新的在前面
+func loadPasswordHistory(file string) ([]string, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var history []string
+	err = json.Unmarshal(content, &history)
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// getUserPasswordHistory 返回策略需要检查的历史密码，策略不检查时返回 nil
+func getUserPasswordHistory(username string, policy *checkers.PasswordPolicy) []string {
+	if policy.HistorySize <= 0 {
+		return nil
+	}
+	history, err := loadPasswordHistory(getPasswordHistoryFile(username))
+	if err != nil && !os.IsNotExist(err) {
+		logger.Warning("failed to load password history:", err)
+	}
+	return history
+}
+
+func savePasswordHistory(file string, history []string) error {
+	err := os.MkdirAll(filepath.Dir(file), 0700)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, content, 0600)
+}
+
+// addPasswordHistory 把新的密码放在最前面，最多保留 size 个
+func addPasswordHistory(history []string, encrypted string, size int) []string {
+	result := []string{encrypted}
+	for _, v := range history {
+		if len(result) >= size {
+			break
+		}
+		if v != encrypted {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func isPasswordInHistory(history []string, password string) bool {
+	for _, encrypted := range history {
+		if users.VerifyPasswd(password, encrypted) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package accounts
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"sync"
+)
+
+// 客户端用 GetPasswordPublicKey 返回的公钥以 RSA-OAEP(SHA-256) 加密明文密码，
+// 再通过 SetSealedPassword 传输，这样密码不以明文经过总线，服务端解密后仍能按策略检查。
+// 私钥只保存在内存中，服务重启后重新生成。
+
+const passwordKeyBits = 2048
+
+var passwordKey struct {
+	once sync.Once
+	key  *rsa.PrivateKey
+	err  error
+}
+
+func getPasswordKey() (*rsa.PrivateKey, error) {
+	passwordKey.once.Do(func() {
+		passwordKey.key, passwordKey.err = rsa.GenerateKey(rand.Reader, passwordKeyBits)
+	})
+	return passwordKey.key, passwordKey.err
+}
+
+// getPasswordPublicKeyPEM 返回 PEM 格式的公钥
+func getPasswordPublicKeyPEM() (string, error) {
+	key, err := getPasswordKey()
+	if err != nil {
+		return "", err
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: der,
+	})), nil
+}
+
+func openSealedPassword(sealed []byte) (string, error) {
+	key, err := getPasswordKey()
+	if err != nil {
+		return "", err
+	}
+	data, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, key, sealed, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
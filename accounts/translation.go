@@ -0,0 +1,170 @@
+package accounts
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// accounts 同时处理不同用户的请求，按调用者的语言翻译提示时直接读取 mo 文件，
+// 不修改进程全局的 locale
+
+const (
+	localeDir  = "/usr/share/locale"
+	textDomain = "dde-daemon"
+
+	moMagic        = 0x950412de
+	moHeaderLength = 20
+)
+
+type translator struct {
+	dir    string
+	domain string
+
+	mu       sync.Mutex
+	catalogs map[string]map[string]string // key 为 mo 文件所在的语言目录，如 zh_CN
+}
+
+func newTranslator(dir, domain string) *translator {
+	return &translator{
+		dir:      dir,
+		domain:   domain,
+		catalogs: make(map[string]map[string]string),
+	}
+}
+
+var defaultTranslator = newTranslator(localeDir, textDomain)
+
+// trLocale 用 locale 的翻译翻译 msgid，没有翻译时返回 msgid
+func trLocale(locale, msgid string) string {
+	return defaultTranslator.tr(locale, msgid)
+}
+
+func (t *translator) tr(locale, msgid string) string {
+	for _, lang := range getLocaleLangs(locale) {
+		if msgstr, ok := t.getCatalog(lang)[msgid]; ok && msgstr != "" {
+			return msgstr
+		}
+	}
+	return msgid
+}
+
+// getCatalog 返回语言 lang 的翻译，加载失败时返回空的翻译，之后不再加载
+func (t *translator) getCatalog(lang string) map[string]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	catalog, ok := t.catalogs[lang]
+	if ok {
+		return catalog
+	}
+
+	file := filepath.Join(t.dir, lang, "LC_MESSAGES", t.domain+".mo")
+	data, err := ioutil.ReadFile(file)
+	if err == nil {
+		catalog, err = parseMo(data)
+		if err != nil {
+			logger.Warningf("failed to parse %q: %v", file, err)
+		}
+	}
+	if catalog == nil {
+		catalog = make(map[string]string)
+	}
+	t.catalogs[lang] = catalog
+	return catalog
+}
+
+// getLocaleLangs 返回 locale 依次查找的语言目录，
+// 如 zh_CN.UTF-8 返回 zh_CN 和 zh，sr_RS.UTF-8@latin 返回 sr_RS@latin、sr@latin、sr_RS 和 sr
+func getLocaleLangs(locale string) []string {
+	if locale == "" || locale == "C" || locale == "POSIX" ||
+		strings.ContainsAny(locale, "/") || strings.HasPrefix(locale, ".") {
+		return nil
+	}
+
+	var modifier string
+	if idx := strings.Index(locale, "@"); idx != -1 {
+		modifier = locale[idx:]
+		locale = locale[:idx]
+	}
+	if idx := strings.Index(locale, "."); idx != -1 {
+		locale = locale[:idx]
+	}
+	lang := locale
+	if idx := strings.Index(locale, "_"); idx != -1 {
+		lang = locale[:idx]
+	}
+
+	var result []string
+	add := func(str string) {
+		for _, v := range result {
+			if v == str {
+				return
+			}
+		}
+		result = append(result, str)
+	}
+	if modifier != "" {
+		add(locale + modifier)
+		add(lang + modifier)
+	}
+	add(locale)
+	add(lang)
+	return result
+}
+
+// parseMo 解析 GNU gettext 的 mo 文件，有上下文的消息和复数形式只保留 msgid 和第一个翻译
+func parseMo(data []byte) (map[string]string, error) {
+	if len(data) < moHeaderLength {
+		return nil, errors.New("invalid mo file")
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if order.Uint32(data) != moMagic {
+		order = binary.BigEndian
+		if order.Uint32(data) != moMagic {
+			return nil, errors.New("invalid mo file magic")
+		}
+	}
+
+	count := order.Uint32(data[8:])
+	origTable := order.Uint32(data[12:])
+	transTable := order.Uint32(data[16:])
+	getString := func(table, idx uint32) (string, error) {
+		pos := uint64(table) + uint64(idx)*8
+		if pos+8 > uint64(len(data)) {
+			return "", errors.New("invalid mo file string table")
+		}
+		length := uint64(order.Uint32(data[pos:]))
+		offset := uint64(order.Uint32(data[pos+4:]))
+		if offset+length > uint64(len(data)) {
+			return "", errors.New("invalid mo file string")
+		}
+		return string(data[offset : offset+length]), nil
+	}
+
+	result := make(map[string]string, count)
+	for i := uint32(0); i < count; i++ {
+		msgid, err := getString(origTable, i)
+		if err != nil {
+			return nil, err
+		}
+		msgstr, err := getString(transTable, i)
+		if err != nil {
+			return nil, err
+		}
+		// 第一项是文件头
+		if msgid == "" || strings.Contains(msgid, "\x04") {
+			continue
+		}
+		if idx := strings.Index(msgid, "\x00"); idx != -1 {
+			msgid = msgid[:idx]
+		}
+		if idx := strings.Index(msgstr, "\x00"); idx != -1 {
+			msgstr = msgstr[:idx]
+		}
+		result[msgid] = msgstr
+	}
+	return result, nil
+}
@@ -148,6 +148,10 @@ type User struct {
 		SetHomeDir            func() `in:"home"`
 		SetShell              func() `in:"shell"`
 		SetPassword           func() `in:"password"`
+		SetSealedPassword     func() `in:"sealedPassword"`
+		CheckPasswordPolicy   func() `in:"password" out:"violations"`
+		GetLoginHistory       func() `in:"limit" out:"records"`
+		GetFailedLogins       func() `in:"limit" out:"attempts"`
 		SetAccountType        func() `in:"accountType"`
 		SetLocked             func() `in:"locked"`
 		SetAutomaticLogin     func() `in:"enabled"`
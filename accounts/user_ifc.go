@@ -20,6 +20,7 @@
 package accounts
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -139,6 +140,8 @@ func (u *User) SetShell(sender dbus.Sender, shell string) *dbus.Error {
 	return nil
 }
 
+// SetPassword 设置密码，password 为 crypt(3) 加密后的密码。
+// 加密后的密码无法按策略检查，管理员配置了策略时不接受，应使用 SetSealedPassword。
 func (u *User) SetPassword(sender dbus.Sender, password string) *dbus.Error {
 	logger.Debug("[SetPassword] start ...")
 
@@ -148,6 +151,63 @@ func (u *User) SetPassword(sender dbus.Sender, password string) *dbus.Error {
 		return dbusutil.ToError(err)
 	}
 
+	if isPasswordPolicyConfigured() && getPasswordPolicy().HasRules() {
+		return dbusutil.ToError(errors.New("encrypted password can not be checked by password policy, use SetSealedPassword instead"))
+	}
+
+	err = u.modifyPassword(password)
+	return dbusutil.ToError(err)
+}
+
+// SetSealedPassword 设置密码，sealedPassword 为用 Manager 的 GetPasswordPublicKey 返回的公钥
+// 以 RSA-OAEP(SHA-256) 加密的明文密码，解密后按策略检查再加密保存
+func (u *User) SetSealedPassword(sender dbus.Sender, sealedPassword []byte) *dbus.Error {
+	logger.Debug("[SetSealedPassword] start ...")
+
+	err := u.checkAuth(sender, false, "")
+	if err != nil {
+		logger.Debug("[SetSealedPassword] access denied:", err)
+		return dbusutil.ToError(err)
+	}
+
+	password, err := openSealedPassword(sealedPassword)
+	if err != nil {
+		logger.Warning("[SetSealedPassword] failed to open sealed password:", err)
+		return dbusutil.ToError(errors.New("invalid sealed password"))
+	}
+
+	policy := getPasswordPolicy()
+	history := getUserPasswordHistory(u.UserName, policy)
+	violations := policy.Check(u.UserName, password, func(passwd string) bool {
+		return isPasswordInHistory(history, passwd)
+	})
+	if len(violations) > 0 {
+		locale, err := getSenderLocale(u.service, sender)
+		if err != nil {
+			logger.Warning(err)
+		}
+		msgs := localizePasswordViolations(violations, locale)
+		return dbusutil.ToError(errors.New(strings.Join(msgs, "\n")))
+	}
+
+	encrypted := users.EncodePasswd(password)
+	err = u.modifyPassword(encrypted)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+
+	if policy.HistorySize > 0 {
+		history = addPasswordHistory(history, encrypted, policy.HistorySize)
+		err = savePasswordHistory(getPasswordHistoryFile(u.UserName), history)
+		if err != nil {
+			logger.Warning("failed to save password history:", err)
+		}
+	}
+	return nil
+}
+
+// modifyPassword 把用户的密码改为加密后的密码 encrypted，并解锁用户
+func (u *User) modifyPassword(encrypted string) error {
 	var count = 10
 	for {
 		_, err := users.GetShadowInfo(u.UserName)
@@ -157,14 +217,14 @@ func (u *User) SetPassword(sender dbus.Sender, password string) *dbus.Error {
 		}
 		count--
 		if count == 0 {
-			return dbusutil.ToError(errors.New("shadow file error"))
+			return errors.New("shadow file error")
 		}
 		time.Sleep(time.Second)
 	}
 
-	if err := users.ModifyPasswd(password, u.UserName); err != nil {
+	if err := users.ModifyPasswd(encrypted, u.UserName); err != nil {
 		logger.Warning("DoAction: modify password failed:", err)
-		return dbusutil.ToError(err)
+		return err
 	}
 
 	u.PropsMu.Lock()
@@ -173,7 +233,7 @@ func (u *User) SetPassword(sender dbus.Sender, password string) *dbus.Error {
 	if u.Locked {
 		if err := users.LockedUser(false, u.UserName); err != nil {
 			logger.Warning("DoAction: unlock user failed:", err)
-			return dbusutil.ToError(err)
+			return err
 		}
 		u.Locked = false
 		_ = u.emitPropChangedLocked(false)
@@ -181,6 +241,39 @@ func (u *User) SetPassword(sender dbus.Sender, password string) *dbus.Error {
 	return nil
 }
 
+// CheckPasswordPolicy 按密码策略检查用户的新密码，包括用户名和最近使用过的密码，
+// 返回违反的所有规则，结果为 JSON 数组，每项包含 Code 和翻译后的 Message
+func (u *User) CheckPasswordPolicy(sender dbus.Sender, password string) (string, *dbus.Error) {
+	err := u.checkAuth(sender, true, "")
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+
+	policy := getPasswordPolicy()
+	history := getUserPasswordHistory(u.UserName, policy)
+	violations := policy.Check(u.UserName, password, func(passwd string) bool {
+		return isPasswordInHistory(history, passwd)
+	})
+
+	locale, err := getSenderLocale(u.service, sender)
+	if err != nil {
+		logger.Warning(err)
+	}
+	msgs := localizePasswordViolations(violations, locale)
+	infos := make([]passwordViolationInfo, len(violations))
+	for i, v := range violations {
+		infos[i] = passwordViolationInfo{
+			Code:    int32(v.Code),
+			Message: msgs[i],
+		}
+	}
+	data, err := json.Marshal(infos)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return string(data), nil
+}
+
 func (u *User) SetMaxPasswordAge(sender dbus.Sender, nDays int32) *dbus.Error {
 	err := u.checkAuth(sender, false, "")
 	if err != nil {
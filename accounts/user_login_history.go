@@ -0,0 +1,47 @@
+package accounts
+
+import (
+	"encoding/json"
+
+	dbus "github.com/godbus/dbus"
+	"pkg.deepin.io/dde/daemon/accounts/logined"
+	"pkg.deepin.io/lib/dbusutil"
+)
+
+// GetLoginHistory 返回用户最近的 limit 条登录记录，limit 为 0 时返回全部，
+// 结果为 logined.LoginRecord 数组的 JSON，最新的在前面。只有用户自己和管理员可以查看。
+func (u *User) GetLoginHistory(sender dbus.Sender, limit uint32) (string, *dbus.Error) {
+	err := u.checkAuth(sender, true, "")
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+
+	records, err := logined.DefaultHistory.GetLogins(u.UserName, int(limit))
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	data, err := json.Marshal(records)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return string(data), nil
+}
+
+// GetFailedLogins 返回用户最近的 limit 次认证失败，limit 为 0 时返回全部，
+// 结果为 logined.FailedAttempt 数组的 JSON，最新的在前面。只有用户自己和管理员可以查看。
+func (u *User) GetFailedLogins(sender dbus.Sender, limit uint32) (string, *dbus.Error) {
+	err := u.checkAuth(sender, true, "")
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+
+	attempts, err := logined.DefaultHistory.GetFailedAttempts(u.UserName, int(limit))
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	data, err := json.Marshal(attempts)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return string(data), nil
+}
@@ -24,6 +24,7 @@ package users
 #cgo LDFLAGS: -lcrypt
 
 #include <stdlib.h>
+#include <crypt.h>
 #include "passwd.h"
 */
 import "C"
@@ -39,15 +40,38 @@ import (
 
 var (
 	wLocker sync.Mutex
+	// crypt 的结果保存在静态变量中，不能同时调用
+	cryptLocker sync.Mutex
 )
 
 func EncodePasswd(words string) string {
 	cwords := C.CString(words)
 	defer C.free(unsafe.Pointer(cwords))
 
+	cryptLocker.Lock()
+	defer cryptLocker.Unlock()
 	return C.GoString(C.mkpasswd(cwords))
 }
 
+// VerifyPasswd 判断明文密码 words 是否与已加密的密码 crypted 相同
+func VerifyPasswd(words, crypted string) bool {
+	if crypted == "" {
+		return false
+	}
+	cwords := C.CString(words)
+	defer C.free(unsafe.Pointer(cwords))
+	csetting := C.CString(crypted)
+	defer C.free(unsafe.Pointer(csetting))
+
+	cryptLocker.Lock()
+	defer cryptLocker.Unlock()
+	result := C.crypt(cwords, csetting)
+	if result == nil {
+		return false
+	}
+	return C.GoString(result) == crypted
+}
+
 // password: has been crypt
 func updatePasswd(password, username string) error {
 	status := C.lock_shadow_file()
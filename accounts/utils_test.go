@@ -20,6 +20,16 @@
 package accounts
 
 import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -74,3 +84,120 @@ func TestGetValueFromLine(t *testing.T) {
 	ret := getValueFromLine("testdata/shells", "/")
 	assert.Equal(t, ret, "shells")
 }
+
+func TestSealedPassword(t *testing.T) {
+	keyPEM, err := getPasswordPublicKeyPEM()
+	assert.Nil(t, err)
+	block, _ := pem.Decode([]byte(keyPEM))
+	assert.NotNil(t, block)
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	assert.Nil(t, err)
+
+	sealed, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub.(*rsa.PublicKey), []byte("Password-123"), nil)
+	assert.Nil(t, err)
+	password, err := openSealedPassword(sealed)
+	assert.Nil(t, err)
+	assert.Equal(t, password, "Password-123")
+
+	_, err = openSealedPassword([]byte("Password-123"))
+	assert.NotNil(t, err)
+}
+
+func TestPasswordHistory(t *testing.T) {
+	history := addPasswordHistory(nil, "p1", 3)
+	assert.Equal(t, history, []string{"p1"})
+	history = addPasswordHistory(history, "p2", 3)
+	history = addPasswordHistory(history, "p3", 3)
+	history = addPasswordHistory(history, "p4", 3)
+	assert.Equal(t, history, []string{"p4", "p3", "p2"})
+	history = addPasswordHistory(history, "p2", 3)
+	assert.Equal(t, history, []string{"p2", "p4", "p3"})
+	history = addPasswordHistory(history, "p5", 1)
+	assert.Equal(t, history, []string{"p5"})
+
+	dir, err := ioutil.TempDir("", "password-history")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "history/test1")
+
+	_, err = loadPasswordHistory(file)
+	assert.True(t, os.IsNotExist(err))
+	err = savePasswordHistory(file, []string{"p2", "p1"})
+	assert.Nil(t, err)
+	history, err = loadPasswordHistory(file)
+	assert.Nil(t, err)
+	assert.Equal(t, history, []string{"p2", "p1"})
+}
+
+func TestGetLocaleLangs(t *testing.T) {
+	var tests = []struct {
+		locale string
+		langs  []string
+	}{
+		{"zh_CN.UTF-8", []string{"zh_CN", "zh"}},
+		{"en_US", []string{"en_US", "en"}},
+		{"de", []string{"de"}},
+		{"sr_RS.UTF-8@latin", []string{"sr_RS@latin", "sr@latin", "sr_RS", "sr"}},
+		{"", nil},
+		{"C", nil},
+		{"../../etc", nil},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.langs, getLocaleLangs(test.locale), test.locale)
+	}
+}
+
+// writeMoFile 生成只包含 msgs 的 mo 文件，msgs 的 key 需要按顺序排列
+func writeMoFile(t *testing.T, file string, msgids, msgstrs []string) {
+	n := uint32(len(msgids))
+	origTable := uint32(moHeaderLength)
+	transTable := origTable + n*8
+	offset := transTable + n*8
+
+	var strs bytes.Buffer
+	var tables [2][]uint32
+	for i, list := range [][]string{msgids, msgstrs} {
+		for _, str := range list {
+			tables[i] = append(tables[i], uint32(len(str)), offset+uint32(strs.Len()))
+			strs.WriteString(str)
+			strs.WriteByte(0)
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, v := range []uint32{moMagic, 0, n, origTable, transTable} {
+		_ = binary.Write(&buf, binary.LittleEndian, v)
+	}
+	_ = binary.Write(&buf, binary.LittleEndian, tables[0])
+	_ = binary.Write(&buf, binary.LittleEndian, tables[1])
+	buf.Write(strs.Bytes())
+
+	err := os.MkdirAll(filepath.Dir(file), 0755)
+	assert.Nil(t, err)
+	err = ioutil.WriteFile(file, buf.Bytes(), 0644)
+	assert.Nil(t, err)
+}
+
+func TestTranslator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "locale")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	writeMoFile(t, filepath.Join(dir, "zh_CN", "LC_MESSAGES", "test.mo"),
+		[]string{"", "Hello", "Password\x00Passwords"},
+		[]string{"Content-Type: text/plain; charset=UTF-8\n", "你好", "密码\x00密码"})
+	writeMoFile(t, filepath.Join(dir, "zh", "LC_MESSAGES", "test.mo"),
+		[]string{"Bye"}, []string{"再见"})
+
+	tr := newTranslator(dir, "test")
+	assert.Equal(t, "你好", tr.tr("zh_CN.UTF-8", "Hello"))
+	assert.Equal(t, "密码", tr.tr("zh_CN.UTF-8", "Password"))
+	assert.Equal(t, "再见", tr.tr("zh_CN.UTF-8", "Bye"))
+	assert.Equal(t, "Hello", tr.tr("en_US.UTF-8", "Hello"))
+	assert.Equal(t, "Unknown", tr.tr("zh_CN.UTF-8", "Unknown"))
+	assert.Equal(t, "Hello", tr.tr("", "Hello"))
+
+	_, err = parseMo([]byte("not a mo file, not a mo file"))
+	assert.NotNil(t, err)
+}
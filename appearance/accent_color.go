@@ -0,0 +1,230 @@
+package appearance
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/nfnt/resize"
+)
+
+const (
+	accentSampleSize  = 64
+	accentPaletteSize = 5
+	// 与白色文字的最小对比度，参考 WCAG 对界面元素的要求
+	accentMinContrast = 3.0
+	// 调色板中两个颜色在 RGB 空间中的最小距离
+	accentMinDistance = 48
+)
+
+type accentBucket struct {
+	r, g, b float64
+	count   int
+	score   float64
+}
+
+func (b *accentBucket) color() color.NRGBA {
+	n := float64(b.count)
+	return color.NRGBA{
+		R: uint8(b.r/n + 0.5),
+		G: uint8(b.g/n + 0.5),
+		B: uint8(b.b/n + 0.5),
+		A: 255,
+	}
+}
+
+// extractPalette 从图片中提取最多 n 个适合作为强调色的主要颜色，按重要程度排序；
+// 过暗、过亮或饱和度过低的颜色不会被选中，所以灰度图片返回空
+func extractPalette(img image.Image, n int) []color.NRGBA {
+	img = resize.Thumbnail(accentSampleSize, accentSampleSize, img, resize.Bilinear)
+	b := img.Bounds()
+
+	// 每个通道取高 4 位分组
+	buckets := make(map[uint16]*accentBucket)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A < 128 {
+				continue
+			}
+			key := uint16(c.R>>4)<<8 | uint16(c.G>>4)<<4 | uint16(c.B>>4)
+			bucket := buckets[key]
+			if bucket == nil {
+				bucket = &accentBucket{}
+				buckets[key] = bucket
+			}
+			bucket.r += float64(c.R)
+			bucket.g += float64(c.G)
+			bucket.b += float64(c.B)
+			bucket.count++
+		}
+	}
+
+	candidates := make([]*accentBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		_, s, l := rgbToHsl(bucket.color())
+		if s < 0.15 || l < 0.1 || l > 0.9 {
+			continue
+		}
+		// 数量多、饱和度高、亮度适中的颜色优先
+		bucket.score = float64(bucket.count) * (0.2 + s) * (1 - math.Abs(l-0.5))
+		candidates = append(candidates, bucket)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].count > candidates[j].count
+	})
+
+	var palette []color.NRGBA
+	for _, bucket := range candidates {
+		if len(palette) >= n {
+			break
+		}
+		c := bucket.color()
+		distinct := true
+		for _, p := range palette {
+			if colorDistance(c, p) < accentMinDistance {
+				distinct = false
+				break
+			}
+		}
+		if distinct {
+			palette = append(palette, c)
+		}
+	}
+	return palette
+}
+
+func colorDistance(c1, c2 color.NRGBA) float64 {
+	dr := float64(c1.R) - float64(c2.R)
+	dg := float64(c1.G) - float64(c2.G)
+	db := float64(c1.B) - float64(c2.B)
+	return math.Sqrt(dr*dr + dg*dg + db*db)
+}
+
+// ensureContrast 降低颜色的亮度，直到白色文字在它上面的对比度不小于 minContrast
+func ensureContrast(c color.NRGBA, minContrast float64) color.NRGBA {
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	h, s, l := rgbToHsl(c)
+	for contrastRatio(c, white) < minContrast && l > 0 {
+		l -= 0.02
+		if l < 0 {
+			l = 0
+		}
+		c = hslToRgb(h, s, l)
+	}
+	return c
+}
+
+func relativeLuminance(c color.NRGBA) float64 {
+	channel := func(v uint8) float64 {
+		f := float64(v) / 255
+		if f <= 0.03928 {
+			return f / 12.92
+		}
+		return math.Pow((f+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.R) + 0.7152*channel(c.G) + 0.0722*channel(c.B)
+}
+
+func contrastRatio(c1, c2 color.NRGBA) float64 {
+	l1 := relativeLuminance(c1)
+	l2 := relativeLuminance(c2)
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+	return (l1 + 0.05) / (l2 + 0.05)
+}
+
+func rgbToHsl(c color.NRGBA) (h, s, l float64) {
+	r := float64(c.R) / 255
+	g := float64(c.G) / 255
+	b := float64(c.B) / 255
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	l = (max + min) / 2
+	if max == min {
+		return 0, 0, l
+	}
+
+	d := max - min
+	if l > 0.5 {
+		s = d / (2 - max - min)
+	} else {
+		s = d / (max + min)
+	}
+	switch max {
+	case r:
+		h = (g - b) / d
+		if g < b {
+			h += 6
+		}
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	h /= 6
+	return
+}
+
+func hslToRgb(h, s, l float64) color.NRGBA {
+	if s == 0 {
+		v := uint8(l*255 + 0.5)
+		return color.NRGBA{R: v, G: v, B: v, A: 255}
+	}
+
+	hueToRgb := func(p, q, t float64) float64 {
+		if t < 0 {
+			t++
+		}
+		if t > 1 {
+			t--
+		}
+		switch {
+		case t < 1.0/6:
+			return p + (q-p)*6*t
+		case t < 1.0/2:
+			return q
+		case t < 2.0/3:
+			return p + (q-p)*(2.0/3-t)*6
+		}
+		return p
+	}
+
+	var q float64
+	if l < 0.5 {
+		q = l * (1 + s)
+	} else {
+		q = l + s - l*s
+	}
+	p := 2*l - q
+	return color.NRGBA{
+		R: uint8(hueToRgb(p, q, h+1.0/3)*255 + 0.5),
+		G: uint8(hueToRgb(p, q, h)*255 + 0.5),
+		B: uint8(hueToRgb(p, q, h-1.0/3)*255 + 0.5),
+		A: 255,
+	}
+}
+
+func colorToHex(c color.NRGBA) string {
+	return byteArrayToHexColor([4]byte{c.R, c.G, c.B, c.A})
+}
+
+// getAccentPalette 返回图片的调色板，第一个颜色是经过对比度调整的强调色，
+// 其余是提取出的主要颜色
+func getAccentPalette(img image.Image) []string {
+	colors := extractPalette(img, accentPaletteSize)
+	if len(colors) == 0 {
+		return nil
+	}
+	palette := make([]string, 0, len(colors)+1)
+	palette = append(palette, colorToHex(ensureContrast(colors[0], accentMinContrast)))
+	for _, c := range colors {
+		palette = append(palette, colorToHex(c))
+	}
+	return palette
+}
@@ -0,0 +1,77 @@
+package appearance
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fillRect(img *image.NRGBA, r image.Rectangle, c color.NRGBA) {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+}
+
+func Test_extractPalette(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 200, 100))
+	blue := color.NRGBA{R: 30, G: 90, B: 200, A: 255}
+	orange := color.NRGBA{R: 230, G: 120, B: 20, A: 255}
+	gray := color.NRGBA{R: 128, G: 128, B: 128, A: 255}
+	fillRect(img, image.Rect(0, 0, 200, 100), gray)
+	fillRect(img, image.Rect(0, 0, 120, 100), blue)
+	fillRect(img, image.Rect(160, 0, 200, 100), orange)
+
+	palette := extractPalette(img, 5)
+	if assert.True(t, len(palette) >= 2) {
+		// 面积最大的颜色排在最前面，缩放时边缘混合出的颜色排在后面
+		assert.True(t, colorDistance(palette[0], blue) < 10)
+		assert.True(t, colorDistance(palette[1], orange) < 10)
+	}
+
+	// 灰度图片中没有合适的颜色
+	grayImg := image.NewNRGBA(image.Rect(0, 0, 100, 100))
+	fillRect(grayImg, grayImg.Bounds(), gray)
+	assert.Len(t, extractPalette(grayImg, 5), 0)
+	assert.Nil(t, getAccentPalette(grayImg))
+}
+
+func Test_getAccentPalette(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 100, 100))
+	yellow := color.NRGBA{R: 240, G: 220, B: 40, A: 255}
+	fillRect(img, img.Bounds(), yellow)
+
+	palette := getAccentPalette(img)
+	if assert.Len(t, palette, 2) {
+		// 调整后的强调色与白色文字有足够的对比度
+		array, err := parseHexColor(palette[0])
+		assert.Nil(t, err)
+		accent := color.NRGBA{R: array[0], G: array[1], B: array[2], A: array[3]}
+		white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+		assert.True(t, contrastRatio(accent, white) >= accentMinContrast)
+		assert.Equal(t, "#F0DC28", palette[1])
+	}
+}
+
+func Test_contrastRatio(t *testing.T) {
+	black := color.NRGBA{A: 255}
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	assert.InDelta(t, 21.0, contrastRatio(black, white), 0.01)
+	assert.InDelta(t, 1.0, contrastRatio(white, white), 0.01)
+}
+
+func Test_rgbHslConversion(t *testing.T) {
+	for _, c := range []color.NRGBA{
+		{R: 255, A: 255},
+		{G: 255, A: 255},
+		{B: 255, A: 255},
+		{R: 30, G: 90, B: 200, A: 255},
+		{R: 128, G: 128, B: 128, A: 255},
+	} {
+		h, s, l := rgbToHsl(c)
+		assert.Equal(t, c, hslToRgb(h, s, l))
+	}
+}
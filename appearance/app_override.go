@@ -0,0 +1,119 @@
+package appearance
+
+import (
+	"encoding/json"
+	"errors"
+
+	"pkg.deepin.io/dde/daemon/appearance/appoverride"
+	"pkg.deepin.io/dde/daemon/appearance/fonts"
+)
+
+// 按应用设置的字体和缩放，由 dock 等在启动应用时通过 GetAppEnvironment 获取环境变量
+
+func (m *Manager) setAppOverride(desktopId, value string) error {
+	desktopId, err := appoverride.NormalizeDesktopId(desktopId)
+	if err != nil {
+		return err
+	}
+	var o appoverride.Override
+	err = json.Unmarshal([]byte(value), &o)
+	if err != nil {
+		return err
+	}
+	err = o.Check()
+	if err != nil {
+		return err
+	}
+	if o.FontFamily != "" && !fonts.IsFontFamily(o.FontFamily) {
+		return errors.New("invalid font family " + o.FontFamily)
+	}
+
+	m.appOverrideMu.Lock()
+	defer m.appOverrideMu.Unlock()
+	cfg, err := appoverride.LoadConfig(appoverride.ConfigFile)
+	if err != nil {
+		return err
+	}
+	if o.IsEmpty() {
+		delete(cfg, desktopId)
+	} else {
+		cfg[desktopId] = &o
+	}
+	err = appoverride.WriteFontConfig(appoverride.FontConfigDir, desktopId, cfg[desktopId], m.FontSize.Get())
+	if err != nil {
+		return err
+	}
+	return cfg.Save(appoverride.ConfigFile)
+}
+
+func (m *Manager) removeAppOverride(desktopId string) error {
+	desktopId, err := appoverride.NormalizeDesktopId(desktopId)
+	if err != nil {
+		return err
+	}
+
+	m.appOverrideMu.Lock()
+	defer m.appOverrideMu.Unlock()
+	cfg, err := appoverride.LoadConfig(appoverride.ConfigFile)
+	if err != nil {
+		return err
+	}
+	if _, ok := cfg[desktopId]; !ok {
+		return nil
+	}
+	delete(cfg, desktopId)
+	err = appoverride.WriteFontConfig(appoverride.FontConfigDir, desktopId, nil, 0)
+	if err != nil {
+		return err
+	}
+	return cfg.Save(appoverride.ConfigFile)
+}
+
+func (m *Manager) listAppOverrides() (string, error) {
+	m.appOverrideMu.Lock()
+	cfg, err := appoverride.LoadConfig(appoverride.ConfigFile)
+	m.appOverrideMu.Unlock()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (m *Manager) getAppEnvironment(desktopId string) ([]string, error) {
+	m.appOverrideMu.Lock()
+	env, err := appoverride.GetEnviron(desktopId)
+	m.appOverrideMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if env == nil {
+		env = []string{}
+	}
+	return env, nil
+}
+
+// updateAppOverrideFontConfigs 在全局字体大小改变后重新生成应用的 fontconfig 配置片段，
+// 因为其中的缩放比例是相对于全局字体大小的
+func (m *Manager) updateAppOverrideFontConfigs(globalFontSize float64) {
+	m.appOverrideMu.Lock()
+	defer m.appOverrideMu.Unlock()
+	cfg, err := appoverride.LoadConfig(appoverride.ConfigFile)
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+	for _, desktopId := range cfg.DesktopIds() {
+		o := cfg[desktopId]
+		if o.FontSize == 0 {
+			continue
+		}
+		err = appoverride.WriteFontConfig(appoverride.FontConfigDir, desktopId, o, globalFontSize)
+		if err != nil {
+			logger.Warningf("failed to write font config of %q: %v", desktopId, err)
+		}
+	}
+}
@@ -63,6 +63,9 @@ func HandlePrepareForSleep(sleep bool) {
 		for monitorSpace := range cfg {
 			if cfg[monitorSpace] == wsPolicyWakeup {
 				_m.autoChangeBg(monitorSpace, time.Now())
+			} else if isExtendedWSPolicy(cfg[monitorSpace]) {
+				// 睡眠期间定时器可能错过了更换壁纸的时间，重新计算
+				_m.startWSScheduler(monitorSpace, cfg[monitorSpace])
 			}
 		}
 	}
@@ -100,6 +103,22 @@ func (*Module) start() error {
 			return dbusutil.ToError(errors.New("type is not string"))
 		}
 		err = _m.setQtActiveColor(value)
+		if err == nil {
+			// 手动设置强调色后不再自动设置
+			_m.disableAutoAccentColor()
+		}
+		return dbusutil.ToError(err)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = so.SetWriteCallback(_m, propAutoAccentColor, func(write *dbusutil.PropertyWrite) *dbus.Error {
+		value, ok := write.Value.(bool)
+		if !ok {
+			return dbusutil.ToError(errors.New("type is not bool"))
+		}
+		err := _m.setAutoAccentColor(value)
 		return dbusutil.ToError(err)
 	})
 	if err != nil {
@@ -0,0 +1,237 @@
+// Package appoverride 管理按应用设置的字体和缩放，
+// 这些设置保存在配置文件中，在启动应用时通过环境变量和 fontconfig 配置片段生效。
+package appoverride
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"pkg.deepin.io/lib/xdg/basedir"
+)
+
+const (
+	desktopExt = ".desktop"
+
+	minScaleFactor = 0.5
+	maxScaleFactor = 4.0
+	minDPI         = 48
+	maxDPI         = 480
+	minFontSize    = 7.0
+	maxFontSize    = 22.0
+
+	baseDPI = 96
+)
+
+var (
+	ConfigFile    = filepath.Join(basedir.GetUserConfigDir(), "deepin/dde-daemon/appearance/app-overrides.json")
+	FontConfigDir = filepath.Join(basedir.GetUserConfigDir(), "deepin/dde-daemon/appearance/app-overrides")
+)
+
+// Override 是一个应用的设置，值为零的字段使用全局设置
+type Override struct {
+	FontFamily  string  `json:",omitempty"`
+	FontSize    float64 `json:",omitempty"`
+	ScaleFactor float64 `json:",omitempty"`
+	DPI         int32   `json:",omitempty"`
+}
+
+func (o *Override) Check() error {
+	if o.FontSize != 0 && (o.FontSize < minFontSize || o.FontSize > maxFontSize) {
+		return fmt.Errorf("invalid font size %v", o.FontSize)
+	}
+	if o.ScaleFactor != 0 && (o.ScaleFactor < minScaleFactor || o.ScaleFactor > maxScaleFactor) {
+		return fmt.Errorf("invalid scale factor %v", o.ScaleFactor)
+	}
+	if o.DPI != 0 && (o.DPI < minDPI || o.DPI > maxDPI) {
+		return fmt.Errorf("invalid dpi %v", o.DPI)
+	}
+	if strings.ContainsAny(o.FontFamily, "<>&\"\n") {
+		return fmt.Errorf("invalid font family %q", o.FontFamily)
+	}
+	return nil
+}
+
+func (o *Override) IsEmpty() bool {
+	return *o == Override{}
+}
+
+// NeedFontConfig 返回是否需要为应用生成 fontconfig 配置片段
+func (o *Override) NeedFontConfig() bool {
+	return o.FontFamily != "" || o.FontSize != 0
+}
+
+// Environ 返回启动应用时需要设置的环境变量，fontConfigFile 是应用的 fontconfig 配置片段
+func (o *Override) Environ(fontConfigFile string) []string {
+	var env []string
+	gdkDpiScale := 1.0
+	if o.ScaleFactor != 0 {
+		env = append(env, "QT_SCALE_FACTOR="+formatFloat(o.ScaleFactor))
+		// GDK_SCALE 只支持整数，剩余的部分通过 GDK_DPI_SCALE 缩放字体
+		gdkScale := int(o.ScaleFactor)
+		if gdkScale < 1 {
+			gdkScale = 1
+		}
+		env = append(env, "GDK_SCALE="+strconv.Itoa(gdkScale))
+		gdkDpiScale = o.ScaleFactor / float64(gdkScale)
+	}
+	if o.DPI != 0 {
+		env = append(env, "QT_FONT_DPI="+strconv.Itoa(int(o.DPI)))
+		gdkDpiScale *= float64(o.DPI) / baseDPI
+	}
+	if o.ScaleFactor != 0 || o.DPI != 0 {
+		env = append(env, "GDK_DPI_SCALE="+formatFloat(gdkDpiScale))
+	}
+	if o.NeedFontConfig() && fontConfigFile != "" {
+		env = append(env, "FONTCONFIG_FILE="+fontConfigFile)
+	}
+	return env
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// FontConfigContent 返回应用的 fontconfig 配置片段，它先包含系统的配置，
+// 再优先使用应用的字体，并按照 globalFontSize 缩放字体大小
+func (o *Override) FontConfigContent(globalFontSize float64) string {
+	var buf strings.Builder
+	buf.WriteString(`<?xml version="1.0"?>
+<!DOCTYPE fontconfig SYSTEM "fonts.dtd">
+<!-- Generated by dde-daemon, do not edit. -->
+<fontconfig>
+    <include ignore_missing="yes">/etc/fonts/fonts.conf</include>
+`)
+	if o.FontFamily != "" {
+		for _, generic := range []string{"sans-serif", "serif"} {
+			fmt.Fprintf(&buf, `
+    <match target="pattern">
+        <test qual="any" name="family">
+            <string>%s</string>
+        </test>
+        <edit name="family" mode="prepend" binding="strong">
+            <string>%s</string>
+        </edit>
+    </match>
+`, generic, o.FontFamily)
+		}
+	}
+	if o.FontSize != 0 && globalFontSize > 0 && o.FontSize != globalFontSize {
+		fmt.Fprintf(&buf, `
+    <match target="font">
+        <edit name="pixelsize" mode="assign">
+            <times>
+                <name>pixelsize</name>
+                <double>%s</double>
+            </times>
+        </edit>
+    </match>
+`, strconv.FormatFloat(o.FontSize/globalFontSize, 'f', 4, 64))
+	}
+	buf.WriteString("</fontconfig>\n")
+	return buf.String()
+}
+
+// NormalizeDesktopId 检查应用的 desktop id，没有 .desktop 后缀时添加后缀
+func NormalizeDesktopId(desktopId string) (string, error) {
+	if desktopId == "" {
+		return "", errors.New("desktop id is empty")
+	}
+	if strings.ContainsAny(desktopId, "/\x00") || strings.HasPrefix(desktopId, ".") {
+		return "", fmt.Errorf("invalid desktop id %q", desktopId)
+	}
+	if !strings.HasSuffix(desktopId, desktopExt) {
+		desktopId += desktopExt
+	}
+	return desktopId, nil
+}
+
+func GetFontConfigFile(dir, desktopId string) string {
+	return filepath.Join(dir, strings.TrimSuffix(desktopId, desktopExt)+".conf")
+}
+
+// Config 的键为应用的 desktop id
+type Config map[string]*Override
+
+// LoadConfig 读取配置文件，文件不存在时返回空的配置
+func LoadConfig(filename string) (Config, error) {
+	cfg := make(Config)
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	err = json.Unmarshal(data, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (cfg Config) Save(filename string) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return err
+	}
+	tmpFile := filename + ".tmp"
+	err = ioutil.WriteFile(tmpFile, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, filename)
+}
+
+func (cfg Config) DesktopIds() []string {
+	ids := make([]string, 0, len(cfg))
+	for id := range cfg {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// WriteFontConfig 生成或删除应用的 fontconfig 配置片段
+func WriteFontConfig(dir, desktopId string, o *Override, globalFontSize float64) error {
+	filename := GetFontConfigFile(dir, desktopId)
+	if o == nil || !o.NeedFontConfig() {
+		err := os.Remove(filename)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, []byte(o.FontConfigContent(globalFontSize)), 0644)
+}
+
+// GetEnviron 返回启动应用 desktopId 时需要设置的环境变量，没有设置时返回 nil
+func GetEnviron(desktopId string) ([]string, error) {
+	desktopId, err := NormalizeDesktopId(desktopId)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := LoadConfig(ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	o := cfg[desktopId]
+	if o == nil {
+		return nil, nil
+	}
+	return o.Environ(GetFontConfigFile(FontConfigDir, desktopId)), nil
+}
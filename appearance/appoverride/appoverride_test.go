@@ -0,0 +1,87 @@
+package appoverride
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverrideCheck(t *testing.T) {
+	assert.Nil(t, (&Override{}).Check())
+	assert.Nil(t, (&Override{FontFamily: "Noto Sans", FontSize: 12, ScaleFactor: 1.25, DPI: 120}).Check())
+	assert.NotNil(t, (&Override{FontSize: 100}).Check())
+	assert.NotNil(t, (&Override{ScaleFactor: 10}).Check())
+	assert.NotNil(t, (&Override{DPI: 10}).Check())
+	assert.NotNil(t, (&Override{FontFamily: "a</string>"}).Check())
+}
+
+func TestOverrideEnviron(t *testing.T) {
+	assert.Nil(t, (&Override{}).Environ("/tmp/a.conf"))
+
+	o := &Override{ScaleFactor: 1.5}
+	assert.Equal(t, []string{"QT_SCALE_FACTOR=1.5", "GDK_SCALE=1", "GDK_DPI_SCALE=1.5"}, o.Environ(""))
+
+	o = &Override{ScaleFactor: 2, DPI: 144}
+	assert.Equal(t, []string{"QT_SCALE_FACTOR=2", "GDK_SCALE=2", "QT_FONT_DPI=144", "GDK_DPI_SCALE=1.5"},
+		o.Environ(""))
+
+	o = &Override{FontFamily: "Noto Sans"}
+	assert.Equal(t, []string{"FONTCONFIG_FILE=/tmp/a.conf"}, o.Environ("/tmp/a.conf"))
+}
+
+func TestFontConfigContent(t *testing.T) {
+	o := &Override{FontFamily: "Noto Sans", FontSize: 15}
+	content := o.FontConfigContent(10.5)
+	assert.True(t, strings.Contains(content, "<include ignore_missing=\"yes\">/etc/fonts/fonts.conf</include>"))
+	assert.Equal(t, 2, strings.Count(content, "<string>Noto Sans</string>"))
+	assert.True(t, strings.Contains(content, "<double>1.4286</double>"))
+
+	content = (&Override{FontSize: 12}).FontConfigContent(12)
+	assert.False(t, strings.Contains(content, "pixelsize"))
+}
+
+func TestNormalizeDesktopId(t *testing.T) {
+	id, err := NormalizeDesktopId("deepin-editor")
+	assert.Nil(t, err)
+	assert.Equal(t, "deepin-editor.desktop", id)
+
+	id, err = NormalizeDesktopId("org.gnome.gedit.desktop")
+	assert.Nil(t, err)
+	assert.Equal(t, "org.gnome.gedit.desktop", id)
+
+	for _, id := range []string{"", "../a.desktop", "a/b.desktop", ".desktop"} {
+		_, err = NormalizeDesktopId(id)
+		assert.NotNil(t, err, id)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "appoverride")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "app-overrides.json")
+	cfg, err := LoadConfig(filename)
+	assert.Nil(t, err)
+	assert.Len(t, cfg, 0)
+
+	cfg["b.desktop"] = &Override{DPI: 120}
+	cfg["a.desktop"] = &Override{FontFamily: "Noto Sans"}
+	assert.Nil(t, cfg.Save(filename))
+
+	cfg, err = LoadConfig(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a.desktop", "b.desktop"}, cfg.DesktopIds())
+	assert.Equal(t, int32(120), cfg["b.desktop"].DPI)
+
+	assert.Nil(t, WriteFontConfig(dir, "a.desktop", cfg["a.desktop"], 10.5))
+	_, err = os.Stat(filepath.Join(dir, "a.conf"))
+	assert.Nil(t, err)
+	assert.Nil(t, WriteFontConfig(dir, "a.desktop", nil, 0))
+	_, err = os.Stat(filepath.Join(dir, "a.conf"))
+	assert.True(t, os.IsNotExist(err))
+}
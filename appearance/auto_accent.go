@@ -0,0 +1,294 @@
+package appearance
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"pkg.deepin.io/lib/imgutil"
+	dutils "pkg.deepin.io/lib/utils"
+	"pkg.deepin.io/lib/xdg/basedir"
+)
+
+const (
+	propAutoAccentColor = "AutoAccentColor"
+	propAccentPalette   = "AccentPalette"
+
+	gtkAccentCssFile = "dde-accent.css"
+)
+
+var accentConfigFile = filepath.Join(basedir.GetUserConfigDir(), "deepin/dde-daemon/appearance/accent.json")
+
+var gtkAccentImport = `@import url("` + gtkAccentCssFile + `");`
+
+// 自动强调色：主屏幕的壁纸改变时，从壁纸中提取颜色并设置为 QtActiveColor 和 GTK 的强调色。
+
+type accentConfig struct {
+	Auto    bool
+	Palette []string `json:",omitempty"`
+}
+
+func loadAccentConfig(filename string) (*accentConfig, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var cfg accentConfig
+	err = json.Unmarshal(data, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (cfg *accentConfig) save(filename string) error {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+func (m *Manager) initAutoAccent() {
+	cfg, err := loadAccentConfig(accentConfigFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warning(err)
+		}
+		return
+	}
+	m.accentMu.Lock()
+	m.AutoAccentColor = cfg.Auto
+	m.AccentPalette = cfg.Palette
+	m.accentMu.Unlock()
+}
+
+func (m *Manager) saveAccentConfigNoLock() {
+	cfg := &accentConfig{
+		Auto:    m.AutoAccentColor,
+		Palette: m.AccentPalette,
+	}
+	err := cfg.save(accentConfigFile)
+	if err != nil {
+		logger.Warning("failed to save accent config:", err)
+	}
+}
+
+func (m *Manager) setAutoAccentColor(enabled bool) error {
+	m.accentMu.Lock()
+	if m.AutoAccentColor == enabled {
+		m.accentMu.Unlock()
+		return nil
+	}
+	m.AutoAccentColor = enabled
+	m.saveAccentConfigNoLock()
+	m.accentMu.Unlock()
+	m.emitPropChangedAutoAccentColor(enabled)
+
+	if !enabled {
+		return removeGtkAccentColor()
+	}
+
+	file, err := m.getPrimaryBackground()
+	if err != nil {
+		return err
+	}
+	go func() {
+		err := m.updateAccentColor(file)
+		if err != nil {
+			logger.Warning("failed to update accent color:", err)
+		}
+	}()
+	return nil
+}
+
+// disableAutoAccentColor 在用户手动设置 QtActiveColor 后调用
+func (m *Manager) disableAutoAccentColor() {
+	m.accentMu.Lock()
+	enabled := m.AutoAccentColor
+	m.accentMu.Unlock()
+	if !enabled {
+		return
+	}
+	err := m.setAutoAccentColor(false)
+	if err != nil {
+		logger.Warning(err)
+	}
+}
+
+func (m *Manager) emitPropChangedAutoAccentColor(value bool) {
+	err := m.service.EmitPropertyChanged(m, propAutoAccentColor, value)
+	if err != nil {
+		logger.Warning(err)
+	}
+}
+
+func (m *Manager) getPrimaryBackground() (string, error) {
+	primary, err := m.display.Primary().Get(0)
+	if err != nil {
+		return "", err
+	}
+	idx, err := m.wm.GetCurrentWorkspace(0)
+	if err != nil {
+		return "", err
+	}
+	uri, err := m.wm.GetWorkspaceBackgroundForMonitor(0, idx, primary)
+	if err != nil {
+		return "", err
+	}
+	if uri == "" {
+		return "", errors.New("background of primary monitor is empty")
+	}
+	return dutils.DecodeURI(uri), nil
+}
+
+// handleBackgroundChangedForAccent 在显示器 monitorName 的壁纸设置为 file 后调用
+func (m *Manager) handleBackgroundChangedForAccent(monitorName, file string) {
+	m.accentMu.Lock()
+	enabled := m.AutoAccentColor
+	m.accentMu.Unlock()
+	if !enabled {
+		return
+	}
+
+	primary, err := m.display.Primary().Get(0)
+	if err != nil {
+		logger.Warning(err)
+	} else if primary != monitorName {
+		return
+	}
+
+	err = m.updateAccentColor(file)
+	if err != nil {
+		logger.Warning("failed to update accent color:", err)
+	}
+}
+
+func (m *Manager) updateAccentColor(file string) error {
+	img, err := imgutil.Load(file)
+	if err != nil {
+		return err
+	}
+	palette := getAccentPalette(img)
+	logger.Debugf("accent palette of %q: %v", file, palette)
+
+	m.accentMu.Lock()
+	if !m.AutoAccentColor {
+		m.accentMu.Unlock()
+		return nil
+	}
+	m.AccentPalette = palette
+	m.saveAccentConfigNoLock()
+	m.accentMu.Unlock()
+
+	if palette == nil {
+		palette = []string{}
+	}
+	err = m.service.EmitPropertyChanged(m, propAccentPalette, palette)
+	if err != nil {
+		logger.Warning(err)
+	}
+
+	if len(palette) == 0 {
+		// 壁纸中没有合适的颜色，保持当前的强调色
+		return nil
+	}
+	accent := palette[0]
+	err = m.setQtActiveColor(accent)
+	if err != nil {
+		return err
+	}
+	return applyGtkAccentColor(accent)
+}
+
+func getGtkConfigDirs() []string {
+	configDir := basedir.GetUserConfigDir()
+	return []string{
+		filepath.Join(configDir, "gtk-3.0"),
+		filepath.Join(configDir, "gtk-4.0"),
+	}
+}
+
+// applyGtkAccentColor 生成定义强调色的 CSS 文件，并在用户的 gtk.css 中导入它
+func applyGtkAccentColor(hexColor string) error {
+	css := "/* Generated by dde-daemon, do not edit. */\n" +
+		"@define-color accent_color " + hexColor + ";\n" +
+		"@define-color accent_bg_color " + hexColor + ";\n" +
+		"@define-color theme_selected_bg_color " + hexColor + ";\n"
+
+	for _, dir := range getGtkConfigDirs() {
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(filepath.Join(dir, gtkAccentCssFile), []byte(css), 0644)
+		if err != nil {
+			return err
+		}
+		err = updateGtkCssImport(filepath.Join(dir, "gtk.css"), true)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func removeGtkAccentColor() error {
+	for _, dir := range getGtkConfigDirs() {
+		err := updateGtkCssImport(filepath.Join(dir, "gtk.css"), false)
+		if err != nil {
+			return err
+		}
+		err = os.Remove(filepath.Join(dir, gtkAccentCssFile))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateGtkCssImport 在 gtk.css 中添加或删除导入强调色的语句，不改变文件的其他内容
+func updateGtkCssImport(filename string, add bool) error {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err != nil && !add {
+		return nil
+	}
+
+	lines := strings.Split(string(content), "\n")
+	var result []string
+	found := false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == gtkAccentImport {
+			found = true
+			if !add {
+				continue
+			}
+		}
+		result = append(result, line)
+	}
+	if !add {
+		if !found {
+			return nil
+		}
+		return ioutil.WriteFile(filename, []byte(strings.Join(result, "\n")), 0644)
+	}
+	if found {
+		return nil
+	}
+	// 放在最后，覆盖前面的定义
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		content = append(content, '\n')
+	}
+	content = append(content, []byte(gtkAccentImport+"\n")...)
+	return ioutil.WriteFile(filename, content, 0644)
+}
@@ -0,0 +1,356 @@
+package appearance
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 外观方案包是一个 tar.gz 文件，包含描述主题、字体等设置的 profile.json 和 wallpapers 目录中的壁纸。
+// profile.json 是第一项，只读取 profile 时不必解压壁纸。
+
+const (
+	bundleVersion       = 1
+	bundleProfileFile   = "profile.json"
+	bundleWallpapersDir = "wallpapers"
+	bundleFileExt       = ".tar.gz"
+	bundleMaxFileSize   = 64 * 1024 * 1024
+	// 包中的项数和解压后的总大小的上限
+	bundleMaxEntries   = 64
+	bundleMaxTotalSize = 256 * 1024 * 1024
+)
+
+type appearanceProfile struct {
+	Version         int
+	Name            string
+	GtkTheme        string   `json:",omitempty"`
+	IconTheme       string   `json:",omitempty"`
+	CursorTheme     string   `json:",omitempty"`
+	StandardFont    string   `json:",omitempty"`
+	MonospaceFont   string   `json:",omitempty"`
+	FontSize        float64  `json:",omitempty"`
+	Opacity         *float64 `json:",omitempty"`
+	WindowRadius    *int32   `json:",omitempty"`
+	QtActiveColor   string   `json:",omitempty"`
+	AutoAccentColor bool     `json:",omitempty"`
+	// 键为显示器的角色，例如 Primary、Subsidiary0，值为壁纸文件，在包中是相对路径
+	Backgrounds map[string]string `json:",omitempty"`
+}
+
+type bundleInfo struct {
+	Id        string
+	Name      string
+	Path      string
+	Deletable bool
+}
+
+func (p *appearanceProfile) check() error {
+	if p.Version <= 0 || p.Version > bundleVersion {
+		return fmt.Errorf("unsupported bundle version %d", p.Version)
+	}
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("bundle name is empty")
+	}
+	if p.QtActiveColor != "" {
+		_, err := parseHexColor(p.QtActiveColor)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isValidBundleEntry(name string) bool {
+	if name == "" || path.IsAbs(name) {
+		return false
+	}
+	clean := path.Clean(name)
+	return clean == name && clean != ".." && !strings.HasPrefix(clean, "../")
+}
+
+// writeBundle 把 profile 和其中的壁纸写入 filename，profile.Backgrounds 中的壁纸是绝对路径
+func writeBundle(filename string, profile *appearanceProfile) (err error) {
+	err = os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return err
+	}
+	tmpFile := filename + ".tmp"
+	fh, err := os.Create(tmpFile)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmpFile)
+		}
+	}()
+
+	gzWriter := gzip.NewWriter(fh)
+	tarWriter := tar.NewWriter(gzWriter)
+
+	bundleProfile := *profile
+	bundleProfile.Backgrounds = make(map[string]string)
+	// 多个显示器使用同一张壁纸时只保存一份
+	entries := make(map[string]string)
+	var files []string
+	roles := make([]string, 0, len(profile.Backgrounds))
+	for role := range profile.Backgrounds {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	for _, role := range roles {
+		file := profile.Backgrounds[role]
+		entry, ok := entries[file]
+		if !ok {
+			entry = path.Join(bundleWallpapersDir, role+strings.ToLower(filepath.Ext(file)))
+			entries[file] = entry
+			files = append(files, file)
+		}
+		bundleProfile.Backgrounds[role] = entry
+	}
+
+	data, err := json.MarshalIndent(&bundleProfile, "", "  ")
+	if err != nil {
+		_ = fh.Close()
+		return err
+	}
+	err = tarWriter.WriteHeader(&tar.Header{
+		Name:    bundleProfileFile,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	})
+	if err == nil {
+		_, err = tarWriter.Write(data)
+	}
+	for _, file := range files {
+		if err != nil {
+			break
+		}
+		err = addFileToTar(tarWriter, entries[file], file)
+	}
+	if err == nil {
+		err = tarWriter.Close()
+	}
+	if err == nil {
+		err = gzWriter.Close()
+	}
+	closeErr := fh.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, filename)
+}
+
+func addFileToTar(tarWriter *tar.Writer, name, file string) error {
+	fh, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	info, err := fh.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%q is not a regular file", file)
+	}
+	if info.Size() > bundleMaxFileSize {
+		return fmt.Errorf("file %q is too large", file)
+	}
+	err = tarWriter.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tarWriter, fh)
+	return err
+}
+
+// readBundle 读取方案包，extractDir 不为空时把壁纸解压到其中，
+// 并把返回的 Backgrounds 转换为解压后的绝对路径
+func readBundle(filename, extractDir string) (*appearanceProfile, error) {
+	fh, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	gzReader, err := gzip.NewReader(fh)
+	if err != nil {
+		return nil, err
+	}
+	defer gzReader.Close()
+
+	var profile *appearanceProfile
+	extracted := make(map[string]bool)
+	var entryCount int
+	var totalSize int64
+	tarReader := tar.NewReader(gzReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		entryCount++
+		if entryCount > bundleMaxEntries {
+			return nil, errors.New("too many entries in bundle")
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if !isValidBundleEntry(header.Name) {
+			return nil, fmt.Errorf("invalid entry %q in bundle", header.Name)
+		}
+		if header.Size > bundleMaxFileSize {
+			return nil, fmt.Errorf("entry %q in bundle is too large", header.Name)
+		}
+		totalSize += header.Size
+		if totalSize > bundleMaxTotalSize {
+			return nil, errors.New("bundle is too large")
+		}
+
+		if header.Name == bundleProfileFile {
+			data, err := ioutil.ReadAll(tarReader)
+			if err != nil {
+				return nil, err
+			}
+			profile = &appearanceProfile{}
+			err = json.Unmarshal(data, profile)
+			if err != nil {
+				return nil, err
+			}
+			// 只读取 profile 时不再读取后面的壁纸
+			if extractDir == "" {
+				break
+			}
+			continue
+		}
+
+		if extractDir == "" || !strings.HasPrefix(header.Name, bundleWallpapersDir+"/") {
+			continue
+		}
+		err = extractTarEntry(tarReader, filepath.Join(extractDir, filepath.FromSlash(header.Name)))
+		if err != nil {
+			return nil, err
+		}
+		extracted[header.Name] = true
+	}
+
+	if profile == nil {
+		return nil, errors.New("no profile in bundle")
+	}
+	err = profile.check()
+	if err != nil {
+		return nil, err
+	}
+
+	for role, entry := range profile.Backgrounds {
+		if !isValidBundleEntry(entry) {
+			return nil, fmt.Errorf("invalid background %q", entry)
+		}
+		if extractDir == "" {
+			continue
+		}
+		if !extracted[entry] {
+			return nil, fmt.Errorf("background %q not found in bundle", entry)
+		}
+		profile.Backgrounds[role] = filepath.Join(extractDir, filepath.FromSlash(entry))
+	}
+	return profile, nil
+}
+
+func extractTarEntry(r io.Reader, filename string) error {
+	err := os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return err
+	}
+	fh, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fh, io.LimitReader(r, bundleMaxFileSize))
+	closeErr := fh.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// listBundles 列出 dirs 中的方案包，前面目录中的同名方案包优先
+func listBundles(dirs []string, deletableDir string) []*bundleInfo {
+	var result []*bundleInfo
+	ids := make(map[string]bool)
+	for _, dir := range dirs {
+		files, err := filepath.Glob(filepath.Join(dir, "*"+bundleFileExt))
+		if err != nil {
+			logger.Warning(err)
+			continue
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			id := strings.TrimSuffix(filepath.Base(file), bundleFileExt)
+			if ids[id] {
+				continue
+			}
+			profile, err := readBundle(file, "")
+			if err != nil {
+				logger.Warningf("failed to read bundle %q: %v", file, err)
+				continue
+			}
+			ids[id] = true
+			result = append(result, &bundleInfo{
+				Id:        id,
+				Name:      profile.Name,
+				Path:      file,
+				Deletable: dir == deletableDir,
+			})
+		}
+	}
+	return result
+}
+
+type bundleStep struct {
+	name     string
+	apply    func() error
+	rollback func() error
+}
+
+// runBundleSteps 依次执行 steps，某一步失败时按相反的顺序撤销已经执行的步骤，包括失败的这一步
+func runBundleSteps(steps []bundleStep) error {
+	for i, step := range steps {
+		err := step.apply()
+		if err == nil {
+			continue
+		}
+		for j := i; j >= 0; j-- {
+			rbErr := steps[j].rollback()
+			if rbErr != nil {
+				logger.Warningf("failed to rollback %s: %v", steps[j].name, rbErr)
+			}
+		}
+		return fmt.Errorf("failed to apply %s: %v", step.name, err)
+	}
+	return nil
+}
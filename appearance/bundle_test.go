@@ -0,0 +1,181 @@
+package appearance
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_bundleReadWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "appearance-bundle")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	wallpaper := filepath.Join(dir, "a.JPG")
+	err = ioutil.WriteFile(wallpaper, []byte("image data"), 0644)
+	assert.Nil(t, err)
+
+	opacity := 0.8
+	profile := &appearanceProfile{
+		Version:       bundleVersion,
+		Name:          "My Look",
+		GtkTheme:      "deepin-dark",
+		IconTheme:     "bloom",
+		FontSize:      10.5,
+		Opacity:       &opacity,
+		QtActiveColor: "#0081FF",
+		Backgrounds: map[string]string{
+			"Primary":     wallpaper,
+			"Subsidiary0": wallpaper,
+		},
+	}
+	bundleFile := filepath.Join(dir, "bundles", "my-look"+bundleFileExt)
+	err = writeBundle(bundleFile, profile)
+	assert.Nil(t, err)
+
+	// 只读取 profile
+	p, err := readBundle(bundleFile, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "My Look", p.Name)
+	assert.Equal(t, "wallpapers/Primary.jpg", p.Backgrounds["Primary"])
+	// 相同的壁纸只保存一份
+	assert.Equal(t, "wallpapers/Primary.jpg", p.Backgrounds["Subsidiary0"])
+
+	extractDir := filepath.Join(dir, "extract")
+	p, err = readBundle(bundleFile, extractDir)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.8, *p.Opacity)
+	assert.Nil(t, p.WindowRadius)
+	data, err := ioutil.ReadFile(p.Backgrounds["Primary"])
+	assert.Nil(t, err)
+	assert.Equal(t, "image data", string(data))
+
+	infos := listBundles([]string{filepath.Join(dir, "bundles"), dir}, filepath.Join(dir, "bundles"))
+	if assert.Len(t, infos, 1) {
+		assert.Equal(t, "my-look", infos[0].Id)
+		assert.Equal(t, "My Look", infos[0].Name)
+		assert.True(t, infos[0].Deletable)
+	}
+}
+
+func Test_bundleLayout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "appearance-bundle")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	wallpaper := filepath.Join(dir, "a.png")
+	err = ioutil.WriteFile(wallpaper, []byte("image data"), 0644)
+	assert.Nil(t, err)
+	bundleFile := filepath.Join(dir, "a"+bundleFileExt)
+	err = writeBundle(bundleFile, &appearanceProfile{
+		Version:     bundleVersion,
+		Name:        "A",
+		Backgrounds: map[string]string{"Primary": wallpaper},
+	})
+	assert.Nil(t, err)
+
+	// profile.json 是第一项
+	names := readTarNames(t, bundleFile)
+	assert.Equal(t, []string{bundleProfileFile, "wallpapers/Primary.png"}, names)
+
+	// 项数超过上限
+	tooMany := filepath.Join(dir, "too-many"+bundleFileExt)
+	writeTestTar(t, tooMany, bundleMaxEntries+1)
+	_, err = readBundle(tooMany, filepath.Join(dir, "extract"))
+	assert.NotNil(t, err)
+}
+
+func readTarNames(t *testing.T, filename string) []string {
+	fh, err := os.Open(filename)
+	if !assert.Nil(t, err) {
+		return nil
+	}
+	defer fh.Close()
+	gzReader, err := gzip.NewReader(fh)
+	if !assert.Nil(t, err) {
+		return nil
+	}
+	var names []string
+	tarReader := tar.NewReader(gzReader)
+	for {
+		header, err := tarReader.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, header.Name)
+	}
+	return names
+}
+
+func writeTestTar(t *testing.T, filename string, count int) {
+	fh, err := os.Create(filename)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer fh.Close()
+	gzWriter := gzip.NewWriter(fh)
+	tarWriter := tar.NewWriter(gzWriter)
+	for i := 0; i < count; i++ {
+		err = tarWriter.WriteHeader(&tar.Header{
+			Name: fmt.Sprintf("wallpapers/%d.png", i),
+			Mode: 0644,
+			Size: 0,
+		})
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, tarWriter.Close())
+	assert.Nil(t, gzWriter.Close())
+}
+
+func Test_bundleCheck(t *testing.T) {
+	p := &appearanceProfile{Version: bundleVersion + 1, Name: "a"}
+	assert.NotNil(t, p.check())
+	p = &appearanceProfile{Version: bundleVersion, Name: " "}
+	assert.NotNil(t, p.check())
+	p = &appearanceProfile{Version: bundleVersion, Name: "a", QtActiveColor: "blue"}
+	assert.NotNil(t, p.check())
+	p = &appearanceProfile{Version: bundleVersion, Name: "a", QtActiveColor: "#0081ff"}
+	assert.Nil(t, p.check())
+
+	assert.True(t, isValidBundleEntry("wallpapers/a.jpg"))
+	assert.False(t, isValidBundleEntry("../a.jpg"))
+	assert.False(t, isValidBundleEntry("/etc/passwd"))
+	assert.False(t, isValidBundleEntry("wallpapers/../../a.jpg"))
+	assert.False(t, isValidBundleEntry(""))
+}
+
+func Test_runBundleSteps(t *testing.T) {
+	var calls []string
+	newStep := func(name string, fail bool) bundleStep {
+		return bundleStep{
+			name: name,
+			apply: func() error {
+				calls = append(calls, "apply "+name)
+				if fail {
+					return errors.New("failed")
+				}
+				return nil
+			},
+			rollback: func() error {
+				calls = append(calls, "rollback "+name)
+				return nil
+			},
+		}
+	}
+
+	err := runBundleSteps([]bundleStep{newStep("a", false), newStep("b", false)})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"apply a", "apply b"}, calls)
+
+	calls = nil
+	err = runBundleSteps([]bundleStep{newStep("a", false), newStep("b", true), newStep("c", false)})
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"apply a", "apply b", "rollback b", "rollback a"}, calls)
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright (C) 2021 ~ 2022 Deepin Technology Co., Ltd.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+#include <fontconfig/fontconfig.h>
+#include <fontconfig/fcfreetype.h>
+#include <ft2build.h>
+#include FT_FREETYPE_H
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#include "font_file.h"
+
+#define PREVIEW_PADDING 8
+
+/*
+ * 返回字体文件中第一个字体的 family，文件不是有效的字体时返回 NULL
+ */
+char *
+query_font_family(const char *file)
+{
+    int count = 0;
+    FcPattern *pat = FcFreeTypeQuery((const FcChar8 *)file, 0, NULL, &count);
+    if (!pat) {
+        return NULL;
+    }
+
+    FcChar8 *family = NULL;
+    char *ret = NULL;
+    if (FcPatternGetString(pat, FC_FAMILY, 0, &family) == FcResultMatch) {
+        ret = strdup((char *)family);
+    }
+    FcPatternDestroy(pat);
+    return ret;
+}
+
+/*
+ * 用字体文件渲染一行文字，返回 width * height 的 8 位灰度图，调用者负责释放
+ */
+unsigned char *
+render_font_preview(const char *file, const unsigned int *text, int len,
+                    int pixel_size, int *width, int *height)
+{
+    FT_Library library;
+    FT_Face face;
+    unsigned char *buf = NULL;
+
+    *width = 0;
+    *height = 0;
+    if (FT_Init_FreeType(&library) != 0) {
+        return NULL;
+    }
+    if (FT_New_Face(library, file, 0, &face) != 0) {
+        FT_Done_FreeType(library);
+        return NULL;
+    }
+    if (FT_Set_Pixel_Sizes(face, 0, pixel_size) != 0) {
+        goto out;
+    }
+
+    int ascender = face->size->metrics.ascender >> 6;
+    int descender = face->size->metrics.descender >> 6;
+    int i;
+    int pen_x = 0;
+    for (i = 0; i < len; i++) {
+        if (FT_Load_Char(face, text[i], FT_LOAD_DEFAULT) != 0) {
+            continue;
+        }
+        pen_x += face->glyph->advance.x >> 6;
+    }
+    if (pen_x <= 0 || ascender - descender <= 0) {
+        goto out;
+    }
+
+    int w = pen_x + PREVIEW_PADDING * 2;
+    int h = ascender - descender + PREVIEW_PADDING * 2;
+    buf = calloc(w * h, 1);
+    if (!buf) {
+        goto out;
+    }
+
+    int baseline = PREVIEW_PADDING + ascender;
+    pen_x = PREVIEW_PADDING;
+    for (i = 0; i < len; i++) {
+        if (FT_Load_Char(face, text[i], FT_LOAD_RENDER) != 0) {
+            continue;
+        }
+        FT_GlyphSlot slot = face->glyph;
+        FT_Bitmap *bitmap = &slot->bitmap;
+        if (bitmap->pixel_mode != FT_PIXEL_MODE_GRAY &&
+            bitmap->pixel_mode != FT_PIXEL_MODE_MONO) {
+            pen_x += slot->advance.x >> 6;
+            continue;
+        }
+        int x0 = pen_x + slot->bitmap_left;
+        int y0 = baseline - slot->bitmap_top;
+        unsigned int row, col;
+        for (row = 0; row < bitmap->rows; row++) {
+            int y = y0 + (int)row;
+            if (y < 0 || y >= h) {
+                continue;
+            }
+            for (col = 0; col < bitmap->width; col++) {
+                int x = x0 + (int)col;
+                if (x < 0 || x >= w) {
+                    continue;
+                }
+                unsigned char v;
+                if (bitmap->pixel_mode == FT_PIXEL_MODE_MONO) {
+                    unsigned char byte = bitmap->buffer[row * bitmap->pitch + col / 8];
+                    v = (byte & (0x80 >> (col % 8))) ? 255 : 0;
+                } else {
+                    v = bitmap->buffer[row * bitmap->pitch + col];
+                }
+                if (v > buf[y * w + x]) {
+                    buf[y * w + x] = v;
+                }
+            }
+        }
+        pen_x += slot->advance.x >> 6;
+    }
+    *width = w;
+    *height = h;
+
+out:
+    FT_Done_Face(face);
+    FT_Done_FreeType(library);
+    return buf;
+}
@@ -0,0 +1,50 @@
+package fonts
+
+// #cgo pkg-config: fontconfig freetype2
+// #include <stdlib.h>
+// #include "font_file.h"
+import "C"
+
+import (
+	"errors"
+	"image"
+	"unsafe"
+)
+
+// queryFontFamily 返回字体文件中第一个字体的 family，文件损坏或者不是字体时返回错误
+func queryFontFamily(file string) (string, error) {
+	cFile := C.CString(file)
+	defer C.free(unsafe.Pointer(cFile))
+	cRet := C.query_font_family(cFile)
+	if cRet == nil {
+		return "", errors.New("invalid font file")
+	}
+	defer C.free(unsafe.Pointer(cRet))
+	return C.GoString(cRet), nil
+}
+
+// renderFontPreview 用字体文件渲染一行文字，返回的图片中文字的覆盖度保存在 Alpha 中
+func renderFontPreview(file, text string, pixelSize int) (*image.Alpha, error) {
+	runes := []rune(text)
+	if len(runes) == 0 {
+		return nil, errors.New("preview text is empty")
+	}
+	codes := make([]C.uint, len(runes))
+	for i, r := range runes {
+		codes[i] = C.uint(r)
+	}
+
+	cFile := C.CString(file)
+	defer C.free(unsafe.Pointer(cFile))
+	var width, height C.int
+	buf := C.render_font_preview(cFile, &codes[0], C.int(len(codes)), C.int(pixelSize),
+		&width, &height)
+	if buf == nil {
+		return nil, errors.New("failed to render font preview")
+	}
+	defer C.free(unsafe.Pointer(buf))
+
+	img := image.NewAlpha(image.Rect(0, 0, int(width), int(height)))
+	copy(img.Pix, C.GoBytes(unsafe.Pointer(buf), width*height))
+	return img, nil
+}
@@ -0,0 +1,25 @@
+/*
+ * Copyright (C) 2021 ~ 2022 Deepin Technology Co., Ltd.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+#ifndef __FONT_FILE_H__
+#define __FONT_FILE_H__
+
+char *query_font_family(const char *file);
+unsigned char *render_font_preview(const char *file, const unsigned int *text, int len,
+                                   int pixel_size, int *width, int *height);
+
+#endif
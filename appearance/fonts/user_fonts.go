@@ -0,0 +1,324 @@
+package fonts
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"pkg.deepin.io/lib/strv"
+	"pkg.deepin.io/lib/xdg/basedir"
+)
+
+const (
+	maxFontFileSize = 64 * 1024 * 1024
+
+	defaultPreviewSize = 32
+	minPreviewSize     = 8
+	maxPreviewSize     = 256
+	maxPreviewTextLen  = 128
+)
+
+var (
+	UserFontsDir   = path.Join(basedir.GetUserDataDir(), "fonts")
+	fontPreviewDir = path.Join(home, ".cache", "deepin", "dde-daemon", "fonts", "preview")
+
+	fontFileExts = strv.Strv([]string{".ttf", ".otf", ".ttc", ".otc", ".pfb", ".pcf"})
+)
+
+type UserFont struct {
+	File   string
+	Family string
+}
+
+func isFontFileExt(file string) bool {
+	return fontFileExts.Contains(strings.ToLower(filepath.Ext(file)))
+}
+
+// isUserFontFile 判断 file 是否为用户字体目录中的文件，只有这些文件可以删除
+func isUserFontFile(file string) bool {
+	file = filepath.Clean(file)
+	return filepath.IsAbs(file) && strings.HasPrefix(file, UserFontsDir+"/")
+}
+
+func sumFileMd5(file string) (string, error) {
+	fh, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer fh.Close()
+
+	h := md5.New()
+	_, err = io.Copy(h, fh)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func listUserFontFiles() ([]string, error) {
+	var files []string
+	err := filepath.Walk(UserFontsDir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.Mode().IsRegular() && isFontFileExt(file) {
+			files = append(files, file)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// ListUserFonts 列出用户字体目录中的字体文件
+func ListUserFonts() ([]*UserFont, error) {
+	files, err := listUserFontFiles()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*UserFont, 0, len(files))
+	for _, file := range files {
+		family, err := queryFontFamily(file)
+		if err != nil {
+			continue
+		}
+		result = append(result, &UserFont{File: file, Family: family})
+	}
+	return result, nil
+}
+
+// checkFontFile 检查要安装的字体文件，文件损坏或者已经安装时返回错误
+func checkFontFile(file string) (*UserFont, error) {
+	if !isFontFileExt(file) {
+		return nil, fmt.Errorf("%q is not a font file", filepath.Base(file))
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%q is not a regular file", file)
+	}
+	if info.Size() > maxFontFileSize {
+		return nil, fmt.Errorf("font file %q is too large", filepath.Base(file))
+	}
+
+	family, err := queryFontFamily(file)
+	if err != nil {
+		return nil, fmt.Errorf("font file %q is broken", filepath.Base(file))
+	}
+
+	installedFile, err := findInstalledFontFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if installedFile != "" {
+		return nil, fmt.Errorf("font %q is already installed as %q", family, installedFile)
+	}
+	return &UserFont{File: file, Family: family}, nil
+}
+
+// findInstalledFontFile 返回用户字体目录中与 file 内容相同的文件，没有时返回空
+func findInstalledFontFile(file string) (string, error) {
+	sum, err := sumFileMd5(file)
+	if err != nil {
+		return "", err
+	}
+	installed, err := listUserFontFiles()
+	if err != nil {
+		return "", err
+	}
+	for _, installedFile := range installed {
+		installedSum, err := sumFileMd5(installedFile)
+		if err != nil {
+			continue
+		}
+		if installedSum == sum {
+			return installedFile, nil
+		}
+	}
+	return "", nil
+}
+
+// InstallFont 把字体文件复制到用户字体目录中，返回安装后的文件，需要调用 UpdateCache 使其生效
+func InstallFont(file string) (*UserFont, error) {
+	font, err := checkFontFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	dest := filepath.Join(UserFontsDir, filepath.Base(file))
+	_, err = os.Stat(dest)
+	if err == nil {
+		return nil, fmt.Errorf("file %q already exists", dest)
+	}
+	err = os.MkdirAll(UserFontsDir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	err = copyFile(file, dest)
+	if err != nil {
+		return nil, err
+	}
+	return &UserFont{File: dest, Family: font.Family}, nil
+}
+
+func copyFile(src, dest string) error {
+	srcFh, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFh.Close()
+
+	tmpFile := dest + ".tmp"
+	destFh, err := os.Create(tmpFile)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(destFh, srcFh)
+	closeErr := destFh.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpFile)
+		return err
+	}
+	return os.Rename(tmpFile, dest)
+}
+
+// RemoveFont 删除用户字体目录中的字体文件，需要调用 UpdateCache 使其生效
+func RemoveFont(file string) error {
+	file = filepath.Clean(file)
+	if !isUserFontFile(file) {
+		return fmt.Errorf("%q is not a user font", file)
+	}
+	if !isFontFileExt(file) {
+		return fmt.Errorf("%q is not a font file", file)
+	}
+	return os.Remove(file)
+}
+
+var cacheUpdater struct {
+	mu        sync.Mutex
+	running   bool
+	pending   bool
+	callbacks []func(error)
+}
+
+// UpdateCache 在后台运行 fc-cache 更新用户字体目录的缓存，完成后调用 callback。
+// 正在更新时再次调用会在这次更新结束后再更新一次
+func UpdateCache(callback func(error)) {
+	cacheUpdater.mu.Lock()
+	if callback != nil {
+		cacheUpdater.callbacks = append(cacheUpdater.callbacks, callback)
+	}
+	if cacheUpdater.running {
+		cacheUpdater.pending = true
+		cacheUpdater.mu.Unlock()
+		return
+	}
+	cacheUpdater.running = true
+	cacheUpdater.mu.Unlock()
+
+	go func() {
+		for {
+			cacheUpdater.mu.Lock()
+			callbacks := cacheUpdater.callbacks
+			cacheUpdater.callbacks = nil
+			cacheUpdater.pending = false
+			cacheUpdater.mu.Unlock()
+
+			out, err := exec.Command("fc-cache", "-f", UserFontsDir).CombinedOutput()
+			if err != nil {
+				err = fmt.Errorf("fc-cache failed: %v, output: %s", err, out)
+			}
+			for _, cb := range callbacks {
+				cb(err)
+			}
+
+			cacheUpdater.mu.Lock()
+			if !cacheUpdater.pending {
+				cacheUpdater.running = false
+				cacheUpdater.mu.Unlock()
+				return
+			}
+			cacheUpdater.mu.Unlock()
+		}
+	}()
+}
+
+// GenPreview 用字体文件渲染 text，生成 PNG 图片，返回图片的路径
+func GenPreview(file, text string, size int32) (string, error) {
+	if size == 0 {
+		size = defaultPreviewSize
+	}
+	if size < minPreviewSize || size > maxPreviewSize {
+		return "", fmt.Errorf("invalid preview size %d", size)
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", errors.New("preview text is empty")
+	}
+	if len([]rune(text)) > maxPreviewTextLen {
+		return "", errors.New("preview text is too long")
+	}
+	if !isFontFileExt(file) {
+		return "", fmt.Errorf("%q is not a font file", filepath.Base(file))
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("%s\x00%d\x00%s\x00%d", file, info.ModTime().UnixNano(), text, size)
+	outFile := filepath.Join(fontPreviewDir, fmt.Sprintf("%x.png", md5.Sum([]byte(key))))
+	_, err = os.Stat(outFile)
+	if err == nil {
+		return outFile, nil
+	}
+
+	mask, err := renderFontPreview(file, text, int(size))
+	if err != nil {
+		return "", err
+	}
+	img := image.NewNRGBA(mask.Bounds())
+	draw.DrawMask(img, img.Bounds(), image.NewUniform(color.Black), image.Point{},
+		mask, image.Point{}, draw.Src)
+
+	err = os.MkdirAll(fontPreviewDir, 0755)
+	if err != nil {
+		return "", err
+	}
+	fh, err := ioutil.TempFile(fontPreviewDir, "preview")
+	if err != nil {
+		return "", err
+	}
+	err = png.Encode(fh, img)
+	closeErr := fh.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(fh.Name())
+		return "", err
+	}
+	return outFile, os.Rename(fh.Name(), outFile)
+}
@@ -0,0 +1,163 @@
+package fonts
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupUserFontsDir(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "user-fonts")
+	assert.Nil(t, err)
+	oldDir := UserFontsDir
+	UserFontsDir = filepath.Join(dir, "fonts")
+	err = os.MkdirAll(UserFontsDir, 0755)
+	assert.Nil(t, err)
+	return func() {
+		UserFontsDir = oldDir
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func writeTestFile(t *testing.T, file, content string) {
+	err := os.MkdirAll(filepath.Dir(file), 0755)
+	assert.Nil(t, err)
+	err = ioutil.WriteFile(file, []byte(content), 0644)
+	assert.Nil(t, err)
+}
+
+func Test_isFontFileExt(t *testing.T) {
+	var tests = []struct {
+		file string
+		ret  bool
+	}{
+		{"/tmp/a.ttf", true},
+		{"/tmp/a.OTF", true},
+		{"/tmp/a.ttc", true},
+		{"/tmp/a.pcf", true},
+		{"/tmp/a.txt", false},
+		{"/tmp/ttf", false},
+		{"/tmp/a.ttf.sh", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.ret, isFontFileExt(test.file), test.file)
+	}
+}
+
+func Test_isUserFontFile(t *testing.T) {
+	defer setupUserFontsDir(t)()
+
+	var tests = []struct {
+		file string
+		ret  bool
+	}{
+		{filepath.Join(UserFontsDir, "a.ttf"), true},
+		{filepath.Join(UserFontsDir, "sub", "a.ttf"), true},
+		{UserFontsDir, false},
+		{UserFontsDir + "-other/a.ttf", false},
+		{UserFontsDir + "/../a.ttf", false},
+		{UserFontsDir + "/sub/../../a.ttf", false},
+		{"fonts/a.ttf", false},
+		{"/usr/share/fonts/a.ttf", false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.ret, isUserFontFile(test.file), test.file)
+	}
+}
+
+func Test_RemoveFont(t *testing.T) {
+	defer setupUserFontsDir(t)()
+
+	outsideFile := filepath.Join(filepath.Dir(UserFontsDir), "outside.ttf")
+	writeTestFile(t, outsideFile, "outside")
+	notFontFile := filepath.Join(UserFontsDir, "a.txt")
+	writeTestFile(t, notFontFile, "text")
+	fontFile := filepath.Join(UserFontsDir, "sub", "a.ttf")
+	writeTestFile(t, fontFile, "font")
+
+	var tests = []struct {
+		file string
+		err  bool
+	}{
+		{outsideFile, true},
+		{UserFontsDir + "/../outside.ttf", true},
+		{notFontFile, true},
+		{filepath.Join(UserFontsDir, "not-exist.ttf"), true},
+		{fontFile, false},
+	}
+
+	for _, test := range tests {
+		err := RemoveFont(test.file)
+		assert.Equal(t, test.err, err != nil, test.file)
+	}
+
+	// 拒绝删除的文件保持不变
+	for _, file := range []string{outsideFile, notFontFile} {
+		_, err := os.Stat(file)
+		assert.Nil(t, err, file)
+	}
+	_, err := os.Stat(fontFile)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_checkFontFile(t *testing.T) {
+	defer setupUserFontsDir(t)()
+
+	dir := filepath.Dir(UserFontsDir)
+	notFontFile := filepath.Join(dir, "a.txt")
+	writeTestFile(t, notFontFile, "text")
+	brokenFontFile := filepath.Join(dir, "broken.ttf")
+	writeTestFile(t, brokenFontFile, "not a font")
+	fontDir := filepath.Join(dir, "dir.ttf")
+	err := os.Mkdir(fontDir, 0755)
+	assert.Nil(t, err)
+
+	var tests = []struct {
+		file string
+	}{
+		{notFontFile},
+		{brokenFontFile},
+		{fontDir},
+		{filepath.Join(dir, "not-exist.ttf")},
+	}
+
+	for _, test := range tests {
+		_, err := checkFontFile(test.file)
+		assert.NotNil(t, err, test.file)
+	}
+}
+
+func Test_findInstalledFontFile(t *testing.T) {
+	defer setupUserFontsDir(t)()
+
+	installedFile := filepath.Join(UserFontsDir, "sub", "installed.ttf")
+	writeTestFile(t, installedFile, "font data")
+	// 不是字体文件的不算已安装
+	writeTestFile(t, filepath.Join(UserFontsDir, "other.txt"), "other data")
+
+	dir := filepath.Dir(UserFontsDir)
+	var tests = []struct {
+		name    string
+		content string
+		ret     string
+	}{
+		{"same.ttf", "font data", installedFile},
+		{"renamed.otf", "font data", installedFile},
+		{"new.ttf", "new font data", ""},
+		{"other.ttf", "other data", ""},
+	}
+
+	for _, test := range tests {
+		file := filepath.Join(dir, test.name)
+		writeTestFile(t, file, test.content)
+		ret, err := findInstalledFontFile(file)
+		assert.Nil(t, err)
+		assert.Equal(t, test.ret, ret, test.name)
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"pkg.deepin.io/dde/daemon/appearance/subthemes"
 	"pkg.deepin.io/lib/dbusutil"
 	"pkg.deepin.io/lib/strv"
+	dutils "pkg.deepin.io/lib/utils"
 )
 
 // Reset reset all themes and fonts settings to default values
@@ -236,6 +237,95 @@ func (m *Manager) GetWallpaperSlideShow(monitorName string) (string, *dbus.Error
 	return slideShow, dbusutil.ToError(err)
 }
 
+// ExportBundle 把当前的外观设置和壁纸导出为方案包 filename，filename 为空时保存到用户的方案包目录中
+func (m *Manager) ExportBundle(name, filename string) (string, *dbus.Error) {
+	logger.Debugf("ExportBundle name: %q, filename: %q", name, filename)
+	file, err := m.exportBundle(name, filename)
+	return file, dbusutil.ToError(err)
+}
+
+// ListBundles 列出已安装的方案包，返回 JSON 格式的列表
+func (m *Manager) ListBundles() (string, *dbus.Error) {
+	bundles, err := m.listBundles()
+	return bundles, dbusutil.ToError(err)
+}
+
+// ApplyBundle 应用方案包，某一项设置失败时恢复之前的设置
+func (m *Manager) ApplyBundle(idOrFile string) *dbus.Error {
+	logger.Debugf("ApplyBundle %q", idOrFile)
+	err := m.applyBundle(idOrFile)
+	if err != nil {
+		logger.Warning(err)
+	}
+	return dbusutil.ToError(err)
+}
+
+// SetAppOverride 设置应用 desktopId 的字体和缩放，value 为 JSON 格式，
+// 包含 FontFamily、FontSize、ScaleFactor 和 DPI，值为零的项使用全局设置
+func (m *Manager) SetAppOverride(desktopId, value string) *dbus.Error {
+	logger.Debugf("SetAppOverride desktopId: %q, value: %q", desktopId, value)
+	err := m.setAppOverride(desktopId, value)
+	return dbusutil.ToError(err)
+}
+
+func (m *Manager) RemoveAppOverride(desktopId string) *dbus.Error {
+	logger.Debugf("RemoveAppOverride %q", desktopId)
+	err := m.removeAppOverride(desktopId)
+	return dbusutil.ToError(err)
+}
+
+// ListAppOverrides 返回所有应用的设置，JSON 格式，键为 desktop id
+func (m *Manager) ListAppOverrides() (string, *dbus.Error) {
+	overrides, err := m.listAppOverrides()
+	return overrides, dbusutil.ToError(err)
+}
+
+// GetAppEnvironment 返回启动应用 desktopId 时需要设置的环境变量，格式为 KEY=VALUE
+func (m *Manager) GetAppEnvironment(desktopId string) ([]string, *dbus.Error) {
+	env, err := m.getAppEnvironment(desktopId)
+	return env, dbusutil.ToError(err)
+}
+
+// SetThemeAutoConfig 设置自动主题（deepin-auto）的切换方式和浅色、深色时使用的主题，value 为 JSON 格式，
+// Mode 可以是 sun、fixed 或 power
+func (m *Manager) SetThemeAutoConfig(value string) *dbus.Error {
+	logger.Debugf("SetThemeAutoConfig %q", value)
+	err := m.setThemeAutoConfig(value)
+	return dbusutil.ToError(err)
+}
+
+func (m *Manager) GetThemeAutoConfig() (string, *dbus.Error) {
+	value, err := m.getThemeAutoConfigJSON()
+	return value, dbusutil.ToError(err)
+}
+
+// InstallFonts 把字体文件安装到用户字体目录中，返回安装后的文件。
+// 字体损坏或者已经安装时返回错误，不安装任何文件。字体缓存更新后发送 Refreshed 信号
+func (m *Manager) InstallFonts(files []string) ([]string, *dbus.Error) {
+	logger.Debug("InstallFonts", files)
+	installed, err := m.installFonts(files)
+	return installed, dbusutil.ToError(err)
+}
+
+// RemoveFonts 删除用户字体目录中的字体文件
+func (m *Manager) RemoveFonts(files []string) *dbus.Error {
+	logger.Debug("RemoveFonts", files)
+	err := m.removeFonts(files)
+	return dbusutil.ToError(err)
+}
+
+// ListUserFonts 列出用户安装的字体，返回 JSON 格式的列表
+func (m *Manager) ListUserFonts() (string, *dbus.Error) {
+	list, err := m.listUserFonts()
+	return list, dbusutil.ToError(err)
+}
+
+// GetFontPreview 用字体文件渲染文字 text，返回 PNG 图片的路径，size 为字体的像素大小，为 0 时使用默认大小
+func (m *Manager) GetFontPreview(file, text string, size int32) (string, *dbus.Error) {
+	image, err := fonts.GenPreview(dutils.DecodeURI(file), text, size)
+	return image, dbusutil.ToError(err)
+}
+
 // Delete delete the special 'name'
 func (m *Manager) Delete(ty, name string) *dbus.Error {
 	logger.Debugf("Delete '%s' type '%s'", name, ty)
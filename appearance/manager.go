@@ -42,6 +42,7 @@ import (
 	display "github.com/linuxdeepin/go-dbus-factory/com.deepin.daemon.display"
 	imageeffect "github.com/linuxdeepin/go-dbus-factory/com.deepin.daemon.imageeffect"
 	sessionmanager "github.com/linuxdeepin/go-dbus-factory/com.deepin.sessionmanager"
+	systemPower "github.com/linuxdeepin/go-dbus-factory/com.deepin.system.power"
 	wm "github.com/linuxdeepin/go-dbus-factory/com.deepin.wm"
 	login1 "github.com/linuxdeepin/go-dbus-factory/org.freedesktop.login1"
 	timedate "github.com/linuxdeepin/go-dbus-factory/org.freedesktop.timedate1"
@@ -139,6 +140,12 @@ type Manager struct {
 	WallpaperSlideShow gsprop.String `prop:"access:rw"`
 	WallpaperURIs      gsprop.String
 	QtActiveColor      string `prop:"access:rw"`
+	// 是否根据主屏幕的壁纸自动设置强调色
+	AutoAccentColor bool `prop:"access:rw"`
+	// 从壁纸中提取的颜色，第一个为使用的强调色
+	AccentPalette []string
+	accentMu      sync.Mutex
+	appOverrideMu sync.Mutex
 	// 社区版定制需求，保存窗口圆角值，默认 18
 	WindowRadius       gsprop.Int `prop:"access:rw"`
 
@@ -152,9 +159,13 @@ type Manager struct {
 	timeDate            *timedate.Timedate
 	sessionTimeDate     *sessiontimedate.Timedate
 	imageEffect         *imageeffect.ImageEffect
+	imageEffectObj      dbus.BusObject
 	xSettings           *sessionmanager.XSettings
 	login1Manager       *login1.Manager
 	themeAutoTimer      *time.Timer
+	themeAutoCfg        *themeAutoConfig
+	themeAutoMu         sync.Mutex
+	sysPower            *systemPower.Power
 	display             *display.Display
 	latitude            float64
 	longitude           float64
@@ -207,6 +218,19 @@ type Manager struct {
 		SetMonitorBackground  func() `in:"monitorName,imageFile"`
 		SetWallpaperSlideShow func() `in:"monitorName,wallpaperSlideShow"`
 		GetWallpaperSlideShow func() `in:"monitorName" out:"slideShow"`
+		ExportBundle          func() `in:"name,filename" out:"file"`
+		ListBundles           func() `out:"bundles"`
+		ApplyBundle           func() `in:"idOrFile"`
+		SetAppOverride        func() `in:"desktopId,value"`
+		RemoveAppOverride     func() `in:"desktopId"`
+		ListAppOverrides      func() `out:"overrides"`
+		GetAppEnvironment     func() `in:"desktopId" out:"env"`
+		SetThemeAutoConfig    func() `in:"value"`
+		GetThemeAutoConfig    func() `out:"value"`
+		InstallFonts          func() `in:"files" out:"installed"`
+		RemoveFonts           func() `in:"files"`
+		ListUserFonts         func() `out:"fonts"`
+		GetFontPreview        func() `in:"file,text,size" out:"image"`
 	}
 }
 
@@ -243,6 +267,7 @@ func newManager(service *dbusutil.Service) *Manager {
 	if err != nil {
 		logger.Warning(err)
 	}
+	m.initAutoAccent()
 
 	m.wsLoopMap = make(map[string]*WSLoop)
 	m.wsSchedulerMap = make(map[string]*WSScheduler)
@@ -457,6 +482,7 @@ func (m *Manager) init() error {
 	}
 	m.imageBlur = accounts.NewImageBlur(systemBus)
 	m.imageEffect = imageeffect.NewImageEffect(systemBus)
+	m.imageEffectObj = systemBus.Object(imageEffectServiceName, imageEffectPath)
 
 	m.xSettings = sessionmanager.NewXSettings(sessionBus)
 	theme_thumb.Init(m.getScaleFactor())
@@ -564,6 +590,8 @@ func (m *Manager) init() error {
 		logger.Warning("connect NTP failed:", err)
 	}
 
+	m.initThemeAuto()
+
 	// set gtk theme
 	gtkThemes := subthemes.ListGtkTheme()
 	currentGtkTheme := m.GtkTheme.Get()
@@ -802,6 +830,7 @@ func (m *Manager) doSetMonitorBackground(monitorName string, imageFile string) (
 			logger.Warning("imageEffect Get outputFile:", outputFile)
 		}
 	}()
+	go m.handleBackgroundChangedForAccent(monitorName, file)
 	return file, nil
 }
 
@@ -915,6 +944,10 @@ func (m *Manager) doSetWallpaperSlideShow(monitorName string, wallpaperSlideShow
 		cfg = make(mapMonitorWorkspaceWSPolicy)
 	}
 
+	if !isValidWSPolicy(wallpaperSlideShow) {
+		return fmt.Errorf("invalid wallpaper slideshow policy %q", wallpaperSlideShow)
+	}
+
 	key := genMonitorKeyString(monitorName, int(idx))
 	cfg[key] = wallpaperSlideShow
 	err = m.setPropertyWallpaperSlideShow(cfg)
@@ -1055,7 +1088,13 @@ func (m *Manager) doSetFontSize(size float64) error {
 		return err
 	}
 
-	return m.writeDQtTheme(dQtKeyFontSize, strconv.FormatFloat(size, 'f', 1, 64))
+	err = m.writeDQtTheme(dQtKeyFontSize, strconv.FormatFloat(size, 'f', 1, 64))
+	if err != nil {
+		return err
+	}
+
+	m.updateAppOverrideFontConfigs(size)
+	return nil
 }
 
 func (*Manager) doShow(ifc interface{}) (string, error) {
@@ -1124,6 +1163,7 @@ func (m *Manager) saveWSConfig(monitorSpace string, t time.Time) error {
 	tempCfg.LastChange = t
 	if m.wsLoopMap[monitorSpace] != nil {
 		tempCfg.Showed = m.wsLoopMap[monitorSpace].GetShowed()
+		tempCfg.Last = m.wsLoopMap[monitorSpace].GetLast()
 	}
 	if cfg == nil {
 		cfg = make(mapMonitorWorkspaceWSConfig)
@@ -1137,7 +1177,7 @@ func (m *Manager) autoChangeBg(monitorSpace string, t time.Time) {
 	if m.wsLoopMap[monitorSpace] == nil {
 		return
 	}
-	file := m.wsLoopMap[monitorSpace].GetNext()
+	file := m.getWSNextFile(monitorSpace, t)
 	if file == "" {
 		logger.Warning("file is empty")
 		return
@@ -1168,6 +1208,7 @@ func (m *Manager) initWallpaperSlideshow() {
 	m.loadWSConfig()
 	cfg, err := doUnmarshalWallpaperSlideshow(m.WallpaperSlideShow.Get())
 	if err == nil {
+		slideshowEnabled := false
 		for monitorSpace, policy := range cfg {
 			_, ok := m.wsSchedulerMap[monitorSpace]
 			if !ok {
@@ -1178,19 +1219,20 @@ func (m *Manager) initWallpaperSlideshow() {
 				m.wsLoopMap[monitorSpace] = newWSLoop()
 			}
 			if isValidWSPolicy(policy) {
+				slideshowEnabled = true
 				if policy == wsPolicyLogin {
 					err := m.changeBgAfterLogin(monitorSpace)
 					if err != nil {
 						logger.Warning("failed to change background after login:", err)
 					}
 				} else {
-					nSec, err := strconv.ParseUint(policy, 10, 32)
-					if err == nil && m.wsSchedulerMap[monitorSpace] != nil {
-						m.wsSchedulerMap[monitorSpace].updateInterval(monitorSpace, time.Duration(nSec)*time.Second)
-					}
+					m.startWSScheduler(monitorSpace, policy)
 				}
 			}
 		}
+		if slideshowEnabled {
+			go m.preGenerateWallpaperEffects()
+		}
 	} else {
 		logger.Debug("doUnmarshalWallpaperSlideshow err is ", err)
 	}
@@ -1257,6 +1299,7 @@ func (m *Manager) loadWSConfig() {
 		for _, file := range cfg[monitorSpace].Showed {
 			m.wsLoopMap[monitorSpace].showed[file] = struct{}{}
 		}
+		m.wsLoopMap[monitorSpace].last = cfg[monitorSpace].Last
 		m.wsLoopMap[monitorSpace].mu.Unlock()
 	}
 }
@@ -1275,10 +1318,11 @@ func (m *Manager) updateWSPolicy(policy string) {
 				m.wsLoopMap[monitorSpace] = newWSLoop()
 			}
 			if m.curMonitorSpace == monitorSpace && isValidWSPolicy(policy) {
-				nSec, err := strconv.ParseUint(policy, 10, 32)
-				if err == nil {
-					m.wsSchedulerMap[monitorSpace].lastSetBg = time.Now()
-					m.wsSchedulerMap[monitorSpace].updateInterval(monitorSpace, time.Duration(nSec)*time.Second)
+				m.wsSchedulerMap[monitorSpace].mu.Lock()
+				m.wsSchedulerMap[monitorSpace].lastSetBg = time.Now()
+				m.wsSchedulerMap[monitorSpace].mu.Unlock()
+				if m.startWSScheduler(monitorSpace, policy) {
+					go m.preGenerateWallpaperEffects()
 					err = m.saveWSConfig(monitorSpace, time.Now())
 					if err != nil {
 						logger.Warning(err)
@@ -1394,11 +1438,16 @@ func (m *Manager) resetThemeAutoTimer() {
 	}
 
 	now := time.Now().In(m.loc)
-	changeTime, err := m.getThemeAutoChangeTime(now, m.latitude, m.longitude)
+	cfg := m.getThemeAutoConfig()
+	changeTime, ok, err := m.getThemeAutoNextChange(&cfg, now, m.latitude, m.longitude)
 	if err != nil {
 		logger.Warning("failed to get theme auto change time:", err)
 		return
 	}
+	if !ok {
+		m.themeAutoTimer.Stop()
+		return
+	}
 
 	interval := changeTime.Sub(now)
 	logger.Debug("change theme after:", interval)
@@ -1411,23 +1460,14 @@ func (m *Manager) autoSetTheme(latitude, longitude float64) {
 		return
 	}
 
-	sunriseT, sunsetT, err := m.getSunriseSunset(now, latitude, longitude)
+	cfg := m.getThemeAutoConfig()
+	isLight, err := m.isThemeAutoLight(&cfg, now, latitude, longitude)
 	if err != nil {
 		logger.Warning(err)
 		return
 	}
-	logger.Debugf("now: %v, sunrise: %v, sunset: %v",
-		now, sunriseT, sunsetT)
-	themeName := getThemeAutoName(isDaytime(now, sunriseT, sunsetT))
-	logger.Debug("auto theme name:", themeName)
-
-	currentTheme := m.GtkTheme.Get()
-	if currentTheme != themeName {
-		err = m.doSetGtkTheme(themeName)
-		if err != nil {
-			logger.Warning(err)
-		}
-	}
+	logger.Debugf("auto theme mode: %s, light: %v", cfg.Mode, isLight)
+	m.applyThemeAutoPair(cfg.getPair(isLight))
 }
 
 func (m *Manager) getQtActiveColor() (string, error) {
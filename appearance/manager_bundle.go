@@ -0,0 +1,300 @@
+package appearance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"pkg.deepin.io/dde/daemon/appearance/background"
+	"pkg.deepin.io/dde/daemon/appearance/subthemes"
+	dutils "pkg.deepin.io/lib/utils"
+	"pkg.deepin.io/lib/xdg/basedir"
+)
+
+const systemBundlesDir = "/usr/share/deepin/appearance-bundles"
+
+var userBundlesDir = filepath.Join(basedir.GetUserDataDir(), "deepin/appearance-bundles")
+
+func getBundleDirs() []string {
+	return []string{userBundlesDir, systemBundlesDir}
+}
+
+func (m *Manager) listBundles() (string, error) {
+	infos := listBundles(getBundleDirs(), userBundlesDir)
+	if infos == nil {
+		infos = []*bundleInfo{}
+	}
+	data, err := json.Marshal(infos)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// getBundleFile 返回方案包的路径，idOrFile 可以是 ListBundles 返回的 Id 或者方案包的路径
+func getBundleFile(idOrFile string) (string, error) {
+	if strings.Contains(idOrFile, "/") {
+		return dutils.DecodeURI(idOrFile), nil
+	}
+	for _, info := range listBundles(getBundleDirs(), userBundlesDir) {
+		if info.Id == idOrFile {
+			return info.Path, nil
+		}
+	}
+	return "", fmt.Errorf("bundle %q not found", idOrFile)
+}
+
+func getBundleId(name string) string {
+	id := strings.Map(func(r rune) rune {
+		if r == '/' || r == 0 || r == ' ' {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	return strings.TrimLeft(id, ".")
+}
+
+// getMonitorBackgrounds 返回当前工作区中各显示器的壁纸，键为显示器的角色
+func (m *Manager) getMonitorBackgrounds() map[string]string {
+	idx, err := m.wm.GetCurrentWorkspace(0)
+	if err != nil {
+		logger.Warning(err)
+		return nil
+	}
+	result := make(map[string]string)
+	for monitor, role := range m.monitorMap {
+		uri, err := m.wm.GetWorkspaceBackgroundForMonitor(0, idx, monitor)
+		if err != nil {
+			logger.Warning(err)
+			continue
+		}
+		if uri != "" {
+			result[role] = dutils.DecodeURI(uri)
+		}
+	}
+	return result
+}
+
+func (m *Manager) getCurrentAppearanceProfile(name string) *appearanceProfile {
+	opacity := m.Opacity.Get()
+	windowRadius := m.WindowRadius.Get()
+	m.accentMu.Lock()
+	autoAccentColor := m.AutoAccentColor
+	m.accentMu.Unlock()
+	return &appearanceProfile{
+		Version:         bundleVersion,
+		Name:            name,
+		GtkTheme:        m.GtkTheme.Get(),
+		IconTheme:       m.IconTheme.Get(),
+		CursorTheme:     m.CursorTheme.Get(),
+		StandardFont:    m.StandardFont.Get(),
+		MonospaceFont:   m.MonospaceFont.Get(),
+		FontSize:        m.FontSize.Get(),
+		Opacity:         &opacity,
+		WindowRadius:    &windowRadius,
+		QtActiveColor:   m.QtActiveColor,
+		AutoAccentColor: autoAccentColor,
+		Backgrounds:     m.getMonitorBackgrounds(),
+	}
+}
+
+func (m *Manager) exportBundle(name, filename string) (string, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", errors.New("bundle name is empty")
+	}
+	if filename == "" {
+		id := getBundleId(name)
+		if id == "" {
+			return "", fmt.Errorf("invalid bundle name %q", name)
+		}
+		filename = filepath.Join(userBundlesDir, id+bundleFileExt)
+	} else {
+		filename = dutils.DecodeURI(filename)
+	}
+
+	profile := m.getCurrentAppearanceProfile(name)
+	err := writeBundle(filename, profile)
+	if err != nil {
+		return "", err
+	}
+	return filename, nil
+}
+
+func (m *Manager) applyBundle(idOrFile string) error {
+	filename, err := getBundleFile(idOrFile)
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := ioutil.TempDir("", "dde-appearance-bundle")
+	if err != nil {
+		return err
+	}
+	// 壁纸在设置时会被复制到自定义壁纸目录中，所以可以删除临时目录
+	defer func() {
+		err := os.RemoveAll(tmpDir)
+		if err != nil {
+			logger.Warning(err)
+		}
+	}()
+
+	profile, err := readBundle(filename, tmpDir)
+	if err != nil {
+		return err
+	}
+	err = m.checkAppearanceProfile(profile)
+	if err != nil {
+		return err
+	}
+
+	current := m.getCurrentAppearanceProfile("")
+	return runBundleSteps(m.getBundleSteps(profile, current))
+}
+
+// checkAppearanceProfile 在应用之前检查方案中的主题、字体和壁纸是否可用，尽量避免回滚
+func (m *Manager) checkAppearanceProfile(p *appearanceProfile) error {
+	if p.GtkTheme != "" && p.GtkTheme != autoGtkTheme && !subthemes.IsGtkTheme(p.GtkTheme) {
+		return fmt.Errorf("gtk theme %q is not installed", p.GtkTheme)
+	}
+	if p.IconTheme != "" && !subthemes.IsIconTheme(p.IconTheme) {
+		return fmt.Errorf("icon theme %q is not installed", p.IconTheme)
+	}
+	if p.CursorTheme != "" && !subthemes.IsCursorTheme(p.CursorTheme) {
+		return fmt.Errorf("cursor theme %q is not installed", p.CursorTheme)
+	}
+	for _, file := range p.Backgrounds {
+		if !background.IsBackgroundFile(file) {
+			return fmt.Errorf("invalid background %q", filepath.Base(file))
+		}
+	}
+	return nil
+}
+
+func (m *Manager) newSetStep(ty, value, oldValue string) bundleStep {
+	return bundleStep{
+		name: ty,
+		apply: func() error {
+			return m.set(ty, value)
+		},
+		rollback: func() error {
+			return m.set(ty, oldValue)
+		},
+	}
+}
+
+func (m *Manager) setMonitorBackgrounds(backgrounds map[string]string) error {
+	reverseMonitorMap := m.reverseMonitorMap()
+	for role, file := range backgrounds {
+		monitor, ok := reverseMonitorMap[role]
+		if !ok {
+			logger.Debugf("ignore background of monitor %q", role)
+			continue
+		}
+		_, err := m.doSetMonitorBackground(monitor, file)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getBundleSteps 返回应用方案 p 的步骤，current 是当前的设置，用于回滚
+func (m *Manager) getBundleSteps(p, current *appearanceProfile) []bundleStep {
+	var steps []bundleStep
+	for _, item := range []struct {
+		ty       string
+		value    string
+		oldValue string
+	}{
+		{TypeGtkTheme, p.GtkTheme, current.GtkTheme},
+		{TypeIconTheme, p.IconTheme, current.IconTheme},
+		{TypeCursorTheme, p.CursorTheme, current.CursorTheme},
+		{TypeStandardFont, p.StandardFont, current.StandardFont},
+		{TypeMonospaceFont, p.MonospaceFont, current.MonospaceFont},
+	} {
+		if item.value != "" {
+			steps = append(steps, m.newSetStep(item.ty, item.value, item.oldValue))
+		}
+	}
+
+	if p.FontSize > 0 {
+		steps = append(steps, m.newSetStep(TypeFontSize,
+			strconv.FormatFloat(p.FontSize, 'f', -1, 64),
+			strconv.FormatFloat(current.FontSize, 'f', -1, 64)))
+	}
+
+	if p.Opacity != nil {
+		opacity, oldOpacity := *p.Opacity, *current.Opacity
+		steps = append(steps, bundleStep{
+			name: "opacity",
+			apply: func() error {
+				m.Opacity.Set(opacity)
+				return nil
+			},
+			rollback: func() error {
+				m.Opacity.Set(oldOpacity)
+				return nil
+			},
+		})
+	}
+
+	if p.WindowRadius != nil {
+		radius, oldRadius := *p.WindowRadius, *current.WindowRadius
+		steps = append(steps, bundleStep{
+			name: "window radius",
+			apply: func() error {
+				m.WindowRadius.Set(radius)
+				return nil
+			},
+			rollback: func() error {
+				m.WindowRadius.Set(oldRadius)
+				return nil
+			},
+		})
+	}
+
+	if len(p.Backgrounds) > 0 {
+		steps = append(steps, bundleStep{
+			name: "backgrounds",
+			apply: func() error {
+				return m.setMonitorBackgrounds(p.Backgrounds)
+			},
+			rollback: func() error {
+				return m.setMonitorBackgrounds(current.Backgrounds)
+			},
+		})
+	}
+
+	// 自动强调色依赖壁纸，放在壁纸之后
+	if p.AutoAccentColor || p.QtActiveColor != "" {
+		steps = append(steps, bundleStep{
+			name: "accent color",
+			apply: func() error {
+				return m.setAccentColorFromProfile(p.AutoAccentColor, p.QtActiveColor)
+			},
+			rollback: func() error {
+				return m.setAccentColorFromProfile(current.AutoAccentColor, current.QtActiveColor)
+			},
+		})
+	}
+	return steps
+}
+
+func (m *Manager) setAccentColorFromProfile(auto bool, hexColor string) error {
+	if auto {
+		return m.setAutoAccentColor(true)
+	}
+	err := m.setAutoAccentColor(false)
+	if err != nil {
+		return err
+	}
+	if hexColor == "" {
+		return nil
+	}
+	return m.setQtActiveColor(hexColor)
+}
@@ -0,0 +1,77 @@
+package appearance
+
+import (
+	"encoding/json"
+	"errors"
+
+	"pkg.deepin.io/dde/daemon/appearance/fonts"
+	dutils "pkg.deepin.io/lib/utils"
+)
+
+// installFonts 安装字体文件，某个文件安装失败时删除这次已经安装的文件
+func (m *Manager) installFonts(files []string) ([]string, error) {
+	if len(files) == 0 {
+		return nil, errors.New("no font file")
+	}
+	installed := make([]string, 0, len(files))
+	for _, file := range files {
+		font, err := fonts.InstallFont(dutils.DecodeURI(file))
+		if err != nil {
+			for _, file := range installed {
+				rmErr := fonts.RemoveFont(file)
+				if rmErr != nil {
+					logger.Warning(rmErr)
+				}
+			}
+			return nil, err
+		}
+		logger.Debugf("installed font %q to %q", font.Family, font.File)
+		installed = append(installed, font.File)
+	}
+	m.updateFontCache()
+	return installed, nil
+}
+
+func (m *Manager) removeFonts(files []string) error {
+	if len(files) == 0 {
+		return errors.New("no font file")
+	}
+	var err error
+	removed := 0
+	for _, file := range files {
+		e := fonts.RemoveFont(dutils.DecodeURI(file))
+		if e != nil {
+			logger.Warning(e)
+			err = e
+			continue
+		}
+		removed++
+	}
+	if removed > 0 {
+		m.updateFontCache()
+	}
+	return err
+}
+
+func (m *Manager) listUserFonts() (string, error) {
+	list, err := fonts.ListUserFonts()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// updateFontCache 在后台更新字体缓存，完成后发送 Refreshed 信号让字体列表刷新
+func (m *Manager) updateFontCache() {
+	fonts.UpdateCache(func(err error) {
+		if err != nil {
+			logger.Warning(err)
+		}
+		m.emitSignalRefreshed(TypeStandardFont)
+		m.emitSignalRefreshed(TypeMonospaceFont)
+	})
+}
@@ -1,9 +1,16 @@
 package appearance
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/kelvins/sunrisesunset"
+	"pkg.deepin.io/lib/xdg/basedir"
 )
 
 func (m *Manager) getSunriseSunset(t time.Time, latitude, longitude float64) (time.Time, time.Time, error) {
@@ -27,13 +34,6 @@ func isDaytime(t, sunriseT, sunsetT time.Time) bool {
 	return sunriseT.Before(t) && t.Before(sunsetT)
 }
 
-func getThemeAutoName(isDaytime bool) string {
-	if isDaytime {
-		return "deepin"
-	}
-	return "deepin-dark"
-}
-
 func (m *Manager) getThemeAutoChangeTime(t time.Time, latitude, longitude float64) (time.Time, error) {
 	sunrise, sunset, err := m.getSunriseSunset(t, latitude, longitude)
 	if err != nil {
@@ -59,3 +59,162 @@ func (m *Manager) getThemeAutoChangeTime(t time.Time, latitude, longitude float6
 
 	return nextDaySunrise, nil
 }
+
+// 自动主题的切换方式：
+// sun   根据日出日落时间切换，没有设置经纬度时使用时区对应的经纬度
+// fixed 在固定的时间切换
+// power 根据电源状态切换，使用电池时为深色
+const (
+	themeAutoModeSun   = "sun"
+	themeAutoModeFixed = "fixed"
+	themeAutoModePower = "power"
+)
+
+var themeAutoConfigFile = filepath.Join(basedir.GetUserConfigDir(), "deepin/dde-daemon/appearance/theme-auto.json")
+
+// themeAutoPair 是浅色或深色时使用的主题，为空的项不改变
+type themeAutoPair struct {
+	GtkTheme      string `json:",omitempty"`
+	IconTheme     string `json:",omitempty"`
+	QtActiveColor string `json:",omitempty"`
+}
+
+type themeAutoConfig struct {
+	Mode      string
+	Latitude  *float64 `json:",omitempty"`
+	Longitude *float64 `json:",omitempty"`
+	// fixed 模式下切换为浅色和深色的时间，格式为 15:04
+	LightTime string
+	DarkTime  string
+	Light     themeAutoPair
+	Dark      themeAutoPair
+}
+
+func defaultThemeAutoConfig() *themeAutoConfig {
+	return &themeAutoConfig{
+		Mode:      themeAutoModeSun,
+		LightTime: "07:00",
+		DarkTime:  "19:00",
+		Light:     themeAutoPair{GtkTheme: "deepin"},
+		Dark:      themeAutoPair{GtkTheme: "deepin-dark"},
+	}
+}
+
+// loadThemeAutoConfig 读取配置文件，文件不存在时返回默认配置
+func loadThemeAutoConfig(filename string) (*themeAutoConfig, error) {
+	cfg := defaultThemeAutoConfig()
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	err = json.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, err
+	}
+	err = cfg.check()
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (cfg *themeAutoConfig) save(filename string) error {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+func (cfg *themeAutoConfig) check() error {
+	switch cfg.Mode {
+	case themeAutoModeSun, themeAutoModeFixed, themeAutoModePower:
+	default:
+		return fmt.Errorf("invalid mode %q", cfg.Mode)
+	}
+	if (cfg.Latitude == nil) != (cfg.Longitude == nil) {
+		return errors.New("latitude and longitude must be set together")
+	}
+	if cfg.Latitude != nil && (*cfg.Latitude < -90 || *cfg.Latitude > 90 ||
+		*cfg.Longitude < -180 || *cfg.Longitude > 180) {
+		return fmt.Errorf("invalid coordinate %v, %v", *cfg.Latitude, *cfg.Longitude)
+	}
+	lightMin, err := parseClockTime(cfg.LightTime)
+	if err != nil {
+		return err
+	}
+	darkMin, err := parseClockTime(cfg.DarkTime)
+	if err != nil {
+		return err
+	}
+	if lightMin == darkMin {
+		return errors.New("light time and dark time are the same")
+	}
+	for _, pair := range []*themeAutoPair{&cfg.Light, &cfg.Dark} {
+		if pair.GtkTheme == autoGtkTheme {
+			return errors.New("gtk theme of theme pair can not be " + autoGtkTheme)
+		}
+		if pair.QtActiveColor != "" {
+			_, err := parseHexColor(pair.QtActiveColor)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseClockTime 解析 15:04 格式的时间，返回从 0 点开始的分钟数
+func parseClockTime(str string) (int, error) {
+	t, err := time.Parse("15:04", str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", str)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (cfg *themeAutoConfig) getPair(isLight bool) *themeAutoPair {
+	if isLight {
+		return &cfg.Light
+	}
+	return &cfg.Dark
+}
+
+func atClockTime(t time.Time, minutes int) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), minutes/60, minutes%60, 0, 0, t.Location())
+}
+
+// isFixedLightTime 返回 fixed 模式下 t 是否为浅色时间，支持深色时间跨过 0 点
+func (cfg *themeAutoConfig) isFixedLightTime(t time.Time) bool {
+	lightMin, _ := parseClockTime(cfg.LightTime)
+	darkMin, _ := parseClockTime(cfg.DarkTime)
+	now := t.Hour()*60 + t.Minute()
+	if lightMin < darkMin {
+		return lightMin <= now && now < darkMin
+	}
+	return !(darkMin <= now && now < lightMin)
+}
+
+// getFixedChangeTime 返回 fixed 模式下 t 之后的下一个切换时间
+func (cfg *themeAutoConfig) getFixedChangeTime(t time.Time) time.Time {
+	lightMin, _ := parseClockTime(cfg.LightTime)
+	darkMin, _ := parseClockTime(cfg.DarkTime)
+	var next time.Time
+	for _, minutes := range []int{lightMin, darkMin} {
+		ct := atClockTime(t, minutes)
+		if !ct.After(t) {
+			ct = atClockTime(t.AddDate(0, 0, 1), minutes)
+		}
+		if next.IsZero() || ct.Before(next) {
+			next = ct
+		}
+	}
+	return next
+}
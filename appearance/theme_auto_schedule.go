@@ -0,0 +1,157 @@
+package appearance
+
+import (
+	"encoding/json"
+	"time"
+
+	systemPower "github.com/linuxdeepin/go-dbus-factory/com.deepin.system.power"
+)
+
+func (m *Manager) initThemeAuto() {
+	m.sysPower = systemPower.NewPower(m.sysSigLoop.Conn())
+	m.sysPower.InitSignalExt(m.sysSigLoop, true)
+
+	cfg, err := loadThemeAutoConfig(themeAutoConfigFile)
+	if err != nil {
+		logger.Warning("failed to load theme auto config:", err)
+		cfg = defaultThemeAutoConfig()
+	}
+	m.themeAutoMu.Lock()
+	m.themeAutoCfg = cfg
+	m.themeAutoMu.Unlock()
+
+	err = m.sysPower.OnBattery().ConnectChanged(func(hasValue bool, value bool) {
+		if !hasValue {
+			return
+		}
+		if m.getThemeAutoConfig().Mode != themeAutoModePower {
+			return
+		}
+		logger.Debug("on battery changed:", value)
+		if m.locationValid {
+			m.autoSetTheme(m.latitude, m.longitude)
+		}
+	})
+	if err != nil {
+		logger.Warning(err)
+	}
+}
+
+// getThemeAutoConfig 返回自动主题配置的副本，配置还没有加载时返回默认配置
+func (m *Manager) getThemeAutoConfig() themeAutoConfig {
+	m.themeAutoMu.Lock()
+	defer m.themeAutoMu.Unlock()
+	if m.themeAutoCfg == nil {
+		return *defaultThemeAutoConfig()
+	}
+	return *m.themeAutoCfg
+}
+
+func (m *Manager) setThemeAutoConfig(value string) error {
+	cfg := defaultThemeAutoConfig()
+	err := json.Unmarshal([]byte(value), cfg)
+	if err != nil {
+		return err
+	}
+	err = cfg.check()
+	if err != nil {
+		return err
+	}
+	err = cfg.save(themeAutoConfigFile)
+	if err != nil {
+		return err
+	}
+
+	m.themeAutoMu.Lock()
+	m.themeAutoCfg = cfg
+	m.themeAutoMu.Unlock()
+
+	if m.GtkTheme.Get() == autoGtkTheme && m.locationValid {
+		m.autoSetTheme(m.latitude, m.longitude)
+		m.resetThemeAutoTimer()
+	}
+	return nil
+}
+
+func (m *Manager) getThemeAutoConfigJSON() (string, error) {
+	cfg := m.getThemeAutoConfig()
+	data, err := json.Marshal(&cfg)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// getThemeAutoLocation 返回 sun 模式使用的经纬度，优先使用配置中手动设置的经纬度
+func getThemeAutoLocation(cfg *themeAutoConfig, latitude, longitude float64) (float64, float64) {
+	if cfg.Latitude != nil && cfg.Longitude != nil {
+		return *cfg.Latitude, *cfg.Longitude
+	}
+	return latitude, longitude
+}
+
+// isThemeAutoLight 返回 t 时应该使用浅色还是深色的主题
+func (m *Manager) isThemeAutoLight(cfg *themeAutoConfig, t time.Time, latitude, longitude float64) (bool, error) {
+	switch cfg.Mode {
+	case themeAutoModeFixed:
+		return cfg.isFixedLightTime(t), nil
+	case themeAutoModePower:
+		onBattery, err := m.sysPower.OnBattery().Get(0)
+		if err != nil {
+			return false, err
+		}
+		return !onBattery, nil
+	}
+
+	latitude, longitude = getThemeAutoLocation(cfg, latitude, longitude)
+	sunriseT, sunsetT, err := m.getSunriseSunset(t, latitude, longitude)
+	if err != nil {
+		return false, err
+	}
+	logger.Debugf("now: %v, sunrise: %v, sunset: %v", t, sunriseT, sunsetT)
+	return isDaytime(t, sunriseT, sunsetT), nil
+}
+
+// getThemeAutoNextChange 返回下一次切换主题的时间，power 模式不需要定时切换，返回 false
+func (m *Manager) getThemeAutoNextChange(cfg *themeAutoConfig, t time.Time,
+	latitude, longitude float64) (time.Time, bool, error) {
+	switch cfg.Mode {
+	case themeAutoModeFixed:
+		return cfg.getFixedChangeTime(t), true, nil
+	case themeAutoModePower:
+		return time.Time{}, false, nil
+	}
+
+	latitude, longitude = getThemeAutoLocation(cfg, latitude, longitude)
+	changeTime, err := m.getThemeAutoChangeTime(t, latitude, longitude)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return changeTime, true, nil
+}
+
+func (m *Manager) applyThemeAutoPair(pair *themeAutoPair) {
+	if pair.GtkTheme != "" {
+		err := m.doSetGtkTheme(pair.GtkTheme)
+		if err != nil {
+			logger.Warning(err)
+		}
+	}
+	if pair.IconTheme != "" {
+		err := m.set(TypeIconTheme, pair.IconTheme)
+		if err != nil {
+			logger.Warning(err)
+		}
+	}
+
+	m.accentMu.Lock()
+	autoAccentColor := m.AutoAccentColor
+	m.accentMu.Unlock()
+	// 自动强调色优先
+	if pair.QtActiveColor != "" && !autoAccentColor && pair.QtActiveColor != m.QtActiveColor {
+		err := m.setQtActiveColor(pair.QtActiveColor)
+		if err != nil {
+			logger.Warning(err)
+		}
+	}
+}
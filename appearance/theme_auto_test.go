@@ -0,0 +1,105 @@
+package appearance
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThemeAutoConfigCheck(t *testing.T) {
+	cfg := defaultThemeAutoConfig()
+	assert.Nil(t, cfg.check())
+
+	cfg.Mode = "unknown"
+	assert.NotNil(t, cfg.check())
+
+	cfg = defaultThemeAutoConfig()
+	latitude := 39.9
+	cfg.Latitude = &latitude
+	assert.NotNil(t, cfg.check())
+	longitude := 116.4
+	cfg.Longitude = &longitude
+	assert.Nil(t, cfg.check())
+	latitude = 100
+	assert.NotNil(t, cfg.check())
+
+	cfg = defaultThemeAutoConfig()
+	cfg.DarkTime = "25:00"
+	assert.NotNil(t, cfg.check())
+	cfg.DarkTime = cfg.LightTime
+	assert.NotNil(t, cfg.check())
+
+	cfg = defaultThemeAutoConfig()
+	cfg.Dark.GtkTheme = autoGtkTheme
+	assert.NotNil(t, cfg.check())
+}
+
+func TestThemeAutoFixedTime(t *testing.T) {
+	cfg := defaultThemeAutoConfig()
+	cfg.Mode = themeAutoModeFixed
+	cfg.LightTime = "07:30"
+	cfg.DarkTime = "19:00"
+
+	day := func(hour, min int) time.Time {
+		return time.Date(2020, 6, 1, hour, min, 0, 0, time.UTC)
+	}
+	assert.False(t, cfg.isFixedLightTime(day(7, 29)))
+	assert.True(t, cfg.isFixedLightTime(day(7, 30)))
+	assert.True(t, cfg.isFixedLightTime(day(18, 59)))
+	assert.False(t, cfg.isFixedLightTime(day(19, 0)))
+
+	assert.Equal(t, day(7, 30), cfg.getFixedChangeTime(day(3, 0)))
+	assert.Equal(t, day(19, 0), cfg.getFixedChangeTime(day(7, 30)))
+	assert.Equal(t, time.Date(2020, 6, 2, 7, 30, 0, 0, time.UTC), cfg.getFixedChangeTime(day(20, 0)))
+
+	// 浅色时间跨过 0 点
+	cfg.LightTime = "22:00"
+	cfg.DarkTime = "06:00"
+	assert.True(t, cfg.isFixedLightTime(day(23, 0)))
+	assert.True(t, cfg.isFixedLightTime(day(1, 0)))
+	assert.False(t, cfg.isFixedLightTime(day(12, 0)))
+	assert.Equal(t, time.Date(2020, 6, 2, 6, 0, 0, 0, time.UTC), cfg.getFixedChangeTime(day(23, 0)))
+}
+
+func TestThemeAutoLocation(t *testing.T) {
+	cfg := defaultThemeAutoConfig()
+	latitude, longitude := getThemeAutoLocation(cfg, 1, 2)
+	assert.Equal(t, 1.0, latitude)
+	assert.Equal(t, 2.0, longitude)
+
+	manualLatitude, manualLongitude := 39.9, 116.4
+	cfg.Latitude = &manualLatitude
+	cfg.Longitude = &manualLongitude
+	latitude, longitude = getThemeAutoLocation(cfg, 1, 2)
+	assert.Equal(t, 39.9, latitude)
+	assert.Equal(t, 116.4, longitude)
+}
+
+func TestLoadThemeAutoConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "theme-auto")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "theme-auto.json")
+	cfg, err := loadThemeAutoConfig(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, defaultThemeAutoConfig(), cfg)
+
+	err = ioutil.WriteFile(filename, []byte(`{"Mode":"power","Dark":{"IconTheme":"bloom-dark"}}`), 0644)
+	assert.Nil(t, err)
+	cfg, err = loadThemeAutoConfig(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, themeAutoModePower, cfg.Mode)
+	assert.Equal(t, "deepin", cfg.Light.GtkTheme)
+	assert.Equal(t, "bloom-dark", cfg.getPair(false).IconTheme)
+	assert.Equal(t, "07:00", cfg.LightTime)
+
+	err = ioutil.WriteFile(filename, []byte(`{"Mode":"bad"}`), 0644)
+	assert.Nil(t, err)
+	_, err = loadThemeAutoConfig(filename)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,538 @@
+package appearance
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"pkg.deepin.io/lib/utils"
+)
+
+// 除了 "login"、"wakeup" 和以秒为单位的间隔，轮播策略还可以是一个 JSON 对象，例如：
+//
+//	{"Type":"interval","Interval":600,"Order":"sequential","Files":["/a.jpg","/b.jpg"]}
+//	{"Type":"sun","Day":["/day1.jpg","/day2.jpg"],"Night":["/night.jpg"]}
+//	{"Type":"weekday","Weekdays":{"Monday":["/a.jpg"],"Saturday":["/b.jpg","/c.jpg"]}}
+//	{"Type":"timed","Manifest":"/usr/share/backgrounds/dynamic/dynamic.xml"}
+//
+// sun 和 weekday 在日出日落或者日期变化时切换壁纸，设置了 Interval 时还会在同一组壁纸中轮播。
+const (
+	wsPolicyTypeInterval = "interval"
+	wsPolicyTypeSun      = "sun"
+	wsPolicyTypeWeekday  = "weekday"
+	wsPolicyTypeTimed    = "timed"
+
+	wsOrderRandom     = "random"
+	wsOrderSequential = "sequential"
+
+	// 无法获取位置时使用的日出日落时间
+	wsDefaultSunriseHour = 6
+	wsDefaultSunsetHour  = 18
+)
+
+type wsPolicy struct {
+	Type     string
+	Interval uint32              `json:",omitempty"`
+	Order    string              `json:",omitempty"`
+	Files    []string            `json:",omitempty"`
+	Day      []string            `json:",omitempty"`
+	Night    []string            `json:",omitempty"`
+	Weekdays map[string][]string `json:",omitempty"`
+	Manifest string              `json:",omitempty"`
+
+	weekdays map[time.Weekday][]string
+	timed    *wsTimedWallpaper
+}
+
+// wsSunFunc 返回 t 所在那天的日出和日落时间
+type wsSunFunc func(t time.Time) (sunrise, sunset time.Time)
+
+func isExtendedWSPolicy(policy string) bool {
+	return strings.HasPrefix(strings.TrimSpace(policy), "{")
+}
+
+func parseWSPolicy(policy string) (*wsPolicy, error) {
+	var p wsPolicy
+	err := json.Unmarshal([]byte(policy), &p)
+	if err != nil {
+		return nil, err
+	}
+	err = p.init()
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (p *wsPolicy) init() error {
+	switch p.Order {
+	case "", wsOrderRandom, wsOrderSequential:
+	default:
+		return fmt.Errorf("invalid order %q", p.Order)
+	}
+	p.Files = decodeWSFiles(p.Files)
+
+	switch p.Type {
+	case wsPolicyTypeInterval:
+		if p.Interval == 0 {
+			return errors.New("interval is 0")
+		}
+	case wsPolicyTypeSun:
+		if len(p.Day) == 0 && len(p.Night) == 0 {
+			return errors.New("both day and night wallpapers are empty")
+		}
+		p.Day = decodeWSFiles(p.Day)
+		p.Night = decodeWSFiles(p.Night)
+	case wsPolicyTypeWeekday:
+		p.weekdays = make(map[time.Weekday][]string)
+		for name, files := range p.Weekdays {
+			weekday, err := parseWeekday(name)
+			if err != nil {
+				return err
+			}
+			p.weekdays[weekday] = decodeWSFiles(files)
+		}
+		if len(p.weekdays) == 0 {
+			return errors.New("weekdays is empty")
+		}
+	case wsPolicyTypeTimed:
+		if p.Manifest == "" {
+			return errors.New("manifest is empty")
+		}
+		timed, err := loadTimedWallpaper(utils.DecodeURI(p.Manifest))
+		if err != nil {
+			return err
+		}
+		p.timed = timed
+	default:
+		return fmt.Errorf("invalid policy type %q", p.Type)
+	}
+	return nil
+}
+
+func decodeWSFiles(files []string) []string {
+	if len(files) == 0 {
+		return nil
+	}
+	result := make([]string, len(files))
+	for i, file := range files {
+		result[i] = utils.DecodeURI(file)
+	}
+	return result
+}
+
+func parseWeekday(name string) (time.Weekday, error) {
+	name = strings.ToLower(name)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		dayName := strings.ToLower(d.String())
+		if name == dayName || name == dayName[:3] {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday %q", name)
+}
+
+func (p *wsPolicy) ordered() bool {
+	return p.Order == wsOrderSequential
+}
+
+// isTimeBased 返回壁纸是否由时间决定，而不只是按间隔轮播
+func (p *wsPolicy) isTimeBased() bool {
+	return p.Type != wsPolicyTypeInterval
+}
+
+// filesAt 返回时间 t 时参与轮播的壁纸，为空表示所有壁纸；ok 为 false 表示此时不需要更换壁纸
+func (p *wsPolicy) filesAt(t time.Time, sunFn wsSunFunc) (files []string, ok bool) {
+	switch p.Type {
+	case wsPolicyTypeInterval:
+		return p.Files, true
+	case wsPolicyTypeSun:
+		sunrise, sunset := sunFn(t)
+		if isDaytime(t, sunrise, sunset) || t.Equal(sunrise) {
+			files = p.Day
+		} else {
+			files = p.Night
+		}
+		return files, len(files) > 0
+	case wsPolicyTypeWeekday:
+		files = p.weekdays[t.Weekday()]
+		return files, len(files) > 0
+	case wsPolicyTypeTimed:
+		file := p.timed.fileAt(t)
+		return []string{file}, file != ""
+	}
+	return nil, false
+}
+
+// nextChange 返回 t 之后下一次需要更换壁纸的时间
+func (p *wsPolicy) nextChange(t time.Time, sunFn wsSunFunc) time.Time {
+	var next time.Time
+	switch p.Type {
+	case wsPolicyTypeInterval:
+		return t.Add(time.Duration(p.Interval) * time.Second)
+	case wsPolicyTypeSun:
+		next = nextSunChange(t, sunFn)
+	case wsPolicyTypeWeekday:
+		y, m, d := t.Date()
+		next = time.Date(y, m, d+1, 0, 0, 0, 0, t.Location())
+	case wsPolicyTypeTimed:
+		return p.timed.nextChange(t)
+	}
+
+	if p.Interval > 0 {
+		intervalNext := t.Add(time.Duration(p.Interval) * time.Second)
+		if intervalNext.Before(next) {
+			next = intervalNext
+		}
+	}
+	return next
+}
+
+func nextSunChange(t time.Time, sunFn wsSunFunc) time.Time {
+	sunrise, sunset := sunFn(t)
+	if t.Before(sunrise) {
+		return sunrise
+	}
+	if t.Before(sunset) {
+		return sunset
+	}
+	nextSunrise, _ := sunFn(t.AddDate(0, 0, 1))
+	return nextSunrise
+}
+
+func defaultSunriseSunset(t time.Time) (time.Time, time.Time) {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, wsDefaultSunriseHour, 0, 0, 0, t.Location()),
+		time.Date(y, m, d, wsDefaultSunsetHour, 0, 0, 0, t.Location())
+}
+
+type wsTimedSlot struct {
+	file     string
+	start    time.Duration // 相对于周期开始的偏移
+	duration time.Duration
+}
+
+// wsTimedWallpaper 是按时间变化的动态壁纸，slots 首尾相接构成一个周期，周期不断重复
+type wsTimedWallpaper struct {
+	start time.Time
+	// daily 为 true 时，周期从每天的零点开始，忽略 start
+	daily bool
+	slots []wsTimedSlot
+	cycle time.Duration
+}
+
+func (tw *wsTimedWallpaper) addSlot(file string, duration time.Duration) {
+	tw.slots = append(tw.slots, wsTimedSlot{
+		file:     file,
+		start:    tw.cycle,
+		duration: duration,
+	})
+	tw.cycle += duration
+}
+
+func (tw *wsTimedWallpaper) cycleStart(t time.Time) time.Time {
+	if tw.daily {
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+	elapsed := t.Sub(tw.start) % tw.cycle
+	if elapsed < 0 {
+		elapsed += tw.cycle
+	}
+	return t.Add(-elapsed)
+}
+
+func (tw *wsTimedWallpaper) slotIndex(offset time.Duration) int {
+	return sort.Search(len(tw.slots), func(i int) bool {
+		return tw.slots[i].start+tw.slots[i].duration > offset
+	})
+}
+
+func (tw *wsTimedWallpaper) fileAt(t time.Time) string {
+	start := tw.cycleStart(t)
+	idx := tw.slotIndex(t.Sub(start))
+	if idx >= len(tw.slots) {
+		return tw.slots[len(tw.slots)-1].file
+	}
+	return tw.slots[idx].file
+}
+
+// nextChange 返回 t 之后第一次更换为不同壁纸的时间
+func (tw *wsTimedWallpaper) nextChange(t time.Time) time.Time {
+	start := tw.cycleStart(t)
+	idx := tw.slotIndex(t.Sub(start))
+	if idx >= len(tw.slots) {
+		// 一天不足 24 小时，例如夏令时
+		return start.AddDate(0, 0, 1)
+	}
+	file := tw.slots[idx].file
+	for i := 1; i <= len(tw.slots); i++ {
+		j := idx + i
+		cycleOffset := start
+		if j >= len(tw.slots) {
+			j -= len(tw.slots)
+			if tw.daily {
+				cycleOffset = start.AddDate(0, 0, 1)
+			} else {
+				cycleOffset = start.Add(tw.cycle)
+			}
+		}
+		if tw.slots[j].file != file {
+			return cycleOffset.Add(tw.slots[j].start)
+		}
+	}
+	// 只有一张壁纸
+	return start.Add(tw.cycle)
+}
+
+func loadTimedWallpaper(filename string) (*wsTimedWallpaper, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(filename)
+	var tw *wsTimedWallpaper
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "<") {
+		tw, err = parseGnomeTimedWallpaper(data, dir)
+	} else {
+		tw, err = parseJSONTimedWallpaper(data, dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %q: %v", filename, err)
+	}
+	return tw, nil
+}
+
+func resolveManifestFile(dir, file string) string {
+	file = utils.DecodeURI(strings.TrimSpace(file))
+	if file != "" && !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	return file
+}
+
+type gnomeTimedWallpaper struct {
+	StartTime struct {
+		Year   int     `xml:"year"`
+		Month  int     `xml:"month"`
+		Day    int     `xml:"day"`
+		Hour   int     `xml:"hour"`
+		Minute int     `xml:"minute"`
+		Second float64 `xml:"second"`
+	} `xml:"starttime"`
+	Items []gnomeTimedItem `xml:",any"`
+}
+
+type gnomeTimedItem struct {
+	XMLName  xml.Name
+	Duration float64 `xml:"duration"`
+	File     struct {
+		Path  string   `xml:",chardata"`
+		Sizes []string `xml:"size"`
+	} `xml:"file"`
+	From string `xml:"from"`
+}
+
+func (item *gnomeTimedItem) file() string {
+	if len(item.File.Sizes) > 0 {
+		return item.File.Sizes[0]
+	}
+	return item.File.Path
+}
+
+// parseGnomeTimedWallpaper 解析 GNOME 的动态壁纸 XML，过渡阶段继续显示过渡前的壁纸
+func parseGnomeTimedWallpaper(data []byte, dir string) (*wsTimedWallpaper, error) {
+	var bg gnomeTimedWallpaper
+	err := xml.Unmarshal(data, &bg)
+	if err != nil {
+		return nil, err
+	}
+
+	st := bg.StartTime
+	tw := &wsTimedWallpaper{
+		start: time.Date(st.Year, time.Month(st.Month), st.Day, st.Hour, st.Minute,
+			int(st.Second), 0, time.Local),
+	}
+	for _, item := range bg.Items {
+		duration := time.Duration(item.Duration * float64(time.Second))
+		if duration <= 0 {
+			continue
+		}
+		var file string
+		switch item.XMLName.Local {
+		case "static":
+			file = item.file()
+		case "transition":
+			file = item.From
+		default:
+			continue
+		}
+		file = resolveManifestFile(dir, file)
+		if file == "" {
+			return nil, fmt.Errorf("empty file in %s", item.XMLName.Local)
+		}
+		tw.addSlot(file, duration)
+	}
+	if len(tw.slots) == 0 {
+		return nil, errors.New("no wallpaper")
+	}
+	return tw, nil
+}
+
+type jsonTimedWallpaper struct {
+	Items []struct {
+		Time string // 15:04
+		File string
+	}
+}
+
+// parseJSONTimedWallpaper 解析以一天中的时间为键的 JSON 清单，每天重复
+func parseJSONTimedWallpaper(data []byte, dir string) (*wsTimedWallpaper, error) {
+	var manifest jsonTimedWallpaper
+	err := json.Unmarshal(data, &manifest)
+	if err != nil {
+		return nil, err
+	}
+	if len(manifest.Items) == 0 {
+		return nil, errors.New("no wallpaper")
+	}
+
+	type item struct {
+		offset time.Duration
+		file   string
+	}
+	items := make([]item, 0, len(manifest.Items))
+	for _, it := range manifest.Items {
+		t, err := time.Parse("15:04", it.Time)
+		if err != nil {
+			return nil, err
+		}
+		file := resolveManifestFile(dir, it.File)
+		if file == "" {
+			return nil, fmt.Errorf("empty file at %s", it.Time)
+		}
+		items = append(items, item{
+			offset: time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute,
+			file:   file,
+		})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].offset < items[j].offset
+	})
+
+	tw := &wsTimedWallpaper{daily: true}
+	// 零点到第一个时间点之间显示前一天最后的壁纸
+	if items[0].offset > 0 {
+		tw.addSlot(items[len(items)-1].file, items[0].offset)
+	}
+	for i, it := range items {
+		end := 24 * time.Hour
+		if i+1 < len(items) {
+			end = items[i+1].offset
+		}
+		if end > it.offset {
+			tw.addSlot(it.file, end-it.offset)
+		}
+	}
+	return tw, nil
+}
+
+// getWSSunriseSunset 使用当前位置计算日出日落时间，没有位置信息时使用默认时间
+func (m *Manager) getWSSunriseSunset(t time.Time) (time.Time, time.Time) {
+	latitude, longitude := m.latitude, m.longitude
+	if !m.locationValid {
+		zone, err := m.timeDate.Timezone().Get(0)
+		if err != nil {
+			logger.Warning(err)
+		}
+		coordinate, ok := m.coordinateMap[zone]
+		if !ok {
+			return defaultSunriseSunset(t)
+		}
+		latitude, longitude = coordinate.latitude, coordinate.longitude
+	}
+	if m.loc != nil {
+		t = t.In(m.loc)
+	}
+	sunrise, sunset, err := m.getSunriseSunset(t, latitude, longitude)
+	if err != nil {
+		logger.Warning("failed to get sunrise and sunset:", err)
+		return defaultSunriseSunset(t)
+	}
+	return sunrise, sunset
+}
+
+// getWSPolicy 返回 monitorSpace 的扩展轮播策略，不是扩展策略时返回 nil
+func (m *Manager) getWSPolicy(monitorSpace string) *wsPolicy {
+	cfg, err := doUnmarshalWallpaperSlideshow(m.WallpaperSlideShow.Get())
+	if err != nil {
+		return nil
+	}
+	policy := cfg[monitorSpace]
+	if !isExtendedWSPolicy(policy) {
+		return nil
+	}
+	p, err := parseWSPolicy(policy)
+	if err != nil {
+		logger.Warningf("invalid wallpaper slideshow policy %q: %v", policy, err)
+		return nil
+	}
+	return p
+}
+
+// startWSScheduler 按照 policy 启动 monitorSpace 的轮播，policy 不需要定时器时返回 false
+func (m *Manager) startWSScheduler(monitorSpace, policy string) bool {
+	scheduler := m.wsSchedulerMap[monitorSpace]
+	if scheduler == nil {
+		return false
+	}
+
+	if !isExtendedWSPolicy(policy) {
+		nSec, err := strconv.ParseUint(policy, 10, 32)
+		if err != nil {
+			return false
+		}
+		scheduler.updateInterval(monitorSpace, time.Duration(nSec)*time.Second)
+		return true
+	}
+
+	p, err := parseWSPolicy(policy)
+	if err != nil {
+		logger.Warningf("invalid wallpaper slideshow policy %q: %v", policy, err)
+		return false
+	}
+	if !p.isTimeBased() {
+		scheduler.updateInterval(monitorSpace, time.Duration(p.Interval)*time.Second)
+		return true
+	}
+	scheduler.updateSchedule(monitorSpace, func(t time.Time) time.Time {
+		return p.nextChange(t, m.getWSSunriseSunset)
+	})
+	// 壁纸由时间决定，立即切换到当前时间对应的壁纸
+	go m.autoChangeBg(monitorSpace, time.Now())
+	return true
+}
+
+func (m *Manager) getWSNextFile(monitorSpace string, t time.Time) string {
+	loop := m.wsLoopMap[monitorSpace]
+	p := m.getWSPolicy(monitorSpace)
+	if p == nil {
+		return loop.GetNext()
+	}
+
+	files, ok := p.filesAt(t, m.getWSSunriseSunset)
+	if !ok {
+		return ""
+	}
+	if p.Type == wsPolicyTypeTimed {
+		return files[0]
+	}
+	return loop.GetNextIn(files, p.ordered())
+}
@@ -0,0 +1,168 @@
+package appearance
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseWSPolicy(t *testing.T) {
+	p, err := parseWSPolicy(`{"Type":"interval","Interval":60,"Order":"sequential","Files":["file:///a.jpg","/b.jpg"]}`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/a.jpg", "/b.jpg"}, p.Files)
+	assert.True(t, p.ordered())
+	assert.False(t, p.isTimeBased())
+
+	p, err = parseWSPolicy(`{"Type":"weekday","Weekdays":{"Monday":["/a.jpg"],"sat":["/b.jpg"]}}`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/a.jpg"}, p.weekdays[time.Monday])
+	assert.Equal(t, []string{"/b.jpg"}, p.weekdays[time.Saturday])
+
+	for _, policy := range []string{
+		`{"Type":"interval"}`,
+		`{"Type":"interval","Interval":60,"Order":"shuffle"}`,
+		`{"Type":"sun"}`,
+		`{"Type":"weekday","Weekdays":{"someday":["/a.jpg"]}}`,
+		`{"Type":"timed"}`,
+		`{"Type":"timed","Manifest":"/nonexistent.xml"}`,
+		`{"Type":"unknown"}`,
+		`{`,
+	} {
+		_, err = parseWSPolicy(policy)
+		assert.NotNil(t, err, policy)
+	}
+}
+
+func testSunFunc(t time.Time) (time.Time, time.Time) {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 7, 0, 0, 0, t.Location()),
+		time.Date(y, m, d, 19, 30, 0, 0, t.Location())
+}
+
+func Test_wsPolicySun(t *testing.T) {
+	p, err := parseWSPolicy(`{"Type":"sun","Day":["/day.jpg"],"Night":["/night.jpg"]}`)
+	assert.Nil(t, err)
+
+	at := func(hour, min int) time.Time {
+		return time.Date(2020, 6, 1, hour, min, 0, 0, time.UTC)
+	}
+	files, ok := p.filesAt(at(12, 0), testSunFunc)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"/day.jpg"}, files)
+	files, _ = p.filesAt(at(7, 0), testSunFunc)
+	assert.Equal(t, []string{"/day.jpg"}, files)
+	files, _ = p.filesAt(at(22, 0), testSunFunc)
+	assert.Equal(t, []string{"/night.jpg"}, files)
+	files, _ = p.filesAt(at(3, 0), testSunFunc)
+	assert.Equal(t, []string{"/night.jpg"}, files)
+
+	assert.Equal(t, at(7, 0), p.nextChange(at(3, 0), testSunFunc))
+	assert.Equal(t, at(19, 30), p.nextChange(at(7, 0), testSunFunc))
+	assert.Equal(t, at(7, 0).AddDate(0, 0, 1), p.nextChange(at(20, 0), testSunFunc))
+
+	// 设置了间隔时在同一组壁纸中轮播
+	p.Interval = 3600
+	assert.Equal(t, at(13, 0), p.nextChange(at(12, 0), testSunFunc))
+	assert.Equal(t, at(19, 30), p.nextChange(at(19, 0), testSunFunc))
+
+	// 没有夜间壁纸时，夜间不更换
+	p, err = parseWSPolicy(`{"Type":"sun","Day":["/day.jpg"]}`)
+	assert.Nil(t, err)
+	_, ok = p.filesAt(at(22, 0), testSunFunc)
+	assert.False(t, ok)
+}
+
+func Test_wsPolicyWeekday(t *testing.T) {
+	p, err := parseWSPolicy(`{"Type":"weekday","Weekdays":{"Monday":["/a.jpg","/b.jpg"]}}`)
+	assert.Nil(t, err)
+
+	monday := time.Date(2020, 6, 1, 15, 0, 0, 0, time.UTC)
+	files, ok := p.filesAt(monday, testSunFunc)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"/a.jpg", "/b.jpg"}, files)
+	_, ok = p.filesAt(monday.AddDate(0, 0, 1), testSunFunc)
+	assert.False(t, ok)
+	assert.Equal(t, time.Date(2020, 6, 2, 0, 0, 0, 0, time.UTC), p.nextChange(monday, testSunFunc))
+}
+
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	filename := filepath.Join(dir, name)
+	err := ioutil.WriteFile(filename, []byte(content), 0644)
+	assert.Nil(t, err)
+	return filename
+}
+
+func Test_timedWallpaperJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "timed-wallpaper")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	manifest := writeTestFile(t, dir, "dynamic.json", `{"Items":[
+		{"Time":"18:00","File":"evening.jpg"},
+		{"Time":"06:00","File":"/usr/share/morning.jpg"},
+		{"Time":"12:00","File":"noon.jpg"}]}`)
+	p, err := parseWSPolicy(`{"Type":"timed","Manifest":"` + manifest + `"}`)
+	assert.Nil(t, err)
+
+	at := func(hour, min int) time.Time {
+		return time.Date(2020, 6, 1, hour, min, 0, 0, time.Local)
+	}
+	assert.Equal(t, filepath.Join(dir, "evening.jpg"), p.timed.fileAt(at(3, 0)))
+	assert.Equal(t, "/usr/share/morning.jpg", p.timed.fileAt(at(6, 0)))
+	assert.Equal(t, filepath.Join(dir, "noon.jpg"), p.timed.fileAt(at(17, 59)))
+	assert.Equal(t, filepath.Join(dir, "evening.jpg"), p.timed.fileAt(at(23, 0)))
+
+	assert.Equal(t, at(6, 0), p.nextChange(at(3, 0), testSunFunc))
+	assert.Equal(t, at(12, 0), p.nextChange(at(6, 0), testSunFunc))
+	// 零点前后是同一张壁纸
+	assert.Equal(t, at(6, 0).AddDate(0, 0, 1), p.nextChange(at(20, 0), testSunFunc))
+}
+
+func Test_timedWallpaperGnomeXML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "timed-wallpaper")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	manifest := writeTestFile(t, dir, "dynamic.xml", `<background>
+  <starttime>
+    <year>2020</year><month>6</month><day>1</day>
+    <hour>8</hour><minute>00</minute><second>00</second>
+  </starttime>
+  <static>
+    <duration>3600.0</duration>
+    <file>a.jpg</file>
+  </static>
+  <transition type="overlay">
+    <duration>600.0</duration>
+    <from>a.jpg</from>
+    <to>/b.jpg</to>
+  </transition>
+  <static>
+    <duration>1800.0</duration>
+    <file>
+      <size width="1920" height="1080">/b.jpg</size>
+    </file>
+  </static>
+</background>`)
+	tw, err := loadTimedWallpaper(manifest)
+	assert.Nil(t, err)
+	assert.Len(t, tw.slots, 3)
+	assert.Equal(t, 100*time.Minute, tw.cycle)
+
+	start := time.Date(2020, 6, 1, 8, 0, 0, 0, time.Local)
+	a := filepath.Join(dir, "a.jpg")
+	assert.Equal(t, a, tw.fileAt(start))
+	// 过渡阶段显示过渡前的壁纸
+	assert.Equal(t, a, tw.fileAt(start.Add(65*time.Minute)))
+	assert.Equal(t, "/b.jpg", tw.fileAt(start.Add(70*time.Minute)))
+	// 周期重复，开始时间之前也按周期计算
+	assert.Equal(t, a, tw.fileAt(start.Add(100*time.Minute)))
+	assert.Equal(t, "/b.jpg", tw.fileAt(start.Add(-10*time.Minute)))
+
+	assert.Equal(t, start.Add(70*time.Minute), tw.nextChange(start.Add(time.Minute)))
+	assert.Equal(t, start.Add(100*time.Minute), tw.nextChange(start.Add(80*time.Minute)))
+}
@@ -15,6 +15,12 @@ import (
 	"pkg.deepin.io/lib/utils"
 )
 
+const (
+	imageEffectServiceName = "com.deepin.daemon.ImageEffect"
+	imageEffectPath        = "/com/deepin/daemon/ImageEffect"
+	imageEffectInterface   = imageEffectServiceName
+)
+
 type changeBgFunc func(monitorSpace string, t time.Time)
 
 // wallpaper slideshow scheduler
@@ -26,12 +32,13 @@ type WSScheduler struct {
 	intervalChanged chan struct{}
 	running         bool
 	fn              changeBgFunc
+	// nextChange 不为 nil 时按它返回的时间更换壁纸，而不是按固定的间隔
+	nextChange func(t time.Time) time.Time
 }
 
 func newWSScheduler(fun changeBgFunc) *WSScheduler {
 	s := &WSScheduler{
-		quit:            make(chan chan struct{}),
-		intervalChanged: make(chan struct{}),
+		intervalChanged: make(chan struct{}, 1),
 		fn:              fun,
 	}
 	return s
@@ -41,6 +48,15 @@ func (s *WSScheduler) remainDuration() time.Duration {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.nextChange != nil {
+		now := time.Now()
+		result := s.nextChange(now).Sub(now)
+		if result < time.Second {
+			result = time.Second
+		}
+		return result
+	}
+
 	elapsed := time.Since(s.lastSetBg)
 	if elapsed < 0 {
 		elapsed = 0
@@ -49,8 +65,7 @@ func (s *WSScheduler) remainDuration() time.Duration {
 	return result
 }
 
-func (s *WSScheduler) loopCheck(mointorSpace string) {
-	s.running = true
+func (s *WSScheduler) loopCheck(mointorSpace string, quit chan chan struct{}) {
 	for {
 		select {
 		case <-s.intervalChanged:
@@ -59,9 +74,10 @@ func (s *WSScheduler) loopCheck(mointorSpace string) {
 			if s.fn != nil {
 				go s.fn(mointorSpace, t)
 			}
+			s.mu.Lock()
 			s.lastSetBg = t
-		case ch := <-s.quit:
-			s.running = false
+			s.mu.Unlock()
+		case ch := <-quit:
 			close(ch)
 			return
 		}
@@ -76,27 +92,52 @@ func (s *WSScheduler) updateInterval(monitorSpace string, v time.Duration) {
 
 	s.mu.Lock()
 	s.interval = v
+	s.nextChange = nil
+	s.mu.Unlock()
+	s.start(monitorSpace)
+}
+
+// updateSchedule 按照 nextChange 返回的时间更换壁纸，用于和时间相关的轮播策略
+func (s *WSScheduler) updateSchedule(monitorSpace string, nextChange func(t time.Time) time.Time) {
+	s.mu.Lock()
+	s.nextChange = nextChange
+	s.mu.Unlock()
+	s.start(monitorSpace)
+}
+
+func (s *WSScheduler) start(monitorSpace string) {
+	s.mu.Lock()
 	if s.running {
 		s.mu.Unlock()
-		s.intervalChanged <- struct{}{}
+		// 循环可能正在退出，不能阻塞
+		select {
+		case s.intervalChanged <- struct{}{}:
+		default:
+		}
 		return
 	}
-
+	s.running = true
+	quit := make(chan chan struct{})
+	s.quit = quit
 	s.mu.Unlock()
-	go s.loopCheck(monitorSpace)
+	go s.loopCheck(monitorSpace, quit)
 }
 
 func (s *WSScheduler) stop() {
+	// 循环中会获取 s.mu，等待循环退出时不能持有锁
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if !s.running {
+		s.mu.Unlock()
 		return
 	}
+	s.running = false
+	quit := s.quit
+	s.quit = nil
+	s.mu.Unlock()
 
 	logger.Debug("stop")
 	ch := make(chan struct{})
-	s.quit <- ch
+	quit <- ch
 	// wait quit
 	<-ch
 }
@@ -105,6 +146,8 @@ func (s *WSScheduler) stop() {
 type WSConfig struct {
 	LastChange time.Time
 	Showed     []string
+	// 顺序轮播时最后显示的壁纸
+	Last string `json:",omitempty"`
 }
 
 func loadWSConfig(filename string) (mapMonitorWorkspaceWSConfig, error) {
@@ -147,6 +190,7 @@ type WSLoop struct {
 	showed    map[string]struct{}
 	all       []string
 	fsChanged bool
+	last      string
 }
 
 func newWSLoop() *WSLoop {
@@ -169,7 +213,13 @@ func (wrl *WSLoop) GetShowed() []string {
 	return result
 }
 
-func (wrl *WSLoop) getNotShowed() []string {
+func (wrl *WSLoop) GetLast() string {
+	wrl.mu.Lock()
+	defer wrl.mu.Unlock()
+	return wrl.last
+}
+
+func (wrl *WSLoop) getAll() []string {
 	if wrl.fsChanged {
 		bgs := background.ListBackground()
 		bgFiles := make([]string, 0, len(bgs))
@@ -178,9 +228,12 @@ func (wrl *WSLoop) getNotShowed() []string {
 		}
 		wrl.all = bgFiles
 	}
+	return wrl.all
+}
 
+func (wrl *WSLoop) getNotShowed(files []string) []string {
 	var result []string
-	for _, file := range wrl.all {
+	for _, file := range files {
 		_, ok := wrl.showed[file]
 		if !ok {
 			result = append(result, file)
@@ -189,14 +242,32 @@ func (wrl *WSLoop) getNotShowed() []string {
 	return result
 }
 
-func (wrl *WSLoop) getNext() string {
-	notShowed := wrl.getNotShowed()
+func (wrl *WSLoop) getNext(files []string) string {
+	notShowed := wrl.getNotShowed(files)
 	if len(notShowed) == 0 {
 		return ""
 	}
 	idx := wrl.rand.Intn(len(notShowed))
 	next := notShowed[idx]
 	wrl.showed[next] = struct{}{}
+	wrl.last = next
+	return next
+}
+
+// getNextOrdered 按 files 的顺序返回 last 之后的壁纸
+func (wrl *WSLoop) getNextOrdered(files []string) string {
+	if len(files) == 0 {
+		return ""
+	}
+	next := files[0]
+	for idx, file := range files {
+		if file == wrl.last {
+			next = files[(idx+1)%len(files)]
+			break
+		}
+	}
+	wrl.showed[next] = struct{}{}
+	wrl.last = next
 	return next
 }
 
@@ -217,17 +288,30 @@ func (wrl *WSLoop) AddToShowed(file string) {
 }
 
 func (wrl *WSLoop) GetNext() string {
+	return wrl.GetNextIn(nil, false)
+}
+
+// GetNextIn 从 files 中选出下一张壁纸，files 为空时从所有壁纸中选择，
+// ordered 为 true 时按顺序选择，否则随机选择一张还没有显示过的
+func (wrl *WSLoop) GetNextIn(files []string, ordered bool) string {
 	wrl.mu.Lock()
 	defer wrl.mu.Unlock()
 
-	next := wrl.getNext()
+	if len(files) == 0 {
+		files = wrl.getAll()
+	}
+	if ordered {
+		return wrl.getNextOrdered(files)
+	}
+
+	next := wrl.getNext(files)
 	if next != "" {
 		return next
 	}
 
-	if len(wrl.all) > 0 {
+	if len(files) > 0 {
 		wrl.reset()
-		next = wrl.getNext()
+		next = wrl.getNext(files)
 	}
 
 	return next
@@ -244,6 +328,31 @@ func isValidWSPolicy(policy string) bool {
 		return true
 	}
 
+	if isExtendedWSPolicy(policy) {
+		_, err := parseWSPolicy(policy)
+		if err != nil {
+			logger.Warningf("invalid wallpaper slideshow policy %q: %v", policy, err)
+		}
+		return err == nil
+	}
+
 	_, err := strconv.ParseUint(policy, 10, 32)
 	return err == nil
 }
+
+// preGenerateWallpaperEffects 让 image_effect 在后台为轮播的所有壁纸生成默认效果图，
+// 避免切换壁纸时等待效果图生成
+func (m *Manager) preGenerateWallpaperEffects() {
+	if m.imageEffectObj == nil {
+		return
+	}
+	bgs := background.ListBackground()
+	files := make([]string, 0, len(bgs))
+	for _, bg := range bgs {
+		files = append(files, utils.DecodeURI(bg.Id))
+	}
+	err := m.imageEffectObj.Call(imageEffectInterface+".PreGenerate", 0, "", files).Err
+	if err != nil {
+		logger.Warning("failed to pre-generate wallpaper effects:", err)
+	}
+}
@@ -41,6 +41,16 @@ type Authority struct {
 		CheckCookie func() `in:"user,cookie" out:"result,authToken"`
 		HasCookie   func() `in:"user" out:"result"`
 	}
+
+	signals *struct { //nolint
+		// 认证失败，app 为请求认证的程序名，accounts 据此记录用户的失败登录尝试。
+		// 总线配置只允许 root 接收这个信号，其他用户不能得知哪些用户认证失败。
+		AuthFailed struct {
+			user     string
+			authType string
+			app      string
+		}
+	}
 }
 
 func newAuthority(service *dbusutil.Service) *Authority {
@@ -304,3 +314,30 @@ func (a *Authority) releaseFprintTransaction(ignoreTxId uint64, devPath dbus.Obj
 		fpTx.mu.Unlock()
 	}
 }
+
+func (a *Authority) emitAuthFailed(authType, user, sender string) {
+	if user == "" {
+		return
+	}
+	app := a.getSenderApp(sender)
+	logger.Infof("auth failed, user: %q, authType: %q, app: %q", user, authType, app)
+	err := a.service.Emit(a, "AuthFailed", user, authType, app)
+	if err != nil {
+		logger.Warning(err)
+	}
+}
+
+// getSenderApp 返回 D-Bus 连接所属进程的程序名
+func (a *Authority) getSenderApp(sender string) string {
+	pid, err := a.service.GetConnPID(sender)
+	if err != nil {
+		logger.Warning(err)
+		return ""
+	}
+	exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		logger.Warning(err)
+		return ""
+	}
+	return filepath.Base(exe)
+}
@@ -161,6 +161,9 @@ func (tx *FPrintTransaction) authenticate() error {
 		tx.PropsMu.Unlock()
 
 		tx.sendResult(verifyErr == nil)
+		if verifyErr == errVerifyFailed {
+			tx.parent.emitAuthFailed(tx.authType, user, tx.Sender)
+		}
 	}()
 
 	return nil
@@ -219,6 +222,7 @@ func (tx *FPrintTransaction) claimDevice(deviceObj *fprint.Device, user string)
 }
 
 var errClaimLost = errors.New("claim lost")
+var errVerifyFailed = errors.New("verify failed")
 
 func (tx *FPrintTransaction) verify(deviceObj *fprint.Device, user, scanType string) error {
 	if !tx.isClaimOk() {
@@ -290,7 +294,7 @@ func (tx *FPrintTransaction) verify(deviceObj *fprint.Device, user, scanType str
 	if verifyOk {
 		return nil
 	}
-	return errors.New("verify failed")
+	return errVerifyFailed
 }
 
 func shouldLimitVerifyTime(deviceObj *fprint.Device) bool {
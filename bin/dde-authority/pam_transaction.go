@@ -129,6 +129,9 @@ func (tx *PAMTransaction) Authenticate(sender dbus.Sender) *dbus.Error {
 			tx.sendResult(err == nil)
 			if err != nil {
 				logger.Warning(err)
+				if err != errTxEnd {
+					tx.parent.emitAuthFailed(tx.authType, tx.getUser(), tx.Sender)
+				}
 			}
 		}()
 	}
@@ -0,0 +1,71 @@
+package dock
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/godbus/dbus"
+	"pkg.deepin.io/dde/daemon/appearance/appoverride"
+	"pkg.deepin.io/lib/appinfo/desktopappinfo"
+)
+
+// startManager 的 LaunchAppWithOptions 支持用这个选项替换 desktop 文件中的 Exec
+const launchOptionOverrideExec = "desktop-override-exec"
+
+// getAppOverrideOptions 返回启动应用时使用的选项，用于应用在个性化中单独设置的字体和缩放，
+// 没有单独的设置时返回 nil
+func getAppOverrideOptions(desktopFile string) map[string]dbus.Variant {
+	desktopId := getDesktopIdByFilePath(desktopFile)
+	if desktopId == "" {
+		desktopId = filepath.Base(desktopFile)
+	}
+	env, err := appoverride.GetEnviron(desktopId)
+	if err != nil {
+		logger.Warning(err)
+		return nil
+	}
+	if len(env) == 0 {
+		return nil
+	}
+
+	dai, err := desktopappinfo.NewDesktopAppInfoFromFile(desktopFile)
+	if err != nil {
+		logger.Warning(err)
+		return nil
+	}
+	cmdline := dai.GetCommandline()
+	if cmdline == "" {
+		return nil
+	}
+	return map[string]dbus.Variant{
+		launchOptionOverrideExec: dbus.MakeVariant(getEnvExec(env, cmdline)),
+	}
+}
+
+// getEnvExec 用 env 命令包装 desktop 文件中的 Exec，参数按照 desktop entry 规范加引号
+func getEnvExec(env []string, exec string) string {
+	args := make([]string, 0, len(env)+2)
+	args = append(args, "env")
+	for _, e := range env {
+		args = append(args, quoteExecArg(e))
+	}
+	args = append(args, exec)
+	return strings.Join(args, " ")
+}
+
+func quoteExecArg(arg string) string {
+	if !strings.ContainsAny(arg, " \t\n\"'\\><~|&;$*?#()`") {
+		return arg
+	}
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range arg {
+		switch r {
+		case '"', '`', '$', '\\':
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
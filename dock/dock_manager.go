@@ -33,6 +33,8 @@ import (
 	"github.com/linuxdeepin/go-dbus-factory/com.deepin.sessionmanager"
 	"github.com/linuxdeepin/go-dbus-factory/com.deepin.wm"
 	"github.com/linuxdeepin/go-dbus-factory/com.deepin.wmswitcher"
+	"github.com/linuxdeepin/go-dbus-factory/org.freedesktop.login1"
+	"github.com/linuxdeepin/go-dbus-factory/org.freedesktop.screensaver"
 	"github.com/linuxdeepin/go-x11-client"
 	"pkg.deepin.io/dde/daemon/common/dsync"
 	"pkg.deepin.io/gir/gio-2.0"
@@ -86,6 +88,8 @@ type Manager struct {
 
 	tempUndockedFiles strv.Strv
 
+	usageStats *usageStats
+
 	// dbus objects:
 	launcher     *launcher.Launcher
 	ddeLauncher  *libDDELauncher.Launcher
@@ -94,6 +98,12 @@ type Manager struct {
 	startManager *sessionmanager.StartManager
 	wmSwitcher   *wmswitcher.WMSwitcher
 	wmName       string
+
+	// 用于在会话空闲、锁屏和待机时暂停使用统计
+	sessionManager *sessionmanager.SessionManager
+	screenSaver    *screensaver.ScreenSaver
+	loginManager   *login1.Manager
+	sysSigLoop     *dbusutil.SignalLoop
 	//nolint
 	signals *struct {
 		ServiceRestarted struct{}
@@ -111,26 +121,33 @@ type Manager struct {
 	}
 	//nolint
 	methods *struct {
-		ActivateWindow            func() `in:"win"`
-		CloseWindow               func() `in:"win"`
-		MaximizeWindow            func() `in:"win"`
-		MinimizeWindow            func() `in:"win"`
-		MakeWindowAbove           func() `in:"win"`
-		MoveWindow                func() `in:"win"`
-		PreviewWindow             func() `in:"win"`
-		GetEntryIDs               func() `out:"list"`
-		SetFrontendWindowRect     func() `in:"x,y,width,height"`
-		IsDocked                  func() `in:"desktopFile" out:"value"`
-		RequestDock               func() `in:"desktopFile,index" out:"ok"`
-		RequestUndock             func() `in:"desktopFile" out:"ok"`
-		MoveEntry                 func() `in:"index,newIndex"`
-		IsOnDock                  func() `in:"desktopFile" out:"value"`
-		QueryWindowIdentifyMethod func() `in:"win" out:"identifyMethod"`
-		GetDockedAppsDesktopFiles func() `out:"desktopFiles"`
-		SetPluginSettings         func() `in:"jsonStr"`
-		GetPluginSettings         func() `out:"jsonStr"`
-		MergePluginSettings       func() `in:"jsonStr"`
-		RemovePluginSettings      func() `in:"key1,key2List"`
+		ActivateWindow             func() `in:"win"`
+		CloseWindow                func() `in:"win"`
+		MaximizeWindow             func() `in:"win"`
+		MinimizeWindow             func() `in:"win"`
+		MakeWindowAbove            func() `in:"win"`
+		MoveWindow                 func() `in:"win"`
+		PreviewWindow              func() `in:"win"`
+		GetEntryIDs                func() `out:"list"`
+		SetFrontendWindowRect      func() `in:"x,y,width,height"`
+		IsDocked                   func() `in:"desktopFile" out:"value"`
+		RequestDock                func() `in:"desktopFile,index" out:"ok"`
+		RequestUndock              func() `in:"desktopFile" out:"ok"`
+		MoveEntry                  func() `in:"index,newIndex"`
+		IsOnDock                   func() `in:"desktopFile" out:"value"`
+		QueryWindowIdentifyMethod  func() `in:"win" out:"identifyMethod"`
+		GetWindowAppId             func() `in:"win" out:"appId"`
+		GetDockedAppsDesktopFiles  func() `out:"desktopFiles"`
+		SetPluginSettings          func() `in:"jsonStr"`
+		GetPluginSettings          func() `out:"jsonStr"`
+		MergePluginSettings        func() `in:"jsonStr"`
+		RemovePluginSettings       func() `in:"key1,key2List"`
+		GetAppUsageStats           func() `in:"begin,end" out:"jsonStr"`
+		GetDailyUsageStats         func() `in:"begin,end" out:"jsonStr"`
+		GetFrequentlyUsedApps      func() `in:"days,limit" out:"appIds"`
+		GetUsageStatsRetentionDays func() `out:"days"`
+		SetUsageStatsRetentionDays func() `in:"days"`
+		ClearUsageStats            func()
 	}
 }
 
@@ -182,9 +199,17 @@ func (m *Manager) destroy() {
 		m.settings = nil
 	}
 
+	if m.usageStats != nil {
+		m.usageStats.destroy()
+	}
+
 	m.launcher.RemoveHandler(proxy.RemoveAllHandlers)
 	m.ddeLauncher.RemoveHandler(proxy.RemoveAllHandlers)
+	m.sessionManager.RemoveHandler(proxy.RemoveAllHandlers)
+	m.screenSaver.RemoveHandler(proxy.RemoveAllHandlers)
+	m.loginManager.RemoveHandler(proxy.RemoveAllHandlers)
 	m.sessionSigLoop.Stop()
+	m.sysSigLoop.Stop()
 	m.syncConfig.Destroy()
 
 	err := m.service.StopExport(m)
@@ -194,7 +219,13 @@ func (m *Manager) destroy() {
 }
 
 func (m *Manager) launch(desktopFile string, timestamp uint32, files []string) {
-	err := m.startManager.LaunchApp(dbus.FlagNoAutoStart, desktopFile, timestamp, files)
+	var err error
+	options := getAppOverrideOptions(desktopFile)
+	if options != nil {
+		err = m.startManager.LaunchAppWithOptions(dbus.FlagNoAutoStart, desktopFile, timestamp, files, options)
+	} else {
+		err = m.startManager.LaunchApp(dbus.FlagNoAutoStart, desktopFile, timestamp, files)
+	}
 	if err != nil {
 		logger.Warningf("launch %q failed: %v", desktopFile, err)
 	}
@@ -421,6 +452,11 @@ func (m *Manager) QueryWindowIdentifyMethod(wid uint32) (string, *dbus.Error) {
 	return "", dbusutil.ToError(fmt.Errorf("window %d not found", wid))
 }
 
+// GetWindowAppId 返回窗口所属应用的 id(desktop id，不含 .desktop 后缀)，未识别时返回空字符串
+func (m *Manager) GetWindowAppId(wid uint32) (string, *dbus.Error) {
+	return m.getWindowAppId(x.Window(wid)), nil
+}
+
 func (m *Manager) GetDockedAppsDesktopFiles() ([]string, *dbus.Error) {
 	var result []string
 	for _, entry := range m.Entries.FilterDocked() {
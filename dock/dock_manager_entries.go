@@ -45,6 +45,7 @@ func (m *Manager) markAppLaunched(appInfo *AppInfo) {
 	}
 	file := appInfo.GetFileName()
 	logger.Debug("markAppLaunched", file)
+	m.usageStats.markLaunched(appInfo.GetId())
 
 	go func() {
 		err := common.ActivateSysDaemonService(m.appsObj.ServiceName_())
@@ -85,6 +86,10 @@ func (m *Manager) attachOrDetachWindow(winInfo *WindowInfo) {
 		if winInfo.entryInnerId == "" {
 			winInfo.entryInnerId, winInfo.appInfo = m.identifyWindow(winInfo)
 			m.markAppLaunched(winInfo.appInfo)
+			if m.isActiveWindow(win) {
+				// 窗口在被识别之前就已经获得了焦点
+				m.usageStats.setFocus(getAppInfoId(winInfo.appInfo))
+			}
 		} else {
 			logger.Debugf("win %v identified", win)
 		}
@@ -231,6 +231,8 @@ func (m *Manager) init() error {
 	m.listenWMSwitcherSignal()
 
 	m.registerIdentifyWindowFuncs()
+	m.usageStats = newUsageStats(usageStatsFile)
+	m.listenUsageStatsPause(systemBus)
 	m.initEntries()
 	m.pluginSettings = newPluginSettingsStorage(m)
 
@@ -0,0 +1,174 @@
+package dock
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/godbus/dbus"
+	"github.com/linuxdeepin/go-dbus-factory/com.deepin.sessionmanager"
+	"github.com/linuxdeepin/go-dbus-factory/org.freedesktop.login1"
+	"github.com/linuxdeepin/go-dbus-factory/org.freedesktop.screensaver"
+	x "github.com/linuxdeepin/go-x11-client"
+	"pkg.deepin.io/lib/dbusutil"
+)
+
+func getAppInfoId(appInfo *AppInfo) string {
+	if appInfo == nil {
+		return ""
+	}
+	return appInfo.GetId()
+}
+
+func (m *Manager) getWindowAppId(win x.Window) string {
+	if win == 0 {
+		return ""
+	}
+	winInfo := m.getWindowInfo(win)
+	if winInfo == nil {
+		return ""
+	}
+	winInfo.Lock()
+	appId := getAppInfoId(winInfo.appInfo)
+	winInfo.Unlock()
+	return appId
+}
+
+func (m *Manager) isActiveWindow(win x.Window) bool {
+	m.activeWindowMu.Lock()
+	result := m.activeWindow == win
+	m.activeWindowMu.Unlock()
+	return result
+}
+
+func (m *Manager) updateUsageStatsFocus(win x.Window) {
+	if m.usageStats == nil {
+		return
+	}
+	m.usageStats.setFocus(m.getWindowAppId(win))
+}
+
+func (m *Manager) setUsageStatsPaused(reason uint, paused bool) {
+	m.usageStats.setPaused(reason, paused)
+	if !paused {
+		m.activeWindowMu.Lock()
+		win := m.activeWindow
+		m.activeWindowMu.Unlock()
+		m.updateUsageStatsFocus(win)
+	}
+}
+
+// listenUsageStatsPause 在会话空闲、锁屏和待机时暂停统计焦点时长
+func (m *Manager) listenUsageStatsPause(systemBus *dbus.Conn) {
+	sessionBus := m.service.Conn()
+	m.screenSaver = screensaver.NewScreenSaver(sessionBus)
+	m.screenSaver.InitSignalExt(m.sessionSigLoop, true)
+	_, err := m.screenSaver.ConnectIdleOn(func() {
+		m.setUsageStatsPaused(usageStatsPauseIdle, true)
+	})
+	if err != nil {
+		logger.Warning(err)
+	}
+	_, err = m.screenSaver.ConnectIdleOff(func() {
+		m.setUsageStatsPaused(usageStatsPauseIdle, false)
+	})
+	if err != nil {
+		logger.Warning(err)
+	}
+
+	m.sessionManager = sessionmanager.NewSessionManager(sessionBus)
+	m.sessionManager.InitSignalExt(m.sessionSigLoop, true)
+	err = m.sessionManager.Locked().ConnectChanged(func(hasValue bool, locked bool) {
+		if !hasValue {
+			return
+		}
+		m.setUsageStatsPaused(usageStatsPauseLocked, locked)
+	})
+	if err != nil {
+		logger.Warning(err)
+	}
+	locked, err := m.sessionManager.Locked().Get(0)
+	if err != nil {
+		logger.Warning(err)
+	} else if locked {
+		m.setUsageStatsPaused(usageStatsPauseLocked, true)
+	}
+
+	m.sysSigLoop = dbusutil.NewSignalLoop(systemBus, 10)
+	m.sysSigLoop.Start()
+	m.loginManager = login1.NewManager(systemBus)
+	m.loginManager.InitSignalExt(m.sysSigLoop, true)
+	_, err = m.loginManager.ConnectPrepareForSleep(func(before bool) {
+		m.setUsageStatsPaused(usageStatsPauseSleep, before)
+	})
+	if err != nil {
+		logger.Warning(err)
+	}
+}
+
+func parseUsageStatsRange(begin, end int64) (time.Time, time.Time, error) {
+	beginTime := time.Unix(begin, 0)
+	endTime := time.Unix(end, 0)
+	if end == 0 {
+		endTime = time.Now()
+	}
+	if endTime.Before(beginTime) {
+		return beginTime, endTime, errors.New("invalid time range")
+	}
+	return beginTime, endTime, nil
+}
+
+// GetAppUsageStats 返回 [begin, end] 时间范围内(Unix 时间戳，按天计算)各应用的使用统计，
+// 结果为 JSON 格式，键为应用 id，end 为 0 表示到当前时间为止。
+func (m *Manager) GetAppUsageStats(begin, end int64) (string, *dbus.Error) {
+	beginTime, endTime, err := parseUsageStatsRange(begin, end)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	usages := m.usageStats.getAppUsages(beginTime, endTime)
+	data, err := json.Marshal(usages)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return string(data), nil
+}
+
+// GetDailyUsageStats 与 GetAppUsageStats 类似，但按日期分组，键为 "2006-01-02" 格式的日期，
+// 可用于生成屏幕使用时间报告。
+func (m *Manager) GetDailyUsageStats(begin, end int64) (string, *dbus.Error) {
+	beginTime, endTime, err := parseUsageStatsRange(begin, end)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	usages := m.usageStats.getDailyUsages(beginTime, endTime)
+	data, err := json.Marshal(usages)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return string(data), nil
+}
+
+// GetFrequentlyUsedApps 返回最近 days 天内最常用的应用 id 列表，最多 limit 个，limit 为 0 表示不限制。
+func (m *Manager) GetFrequentlyUsedApps(days, limit uint32) ([]string, *dbus.Error) {
+	if days == 0 {
+		days = uint32(m.usageStats.getRetentionDays())
+	}
+	return m.usageStats.getFrequentlyUsedApps(int(days), int(limit)), nil
+}
+
+func (m *Manager) GetUsageStatsRetentionDays() (uint32, *dbus.Error) {
+	return uint32(m.usageStats.getRetentionDays()), nil
+}
+
+func (m *Manager) SetUsageStatsRetentionDays(days uint32) *dbus.Error {
+	if days == 0 || days > usageStatsMaxRetentionDays {
+		return dbusutil.ToError(errors.New("invalid retention days"))
+	}
+	m.usageStats.setRetentionDays(int(days))
+	return nil
+}
+
+func (m *Manager) ClearUsageStats() *dbus.Error {
+	m.usageStats.clear()
+	return nil
+}
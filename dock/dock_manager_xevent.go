@@ -134,6 +134,7 @@ func (m *Manager) handleActiveWindowChanged() {
 	m.activeWindowMu.Unlock()
 
 	logger.Debug("Active window changed", activeWindow)
+	m.updateUsageStatsFocus(activeWindow)
 
 	m.Entries.mu.RLock()
 	for _, entry := range m.Entries.items {
@@ -0,0 +1,362 @@
+package dock
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"pkg.deepin.io/lib/xdg/basedir"
+)
+
+const (
+	usageStatsVersion              = 1
+	usageStatsDefaultRetentionDays = 90
+	usageStatsMaxRetentionDays     = 3650
+	usageStatsDateLayout           = "2006-01-02"
+	usageStatsSaveDelay            = 10 * time.Second
+)
+
+// 会话空闲、锁屏和待机时用户没有在使用焦点应用，暂停统计焦点时长
+const (
+	usageStatsPauseIdle uint = 1 << iota
+	usageStatsPauseLocked
+	usageStatsPauseSleep
+)
+
+var usageStatsFile = filepath.Join(basedir.GetUserConfigDir(),
+	"deepin/dde-daemon/dock/usage-stats.json")
+
+// AppUsage 是某个应用在一段时间内的使用统计，时间单位为秒。
+type AppUsage struct {
+	FocusedTime  int64
+	LaunchCount  uint32
+	HourFocused  [24]int64
+	HourLaunched [24]uint32
+}
+
+func (u *AppUsage) add(other *AppUsage) {
+	u.FocusedTime += other.FocusedTime
+	u.LaunchCount += other.LaunchCount
+	for i := 0; i < 24; i++ {
+		u.HourFocused[i] += other.HourFocused[i]
+		u.HourLaunched[i] += other.HourLaunched[i]
+	}
+}
+
+type usageStatsData struct {
+	Version       int
+	RetentionDays int
+	// date => app id => usage
+	Days map[string]map[string]*AppUsage
+}
+
+type usageStats struct {
+	mu       sync.Mutex
+	data     usageStatsData
+	filename string
+
+	focusAppId string
+	focusStart time.Time
+	// 暂停统计焦点时长的原因，为 0 时才统计
+	pauseReasons uint
+
+	saveTimer *time.Timer
+	saving    bool
+
+	now func() time.Time
+}
+
+func newUsageStats(filename string) *usageStats {
+	s := &usageStats{
+		filename: filename,
+		now:      time.Now,
+	}
+	err := s.load()
+	if err != nil && !os.IsNotExist(err) {
+		logger.Warning("failed to load usage stats:", err)
+	}
+	if s.data.Days == nil {
+		s.data.Days = make(map[string]map[string]*AppUsage)
+	}
+	if s.data.RetentionDays <= 0 {
+		s.data.RetentionDays = usageStatsDefaultRetentionDays
+	}
+	s.data.Version = usageStatsVersion
+
+	s.saveTimer = time.AfterFunc(usageStatsSaveDelay, func() {
+		s.mu.Lock()
+		s.saving = false
+		s.mu.Unlock()
+
+		err := s.save()
+		if err != nil {
+			logger.Warning("failed to save usage stats:", err)
+		}
+	})
+	s.saveTimer.Stop()
+	return s
+}
+
+func (s *usageStats) load() error {
+	content, err := ioutil.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, &s.data)
+}
+
+func (s *usageStats) save() error {
+	s.mu.Lock()
+	s.pruneLocked()
+	content, err := json.Marshal(&s.data)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(s.filename), 0755)
+	if err != nil {
+		return err
+	}
+	tmpFile := s.filename + ".tmp"
+	err = ioutil.WriteFile(tmpFile, content, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, s.filename)
+}
+
+// requestSave 需要在持有 s.mu 的情况下调用
+func (s *usageStats) requestSave() {
+	if s.saving {
+		return
+	}
+	s.saving = true
+	s.saveTimer.Reset(usageStatsSaveDelay)
+}
+
+// pruneLocked 删除超出保留天数的记录，需要在持有 s.mu 的情况下调用
+func (s *usageStats) pruneLocked() {
+	oldest := s.now().AddDate(0, 0, -s.data.RetentionDays+1).Format(usageStatsDateLayout)
+	for date := range s.data.Days {
+		if date < oldest {
+			delete(s.data.Days, date)
+		}
+	}
+}
+
+func (s *usageStats) getUsageLocked(date time.Time, appId string) *AppUsage {
+	key := date.Format(usageStatsDateLayout)
+	apps := s.data.Days[key]
+	if apps == nil {
+		apps = make(map[string]*AppUsage)
+		s.data.Days[key] = apps
+	}
+	usage := apps[appId]
+	if usage == nil {
+		usage = &AppUsage{}
+		apps[appId] = usage
+	}
+	return usage
+}
+
+// addFocusedTimeLocked 把 [start, end) 时间段按小时拆分，累加到对应日期和小时上。
+func (s *usageStats) addFocusedTimeLocked(appId string, start, end time.Time) {
+	for start.Before(end) {
+		// 按本地时间的整点对齐，time.Truncate 是基于 UTC 的
+		next := time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), 0, 0, 0,
+			start.Location()).Add(time.Hour)
+		if next.After(end) {
+			next = end
+		}
+		seconds := int64(next.Sub(start) / time.Second)
+		if seconds > 0 {
+			usage := s.getUsageLocked(start, appId)
+			usage.FocusedTime += seconds
+			usage.HourFocused[start.Hour()] += seconds
+		}
+		start = next
+	}
+}
+
+func (s *usageStats) flushFocusLocked(now time.Time) {
+	if s.focusAppId == "" {
+		return
+	}
+	if s.pauseReasons == 0 && now.After(s.focusStart) {
+		s.addFocusedTimeLocked(s.focusAppId, s.focusStart, now)
+		s.requestSave()
+	}
+	s.focusStart = now
+}
+
+// setFocus 记录焦点应用的切换，appId 为空表示当前没有焦点应用。
+func (s *usageStats) setFocus(appId string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.focusAppId == appId {
+		return
+	}
+	now := s.now()
+	s.flushFocusLocked(now)
+	s.focusAppId = appId
+	s.focusStart = now
+}
+
+// setPaused 设置或解除暂停的原因 reason。暂停时记入之前的焦点时长并清除焦点应用，
+// 所有原因都解除后从现在开始统计，焦点应用由之后的 setFocus 设置。
+func (s *usageStats) setPaused(reason uint, paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reasons := s.pauseReasons
+	if paused {
+		reasons |= reason
+	} else {
+		reasons &^= reason
+	}
+	if (reasons == 0) == (s.pauseReasons == 0) {
+		s.pauseReasons = reasons
+		return
+	}
+
+	now := s.now()
+	if reasons != 0 {
+		s.flushFocusLocked(now)
+		s.focusAppId = ""
+	}
+	s.pauseReasons = reasons
+	s.focusStart = now
+}
+
+func (s *usageStats) markLaunched(appId string) {
+	if appId == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	usage := s.getUsageLocked(now, appId)
+	usage.LaunchCount++
+	usage.HourLaunched[now.Hour()]++
+	s.requestSave()
+}
+
+// forEachDayLocked 遍历 [begin, end] 日期范围内的记录
+func (s *usageStats) forEachDayLocked(begin, end time.Time, fn func(date string, apps map[string]*AppUsage)) {
+	beginKey := begin.Format(usageStatsDateLayout)
+	endKey := end.Format(usageStatsDateLayout)
+	for date, apps := range s.data.Days {
+		if date < beginKey || date > endKey {
+			continue
+		}
+		fn(date, apps)
+	}
+}
+
+func (s *usageStats) getAppUsages(begin, end time.Time) map[string]*AppUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.flushFocusLocked(s.now())
+	result := make(map[string]*AppUsage)
+	s.forEachDayLocked(begin, end, func(date string, apps map[string]*AppUsage) {
+		for appId, usage := range apps {
+			sum := result[appId]
+			if sum == nil {
+				sum = &AppUsage{}
+				result[appId] = sum
+			}
+			sum.add(usage)
+		}
+	})
+	return result
+}
+
+func (s *usageStats) getDailyUsages(begin, end time.Time) map[string]map[string]*AppUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.flushFocusLocked(s.now())
+	result := make(map[string]map[string]*AppUsage)
+	s.forEachDayLocked(begin, end, func(date string, apps map[string]*AppUsage) {
+		dayResult := make(map[string]*AppUsage, len(apps))
+		for appId, usage := range apps {
+			usageCopy := *usage
+			dayResult[appId] = &usageCopy
+		}
+		result[date] = dayResult
+	})
+	return result
+}
+
+// getFrequentlyUsedApps 按启动次数排序，启动次数相同时按焦点时长排序。
+func (s *usageStats) getFrequentlyUsedApps(days int, limit int) []string {
+	end := s.now()
+	begin := end.AddDate(0, 0, -days+1)
+	usages := s.getAppUsages(begin, end)
+
+	appIds := make([]string, 0, len(usages))
+	for appId := range usages {
+		appIds = append(appIds, appId)
+	}
+	sort.Slice(appIds, func(i, j int) bool {
+		a := usages[appIds[i]]
+		b := usages[appIds[j]]
+		if a.LaunchCount != b.LaunchCount {
+			return a.LaunchCount > b.LaunchCount
+		}
+		if a.FocusedTime != b.FocusedTime {
+			return a.FocusedTime > b.FocusedTime
+		}
+		return appIds[i] < appIds[j]
+	})
+	if limit > 0 && len(appIds) > limit {
+		appIds = appIds[:limit]
+	}
+	return appIds
+}
+
+func (s *usageStats) getRetentionDays() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.RetentionDays
+}
+
+func (s *usageStats) setRetentionDays(days int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.RetentionDays = days
+	s.pruneLocked()
+	s.requestSave()
+}
+
+func (s *usageStats) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Days = make(map[string]map[string]*AppUsage)
+	s.focusStart = s.now()
+	s.requestSave()
+}
+
+func (s *usageStats) destroy() {
+	s.mu.Lock()
+	s.flushFocusLocked(s.now())
+	s.focusAppId = ""
+	s.saveTimer.Stop()
+	s.saving = false
+	s.mu.Unlock()
+
+	err := s.save()
+	if err != nil {
+		logger.Warning("failed to save usage stats:", err)
+	}
+}
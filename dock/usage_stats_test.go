@@ -0,0 +1,134 @@
+package dock
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestUsageStats(t *testing.T, now *time.Time) (*usageStats, func()) {
+	dir, err := ioutil.TempDir("", "dock-usage-stats")
+	assert.Nil(t, err)
+	s := newUsageStats(filepath.Join(dir, "usage-stats.json"))
+	s.now = func() time.Time {
+		return *now
+	}
+	return s, func() {
+		s.saveTimer.Stop()
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func Test_usageStatsFocusedTime(t *testing.T) {
+	now := time.Date(2020, 3, 1, 9, 50, 0, 0, time.Local)
+	s, cleanup := newTestUsageStats(t, &now)
+	defer cleanup()
+
+	s.setFocus("deepin-terminal")
+	now = now.Add(20 * time.Minute)
+	s.setFocus("google-chrome")
+	now = now.Add(5 * time.Minute)
+	s.setFocus("")
+
+	usages := s.getAppUsages(now, now)
+	terminal := usages["deepin-terminal"]
+	assert.NotNil(t, terminal)
+	assert.Equal(t, int64(20*60), terminal.FocusedTime)
+	assert.Equal(t, int64(10*60), terminal.HourFocused[9])
+	assert.Equal(t, int64(10*60), terminal.HourFocused[10])
+	assert.Equal(t, int64(5*60), usages["google-chrome"].FocusedTime)
+}
+
+func Test_usageStatsPaused(t *testing.T) {
+	now := time.Date(2020, 3, 1, 9, 0, 0, 0, time.Local)
+	s, cleanup := newTestUsageStats(t, &now)
+	defer cleanup()
+
+	s.setFocus("deepin-terminal")
+	now = now.Add(10 * time.Minute)
+	s.setPaused(usageStatsPauseIdle, true)
+	now = now.Add(10 * time.Minute)
+	s.setPaused(usageStatsPauseLocked, true)
+	// 还在锁屏，焦点切换不统计
+	s.setPaused(usageStatsPauseIdle, false)
+	s.setFocus("dde-lock")
+	now = now.Add(10 * time.Minute)
+	s.setPaused(usageStatsPauseLocked, false)
+	s.setFocus("deepin-terminal")
+	now = now.Add(5 * time.Minute)
+	s.setFocus("")
+
+	usages := s.getAppUsages(now, now)
+	assert.Equal(t, int64(15*60), usages["deepin-terminal"].FocusedTime)
+	assert.Nil(t, usages["dde-lock"])
+}
+
+func Test_usageStatsFocusedTimeAcrossDays(t *testing.T) {
+	now := time.Date(2020, 3, 1, 23, 30, 0, 0, time.Local)
+	s, cleanup := newTestUsageStats(t, &now)
+	defer cleanup()
+
+	s.setFocus("deepin-terminal")
+	now = now.Add(time.Hour)
+	s.setFocus("")
+
+	daily := s.getDailyUsages(now.AddDate(0, 0, -1), now)
+	assert.Equal(t, int64(30*60), daily["2020-03-01"]["deepin-terminal"].FocusedTime)
+	assert.Equal(t, int64(30*60), daily["2020-03-02"]["deepin-terminal"].FocusedTime)
+	assert.Equal(t, int64(30*60), daily["2020-03-02"]["deepin-terminal"].HourFocused[0])
+}
+
+func Test_usageStatsFrequentlyUsed(t *testing.T) {
+	now := time.Date(2020, 3, 1, 12, 0, 0, 0, time.Local)
+	s, cleanup := newTestUsageStats(t, &now)
+	defer cleanup()
+
+	s.markLaunched("a")
+	s.markLaunched("b")
+	s.markLaunched("b")
+	s.markLaunched("c")
+	s.setFocus("c")
+	now = now.Add(time.Minute)
+	s.setFocus("")
+
+	assert.Equal(t, []string{"b", "c", "a"}, s.getFrequentlyUsedApps(7, 0))
+	assert.Equal(t, []string{"b"}, s.getFrequentlyUsedApps(7, 1))
+
+	usages := s.getAppUsages(now, now)
+	assert.Equal(t, uint32(2), usages["b"].HourLaunched[12])
+}
+
+func Test_usageStatsRetention(t *testing.T) {
+	now := time.Date(2020, 3, 1, 12, 0, 0, 0, time.Local)
+	s, cleanup := newTestUsageStats(t, &now)
+	defer cleanup()
+
+	s.markLaunched("old")
+	now = now.AddDate(0, 0, 10)
+	s.markLaunched("new")
+	s.setRetentionDays(5)
+
+	usages := s.getAppUsages(now.AddDate(0, 0, -30), now)
+	assert.Nil(t, usages["old"])
+	assert.NotNil(t, usages["new"])
+}
+
+func Test_usageStatsSaveLoad(t *testing.T) {
+	now := time.Date(2020, 3, 1, 12, 0, 0, 0, time.Local)
+	s, cleanup := newTestUsageStats(t, &now)
+	defer cleanup()
+
+	s.markLaunched("deepin-terminal")
+	s.setRetentionDays(30)
+	assert.Nil(t, s.save())
+
+	s1 := newUsageStats(s.filename)
+	s1.saveTimer.Stop()
+	s1.now = s.now
+	assert.Equal(t, 30, s1.getRetentionDays())
+	assert.Equal(t, uint32(1), s1.getAppUsages(now, now)["deepin-terminal"].LaunchCount)
+}
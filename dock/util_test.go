@@ -51,3 +51,9 @@ func Test_strSliceContains(t *testing.T) {
 	assert.False(t, strSliceContains(slice, "e"))
 
 }
+
+func Test_getEnvExec(t *testing.T) {
+	env := []string{"QT_SCALE_FACTOR=1.5", "FONTCONFIG_FILE=/home/a b/$x.conf"}
+	assert.Equal(t, `env QT_SCALE_FACTOR=1.5 "FONTCONFIG_FILE=/home/a b/\$x.conf" deepin-editor %F`,
+		getEnvExec(env, "deepin-editor %F"))
+}
@@ -0,0 +1,198 @@
+package image_effect
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	cacheIndexFile = "index.json"
+
+	defaultCacheMaxSize    = 512 * 1024 * 1024
+	defaultCacheMaxEntries = 256
+	// 命中缓存只更新使用时间，延迟一段时间后再保存索引，避免每次读取壁纸都写文件
+	defaultCacheSaveDelay = time.Minute
+)
+
+type cacheEntry struct {
+	Source   string
+	Effect   string
+	Size     int64
+	LastUsed int64
+}
+
+// effectCache 记录生成的文件，超出容量时按最近最少使用的顺序删除
+type effectCache struct {
+	dir        string
+	maxSize    int64
+	maxEntries int
+	saveDelay  time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]*cacheEntry // key 为输出文件
+	saveTimer *time.Timer            // 不为 nil 表示有未保存的修改
+}
+
+func newEffectCache(dir string) *effectCache {
+	c := &effectCache{
+		dir:        dir,
+		maxSize:    defaultCacheMaxSize,
+		maxEntries: defaultCacheMaxEntries,
+		saveDelay:  defaultCacheSaveDelay,
+		entries:    make(map[string]*cacheEntry),
+	}
+	c.load()
+	return c
+}
+
+func (c *effectCache) indexFile() string {
+	return filepath.Join(c.dir, cacheIndexFile)
+}
+
+func (c *effectCache) load() {
+	data, err := ioutil.ReadFile(c.indexFile())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warning(err)
+		}
+		return
+	}
+	var entries map[string]*cacheEntry
+	err = json.Unmarshal(data, &entries)
+	if err != nil {
+		logger.Warning("failed to load cache index:", err)
+		return
+	}
+	for file, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		c.entries[file] = entry
+	}
+}
+
+func (c *effectCache) saveNoLock() {
+	if c.saveTimer != nil {
+		c.saveTimer.Stop()
+		c.saveTimer = nil
+	}
+
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+	err = os.MkdirAll(c.dir, 0755)
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+	tmpFile := c.indexFile() + ".tmp"
+	err = ioutil.WriteFile(tmpFile, data, 0644)
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+	err = os.Rename(tmpFile, c.indexFile())
+	if err != nil {
+		logger.Warning(err)
+	}
+}
+
+func (c *effectCache) delaySaveNoLock() {
+	if c.saveTimer != nil {
+		return
+	}
+	c.saveTimer = time.AfterFunc(c.saveDelay, func() {
+		c.mu.Lock()
+		c.saveNoLock()
+		c.mu.Unlock()
+	})
+}
+
+// touch 记录 outputFile 被使用，然后检查容量并删除最久未使用的文件。
+// 只有新生成文件或者删除了文件时才立即保存索引。
+func (c *effectCache) touch(outputFile, source, effect string, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now().UnixNano()
+	entry, ok := c.entries[outputFile]
+	if ok && entry.Source == source && entry.Effect == effect && entry.Size == size {
+		entry.LastUsed = now
+		c.delaySaveNoLock()
+		return
+	}
+
+	c.entries[outputFile] = &cacheEntry{
+		Source:   source,
+		Effect:   effect,
+		Size:     size,
+		LastUsed: now,
+	}
+	c.evictNoLock(outputFile)
+	c.saveNoLock()
+}
+
+func (c *effectCache) evictNoLock(keep string) {
+	var totalSize int64
+	files := make([]string, 0, len(c.entries))
+	for file, entry := range c.entries {
+		totalSize += entry.Size
+		files = append(files, file)
+	}
+	if totalSize <= c.maxSize && len(files) <= c.maxEntries {
+		return
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return c.entries[files[i]].LastUsed < c.entries[files[j]].LastUsed
+	})
+
+	count := len(files)
+	for _, file := range files {
+		if totalSize <= c.maxSize && count <= c.maxEntries {
+			break
+		}
+		if file == keep {
+			continue
+		}
+		entry := c.entries[file]
+		logger.Debugf("evict cache file %q, effect: %q, source: %q", file, entry.Effect, entry.Source)
+		err := os.Remove(file)
+		if err != nil && !os.IsNotExist(err) {
+			logger.Warning(err)
+		}
+		delete(c.entries, file)
+		totalSize -= entry.Size
+		count--
+	}
+}
+
+func (c *effectCache) remove(outputFile string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[outputFile]; !ok {
+		return
+	}
+	delete(c.entries, outputFile)
+	c.saveNoLock()
+}
+
+// filesOfSource 返回 source 生成的所有文件
+func (c *effectCache) filesOfSource(source string) map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result := make(map[string]string)
+	for file, entry := range c.entries {
+		if entry.Source == source {
+			result[file] = entry.Effect
+		}
+	}
+	return result
+}
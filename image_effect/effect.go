@@ -0,0 +1,186 @@
+package image_effect
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 原生效果使用 effect spec 描述，多个步骤用 "|" 连接，按顺序处理，步骤的参数跟在 ":" 后，
+// 参数之间用 "," 分隔，例如：
+//   blur:radius=30
+//   resize:width=1920,height=1080|blur:radius=20|dim:amount=0.3
+// 未指定的参数使用默认值。
+
+const (
+	effectBlur      = "blur"
+	effectDim       = "dim"
+	effectGrayscale = "grayscale"
+	effectResize    = "resize"
+
+	effectStepSep  = "|"
+	effectParamSep = ","
+)
+
+type effectParam struct {
+	defaultValue float64
+	min          float64
+	max          float64
+}
+
+type nativeEffect struct {
+	params map[string]effectParam
+	apply  func(img image.Image, params map[string]float64) image.Image
+}
+
+var nativeEffects = map[string]*nativeEffect{
+	effectBlur: {
+		params: map[string]effectParam{
+			"radius": {defaultValue: 20, min: 1, max: 200},
+		},
+		apply: func(img image.Image, params map[string]float64) image.Image {
+			return blurImage(img, int(params["radius"]))
+		},
+	},
+	effectDim: {
+		params: map[string]effectParam{
+			"amount": {defaultValue: 0.4, min: 0, max: 1},
+		},
+		apply: func(img image.Image, params map[string]float64) image.Image {
+			return dimImage(img, params["amount"])
+		},
+	},
+	effectGrayscale: {
+		apply: func(img image.Image, params map[string]float64) image.Image {
+			return grayscaleImage(img)
+		},
+	},
+	effectResize: {
+		// 通常为显示器的分辨率，图片按比例缩放至铺满并居中裁剪
+		params: map[string]effectParam{
+			"width":  {defaultValue: 1920, min: 1, max: 16384},
+			"height": {defaultValue: 1080, min: 1, max: 16384},
+		},
+		apply: func(img image.Image, params map[string]float64) image.Image {
+			return resizeImageFill(img, int(params["width"]), int(params["height"]))
+		},
+	},
+}
+
+type effectStep struct {
+	name   string
+	params map[string]float64
+}
+
+type effectPipeline []effectStep
+
+func isNativeEffect(effect string) bool {
+	name := effect
+	if idx := strings.IndexAny(effect, ":"+effectStepSep); idx != -1 {
+		name = effect[:idx]
+	}
+	_, ok := nativeEffects[name]
+	return ok
+}
+
+func parseEffectPipeline(spec string) (effectPipeline, error) {
+	var pipeline effectPipeline
+	for _, stepStr := range strings.Split(spec, effectStepSep) {
+		step, err := parseEffectStep(strings.TrimSpace(stepStr))
+		if err != nil {
+			return nil, err
+		}
+		pipeline = append(pipeline, step)
+	}
+	return pipeline, nil
+}
+
+func parseEffectStep(str string) (effectStep, error) {
+	name := str
+	var paramsStr string
+	if idx := strings.Index(str, ":"); idx != -1 {
+		name = str[:idx]
+		paramsStr = str[idx+1:]
+	}
+
+	effect, ok := nativeEffects[name]
+	if !ok {
+		return effectStep{}, fmt.Errorf("invalid effect %q", name)
+	}
+
+	step := effectStep{
+		name:   name,
+		params: make(map[string]float64, len(effect.params)),
+	}
+	for key, param := range effect.params {
+		step.params[key] = param.defaultValue
+	}
+
+	if paramsStr == "" {
+		return step, nil
+	}
+	for _, kv := range strings.Split(paramsStr, effectParamSep) {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			return effectStep{}, fmt.Errorf("effect %q: invalid param %q", name, kv)
+		}
+		key := strings.TrimSpace(parts[0])
+		param, ok := effect.params[key]
+		if !ok {
+			return effectStep{}, fmt.Errorf("effect %q: unknown param %q", name, key)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil {
+			return effectStep{}, fmt.Errorf("effect %q: param %q: %v", name, key, err)
+		}
+		if value < param.min || value > param.max {
+			return effectStep{}, fmt.Errorf("effect %q: param %q out of range [%v, %v]",
+				name, key, param.min, param.max)
+		}
+		step.params[key] = value
+	}
+	return step, nil
+}
+
+// String 返回规范化的 spec，参数按名称排序并包含默认值，用作缓存的键
+func (step effectStep) String() string {
+	if len(step.params) == 0 {
+		return step.name
+	}
+	keys := make([]string, 0, len(step.params))
+	for key := range step.params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	kvs := make([]string, len(keys))
+	for i, key := range keys {
+		kvs[i] = key + "=" + strconv.FormatFloat(step.params[key], 'g', -1, 64)
+	}
+	return step.name + ":" + strings.Join(kvs, effectParamSep)
+}
+
+func (p effectPipeline) String() string {
+	steps := make([]string, len(p))
+	for i, step := range p {
+		steps[i] = step.String()
+	}
+	return strings.Join(steps, effectStepSep)
+}
+
+// name 返回由各步骤名称组成的名称，用作缓存子目录
+func (p effectPipeline) name() string {
+	names := make([]string, len(p))
+	for i, step := range p {
+		names[i] = step.name
+	}
+	return strings.Join(names, "-")
+}
+
+func (p effectPipeline) apply(img image.Image) image.Image {
+	for _, step := range p {
+		img = nativeEffects[step.name].apply(img, step.params)
+	}
+	return img
+}
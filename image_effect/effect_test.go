@@ -0,0 +1,184 @@
+package image_effect
+
+import (
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEffectPipeline(t *testing.T) {
+	pipeline, err := parseEffectPipeline("blur")
+	assert.Nil(t, err)
+	assert.Equal(t, "blur:radius=20", pipeline.String())
+
+	pipeline, err = parseEffectPipeline("resize:height=1080,width=1920 | blur:radius=30|dim:amount=0.5|grayscale")
+	assert.Nil(t, err)
+	assert.Equal(t, "resize:height=1080,width=1920|blur:radius=30|dim:amount=0.5|grayscale",
+		pipeline.String())
+	assert.Equal(t, "resize-blur-dim-grayscale", pipeline.name())
+
+	// 参数顺序不影响规范化结果
+	p1, _ := parseEffectPipeline("resize:width=800,height=600")
+	p2, _ := parseEffectPipeline("resize:height=600,width=800")
+	assert.Equal(t, p1.String(), p2.String())
+
+	for _, spec := range []string{
+		"",
+		"pixmix",
+		"blur:radius",
+		"blur:radius=abc",
+		"blur:radius=0",
+		"blur:size=10",
+		"dim:amount=2",
+		"blur|unknown",
+	} {
+		_, err = parseEffectPipeline(spec)
+		assert.NotNil(t, err, spec)
+	}
+}
+
+func TestIsNativeEffect(t *testing.T) {
+	assert.True(t, isNativeEffect("blur"))
+	assert.True(t, isNativeEffect("blur:radius=3"))
+	assert.True(t, isNativeEffect("dim|blur"))
+	assert.False(t, isNativeEffect(""))
+	assert.False(t, isNativeEffect("pixmix"))
+}
+
+func newUniformImage(w, h int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestBlurImage(t *testing.T) {
+	// 均匀的图片模糊后不变
+	c := color.NRGBA{R: 100, G: 150, B: 200, A: 255}
+	img := blurImage(newUniformImage(20, 10, c), 5).(*image.NRGBA)
+	assert.Equal(t, c, img.NRGBAAt(0, 0))
+	assert.Equal(t, c, img.NRGBAAt(19, 9))
+
+	// 单个亮点会扩散到周围
+	src := newUniformImage(21, 21, color.NRGBA{A: 255})
+	src.SetNRGBA(10, 10, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+	img = blurImage(src, 4).(*image.NRGBA)
+	center := img.NRGBAAt(10, 10).R
+	assert.True(t, center < 255)
+	assert.True(t, img.NRGBAAt(11, 10).R > 0)
+	assert.True(t, img.NRGBAAt(11, 10).R <= center)
+	assert.Equal(t, uint8(0), img.NRGBAAt(0, 0).R)
+	// 不修改原图
+	assert.Equal(t, uint8(255), src.NRGBAAt(10, 10).R)
+}
+
+func TestBoxesForGauss(t *testing.T) {
+	radii := boxesForGauss(10, 3)
+	assert.Len(t, radii, 3)
+	for _, r := range radii {
+		assert.True(t, r >= 9 && r <= 10, r)
+	}
+}
+
+func TestDimAndGrayscale(t *testing.T) {
+	src := newUniformImage(2, 2, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+
+	img := dimImage(src, 0.5).(*image.NRGBA)
+	assert.Equal(t, color.NRGBA{R: 100, G: 50, B: 25, A: 255}, img.NRGBAAt(1, 1))
+	assert.Equal(t, uint8(200), src.NRGBAAt(1, 1).R)
+
+	img = grayscaleImage(src).(*image.NRGBA)
+	px := img.NRGBAAt(0, 0)
+	assert.Equal(t, px.R, px.G)
+	assert.Equal(t, px.G, px.B)
+	assert.Equal(t, uint8(124), px.R)
+}
+
+func TestResizeImageFill(t *testing.T) {
+	img := resizeImageFill(newUniformImage(400, 100, color.NRGBA{A: 255}), 100, 100)
+	assert.Equal(t, image.Rect(0, 0, 100, 100), img.Bounds())
+
+	img = resizeImageFill(newUniformImage(100, 400, color.NRGBA{A: 255}), 160, 90)
+	assert.Equal(t, image.Rect(0, 0, 160, 90), img.Bounds())
+}
+
+func TestIsFileInDirs(t *testing.T) {
+	dirs := []string{"/usr/share/wallpapers/deepin", "/home/test/custom-wallpapers"}
+	assert.True(t, isFileInDirs("/usr/share/wallpapers/deepin/a.jpg", dirs))
+	assert.True(t, isFileInDirs("/home/test/custom-wallpapers/b.png", dirs))
+	assert.False(t, isFileInDirs("/usr/share/wallpapers/deepin/sub/a.jpg", dirs))
+	assert.False(t, isFileInDirs("/etc/shadow", dirs))
+	assert.False(t, isFileInDirs(filepath.Clean("/usr/share/wallpapers/deepin/../../../../etc/shadow"), dirs))
+}
+
+func TestCheckImageConfig(t *testing.T) {
+	assert.Nil(t, checkImageConfig(image.Config{Width: 1920, Height: 1080}))
+	assert.Nil(t, checkImageConfig(image.Config{Width: 8192, Height: 8192}))
+	assert.NotNil(t, checkImageConfig(image.Config{Width: 0, Height: 1080}))
+	assert.NotNil(t, checkImageConfig(image.Config{Width: 16385, Height: 1}))
+	assert.NotNil(t, checkImageConfig(image.Config{Width: 1, Height: 16385}))
+	assert.NotNil(t, checkImageConfig(image.Config{Width: 16384, Height: 16384}))
+}
+
+func TestEffectCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "image-effect-cache")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	newFile := func(name string) string {
+		file := filepath.Join(dir, name)
+		err := ioutil.WriteFile(file, []byte("data"), 0644)
+		assert.Nil(t, err)
+		return file
+	}
+	exist := func(file string) bool {
+		_, err := os.Stat(file)
+		return err == nil
+	}
+
+	c := newEffectCache(dir)
+	c.maxEntries = 2
+	f1 := newFile("1.jpg")
+	f2 := newFile("2.jpg")
+	f3 := newFile("3.jpg")
+
+	c.touch(f1, "/a.jpg", "blur:radius=20", 4)
+	c.touch(f2, "/a.jpg", "dim:amount=0.4", 4)
+	c.touch(f1, "/a.jpg", "blur:radius=20", 4)
+	c.touch(f3, "/b.jpg", "blur:radius=20", 4)
+	// f2 最久未使用
+	assert.True(t, exist(f1))
+	assert.False(t, exist(f2))
+	assert.True(t, exist(f3))
+
+	assert.Equal(t, map[string]string{f1: "blur:radius=20"}, c.filesOfSource("/a.jpg"))
+
+	// 索引保存到了文件中
+	c = newEffectCache(dir)
+	assert.Len(t, c.entries, 2)
+	c.remove(f1)
+	assert.Len(t, c.filesOfSource("/a.jpg"), 0)
+
+	// 命中缓存时延迟保存索引
+	indexFile := filepath.Join(dir, cacheIndexFile)
+	index, err := ioutil.ReadFile(indexFile)
+	assert.Nil(t, err)
+	c.touch(f3, "/b.jpg", "blur:radius=20", 4)
+	assert.NotNil(t, c.saveTimer)
+	index1, err := ioutil.ReadFile(indexFile)
+	assert.Nil(t, err)
+	assert.Equal(t, index, index1)
+
+	// 容量超出时不删除刚使用的文件
+	c.maxSize = 1
+	c.touch(f3, "/b.jpg", "blur:radius=20", 5)
+	assert.True(t, exist(f3))
+}
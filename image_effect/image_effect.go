@@ -22,6 +22,7 @@ package image_effect
 import (
 	"errors"
 	"fmt"
+	"image"
 	"os"
 	"path/filepath"
 	"sync"
@@ -30,6 +31,8 @@ import (
 	dbus "github.com/godbus/dbus"
 	"golang.org/x/xerrors"
 	"pkg.deepin.io/lib/dbusutil"
+	"pkg.deepin.io/lib/graphic"
+	"pkg.deepin.io/lib/imgutil"
 	"pkg.deepin.io/lib/procfs"
 )
 
@@ -41,6 +44,9 @@ const (
 	cacheDir      = "/var/cache/deepin/dde-daemon/image-effect"
 	effectPixmix  = "pixmix"
 	defaultEffect = effectPixmix
+
+	// PreGenerate 一次最多处理的文件数
+	preGenerateMaxFiles = 200
 )
 
 var allEffects = []string{effectPixmix}
@@ -60,11 +66,15 @@ type ImageEffect struct {
 	tools   map[string]effectTool
 	//nolint
 	methods *struct {
-		Get    func() `in:"effect,filename" out:"outputFile"`
-		Delete func() `in:"effect,filename"`
+		Get         func() `in:"effect,filename" out:"outputFile"`
+		Delete      func() `in:"effect,filename"`
+		PreGenerate func() `in:"effect,filenames"`
 	}
 	tasks   map[taskKey]*Task
 	tasksMu sync.Mutex
+	cache   *effectCache
+	// 保证 PreGenerate 的任务依次执行，避免同时处理大量图片
+	preGenerateMu sync.Mutex
 }
 
 func (ie *ImageEffect) addTask(effect, filename string) (ch chan error) {
@@ -129,11 +139,60 @@ func newImageEffect() *ImageEffect {
 	ie := &ImageEffect{
 		tools: make(map[string]effectTool),
 		tasks: make(map[taskKey]*Task),
+		cache: newEffectCache(cacheDir),
 	}
 	ie.tools[effectPixmix] = effectToolFunc(ddePixmix)
 	return ie
 }
 
+// nativeTool 在进程内处理图片，不依赖外部程序
+type nativeTool effectPipeline
+
+func (nt nativeTool) generate(uid int, inputFile, outputFile string, envVars []string) error {
+	err := checkImageSize(inputFile)
+	if err != nil {
+		return err
+	}
+	img, err := imgutil.Load(inputFile)
+	if err != nil {
+		return err
+	}
+	img = effectPipeline(nt).apply(img)
+	return graphic.SaveImage(outputFile, img, graphic.FormatJpeg)
+}
+
+// 解码前检查图片的尺寸，防止很小的文件解码出很大的图片耗尽内存
+const (
+	maxImageWidth  = 16384
+	maxImageHeight = 16384
+	maxImagePixels = 8192 * 8192
+)
+
+func checkImageSize(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return err
+	}
+	return checkImageConfig(cfg)
+}
+
+func checkImageConfig(cfg image.Config) error {
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return fmt.Errorf("invalid image size %dx%d", cfg.Width, cfg.Height)
+	}
+	if cfg.Width > maxImageWidth || cfg.Height > maxImageHeight ||
+		int64(cfg.Width)*int64(cfg.Height) > maxImagePixels {
+		return fmt.Errorf("image size %dx%d is too large", cfg.Width, cfg.Height)
+	}
+	return nil
+}
+
 func ddePixmix(uid int, inputFile, outputFile string, envVars []string) error {
 	return runCmdRedirectStdOut(uid, outputFile, []string{"dde-pixmix", "-o=-", inputFile}, envVars)
 }
@@ -156,11 +215,26 @@ func (ie *ImageEffect) Get(sender dbus.Sender, effect, filename string) (outputF
 		filename = filenameResolved
 	}
 
-	uid, err := ie.service.GetConnUID(string(sender))
+	uid, envVars, err := ie.getCallerInfo(sender)
+	if err != nil {
+		return
+	}
+
+	outputFile, err = ie.get(uid, effect, filename, envVars)
+	if err != nil {
+		err = xerrors.Errorf("failed to get output file: %w", err)
+		return
+	}
+	return
+}
+
+func (ie *ImageEffect) getCallerInfo(sender dbus.Sender) (uid int, envVars []string, err error) {
+	uid0, err := ie.service.GetConnUID(string(sender))
 	if err != nil {
 		err = xerrors.Errorf("failed to get conn uid: %w", err)
 		return
 	}
+	uid = int(uid0)
 	pid, err := ie.service.GetConnPID(string(sender))
 	if err != nil {
 		err = xerrors.Errorf("failed to get conn pid: %w", err)
@@ -174,18 +248,77 @@ func (ie *ImageEffect) Get(sender dbus.Sender, effect, filename string) (outputF
 		return
 	}
 	var envVarNames = []string{"DISPLAY", "XDG_RUNTIME_DIR"}
-	var envVars = make([]string, len(envVarNames))
+	envVars = make([]string, len(envVarNames))
 	for idx, envVarName := range envVarNames {
 		envVarVal := processEnv.Get(envVarName)
 		envVars[idx] = envVarName + "=" + envVarVal
 	}
+	return
+}
+
+// PreGenerate 在后台依次为 filenames 生成效果图，用于壁纸轮播等提前知道图片的场景
+func (ie *ImageEffect) PreGenerate(sender dbus.Sender, effect string, filenames []string) *dbus.Error {
+	logger.Debugf("PreGenerate sender: %s, effect: %q, %d files", sender, effect, len(filenames))
+	if effect != "" && !isNativeEffect(effect) && ie.tools[effect] == nil {
+		return dbusutil.ToError(fmt.Errorf("invalid effect %q", effect))
+	}
+	if isNativeEffect(effect) {
+		_, err := parseEffectPipeline(effect)
+		if err != nil {
+			return dbusutil.ToError(err)
+		}
+	}
+
+	if len(filenames) > preGenerateMaxFiles {
+		return dbusutil.ToError(fmt.Errorf("too many files, at most %d", preGenerateMaxFiles))
+	}
 
-	outputFile, err = ie.get(int(uid), effect, filename, envVars)
+	uid, envVars, err := ie.getCallerInfo(sender)
 	if err != nil {
-		err = xerrors.Errorf("failed to get output file: %w", err)
-		return
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+
+	filenames = filterPreGenerateFiles(uid, filenames)
+	go ie.preGenerate(uid, effect, filenames, envVars)
+	return nil
+}
+
+// filterPreGenerateFiles 只保留壁纸目录中调用者可以读取的文件，返回解析符号链接后的路径
+func filterPreGenerateFiles(uid int, filenames []string) []string {
+	dirs := getWallpaperDirs(uid)
+	result := make([]string, 0, len(filenames))
+	for _, filename := range filenames {
+		filenameResolved, err := filepath.EvalSymlinks(filename)
+		if err != nil {
+			logger.Warningf("failed to eval symlinks %q: %v", filename, err)
+			continue
+		}
+		// 系统壁纸目录中的文件可能是链接到其他目录的符号链接
+		if !isFileInDirs(filepath.Clean(filename), dirs) && !isFileInDirs(filenameResolved, dirs) {
+			logger.Warningf("ignore file %q not in wallpaper dirs", filename)
+			continue
+		}
+		err = checkFileReadable(uid, filenameResolved)
+		if err != nil {
+			logger.Warning(err)
+			continue
+		}
+		result = append(result, filenameResolved)
+	}
+	return result
+}
+
+func (ie *ImageEffect) preGenerate(uid int, effect string, filenames []string, envVars []string) {
+	ie.preGenerateMu.Lock()
+	defer ie.preGenerateMu.Unlock()
+
+	for _, filename := range filenames {
+		_, err := ie.get(uid, effect, filename, envVars)
+		if err != nil {
+			logger.Warningf("failed to pre-generate effect %q for %q: %v", effect, filename, err)
+		}
 	}
-	return
 }
 
 func (ie *ImageEffect) get(uid int, effect, filename string, envVars []string) (outputFile string, err error) {
@@ -193,10 +326,28 @@ func (ie *ImageEffect) get(uid int, effect, filename string, envVars []string) (
 		effect = defaultEffect
 	}
 
-	tool := ie.tools[effect]
-	if tool == nil {
-		err = fmt.Errorf("invalid effect %q", effect)
-		return
+	var tool effectTool
+	if isNativeEffect(effect) {
+		var pipeline effectPipeline
+		pipeline, err = parseEffectPipeline(effect)
+		if err != nil {
+			return
+		}
+		// 原生效果在 root 进程中读取图片，需要先检查调用者是否有权限读取
+		err = checkFileReadable(uid, filename)
+		if err != nil {
+			return
+		}
+		effect = pipeline.String()
+		tool = nativeTool(pipeline)
+		outputFile = getNativeOutputFile(pipeline, filename)
+	} else {
+		tool = ie.tools[effect]
+		if tool == nil {
+			err = fmt.Errorf("invalid effect %q", effect)
+			return
+		}
+		outputFile = getOutputFile(effect, filename)
 	}
 
 	inputFileInfo, err := os.Stat(filename)
@@ -205,7 +356,6 @@ func (ie *ImageEffect) get(uid int, effect, filename string, envVars []string) (
 		return
 	}
 
-	outputFile = getOutputFile(effect, filename)
 	outputDir := filepath.Dir(outputFile)
 	err = os.MkdirAll(outputDir, 0755)
 	if err != nil {
@@ -221,6 +371,7 @@ func (ie *ImageEffect) get(uid int, effect, filename string, envVars []string) (
 			// check mod time
 			if modTimeEqual(inputFileInfo.ModTime(), outputFileInfo.ModTime()) {
 				logger.Debug("mod time equal")
+				ie.cache.touch(outputFile, filename, effect, outputFileInfo.Size())
 				return
 			}
 		}
@@ -259,6 +410,8 @@ func (ie *ImageEffect) get(uid int, effect, filename string, envVars []string) (
 		if fileInfo.Size() == 0 {
 			shouldDelete = true
 			err = errors.New("generate success but output file is empty")
+		} else {
+			ie.cache.touch(outputFile, filename, effect, fileInfo.Size())
 		}
 	} else {
 		// generate failed
@@ -301,6 +454,13 @@ func (ie *ImageEffect) Delete(effect, filename string) (busErr *dbus.Error) {
 				logger.Warning(err)
 			}
 		}
+		// 原生效果的参数不固定，从缓存记录中找出所有生成过的文件
+		for _, effect := range ie.cache.filesOfSource(filename) {
+			err = ie.delete(effect, filename)
+			if err != nil {
+				logger.Warning(err)
+			}
+		}
 		err = nil
 		return
 	}
@@ -314,13 +474,26 @@ func (ie *ImageEffect) delete(effect, filename string) (err error) {
 		effect = defaultEffect
 	}
 
+	var outputFile string
+	if isNativeEffect(effect) {
+		var pipeline effectPipeline
+		pipeline, err = parseEffectPipeline(effect)
+		if err != nil {
+			return
+		}
+		effect = pipeline.String()
+		outputFile = getNativeOutputFile(pipeline, filename)
+	} else {
+		outputFile = getOutputFile(effect, filename)
+	}
+
 	has := ie.hasTask(effect, filename)
 	if has {
 		return errors.New("generation task is in progress")
 	}
 
-	outputFile := getOutputFile(effect, filename)
 	logger.Debugf("delete file %q, effect: %q, source: %q", outputFile, effect, filename)
+	ie.cache.remove(outputFile)
 	err = os.Remove(outputFile)
 	if err != nil {
 		if os.IsNotExist(err) {
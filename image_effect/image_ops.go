@@ -0,0 +1,191 @@
+package image_effect
+
+import (
+	"image"
+	"image/draw"
+	"math"
+
+	"github.com/nfnt/resize"
+)
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	if nrgba, ok := img.(*image.NRGBA); ok && nrgba.Rect.Min == (image.Point{}) {
+		return nrgba
+	}
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+// blurImage 使用三次盒式模糊近似高斯模糊，耗时与半径无关
+func blurImage(img image.Image, radius int) image.Image {
+	src := toNRGBA(img)
+	if radius < 1 {
+		return src
+	}
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewNRGBA(src.Rect)
+	tmp := image.NewNRGBA(src.Rect)
+	copy(dst.Pix, src.Pix)
+
+	for _, r := range boxesForGauss(float64(radius)/2, 3) {
+		boxBlurH(dst, tmp, w, h, r)
+		boxBlurV(tmp, dst, w, h, r)
+	}
+	return dst
+}
+
+// boxesForGauss 计算 n 次盒式模糊的半径，使其效果接近标准差为 sigma 的高斯模糊
+func boxesForGauss(sigma float64, n int) []int {
+	wIdeal := math.Sqrt(12*sigma*sigma/float64(n) + 1)
+	wl := int(wIdeal)
+	if wl%2 == 0 {
+		wl--
+	}
+	wu := wl + 2
+	mIdeal := (12*sigma*sigma - float64(n*wl*wl) - float64(4*n*wl) - float64(3*n)) /
+		float64(-4*wl-4)
+	m := int(mIdeal + 0.5)
+
+	radii := make([]int, n)
+	for i := range radii {
+		if i < m {
+			radii[i] = (wl - 1) / 2
+		} else {
+			radii[i] = (wu - 1) / 2
+		}
+	}
+	return radii
+}
+
+func clampIndex(i, max int) int {
+	if i < 0 {
+		return 0
+	}
+	if i > max {
+		return max
+	}
+	return i
+}
+
+func boxBlurH(src, dst *image.NRGBA, w, h, r int) {
+	if r < 1 {
+		copy(dst.Pix, src.Pix)
+		return
+	}
+	div := 2*r + 1
+	for y := 0; y < h; y++ {
+		row := y * src.Stride
+		var sum [4]int
+		for i := -r; i <= r; i++ {
+			off := row + clampIndex(i, w-1)*4
+			for c := 0; c < 4; c++ {
+				sum[c] += int(src.Pix[off+c])
+			}
+		}
+		for x := 0; x < w; x++ {
+			off := row + x*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[off+c] = uint8(sum[c] / div)
+			}
+			addOff := row + clampIndex(x+r+1, w-1)*4
+			subOff := row + clampIndex(x-r, w-1)*4
+			for c := 0; c < 4; c++ {
+				sum[c] += int(src.Pix[addOff+c]) - int(src.Pix[subOff+c])
+			}
+		}
+	}
+}
+
+func boxBlurV(src, dst *image.NRGBA, w, h, r int) {
+	if r < 1 {
+		copy(dst.Pix, src.Pix)
+		return
+	}
+	div := 2*r + 1
+	stride := src.Stride
+	for x := 0; x < w; x++ {
+		col := x * 4
+		var sum [4]int
+		for i := -r; i <= r; i++ {
+			off := clampIndex(i, h-1)*stride + col
+			for c := 0; c < 4; c++ {
+				sum[c] += int(src.Pix[off+c])
+			}
+		}
+		for y := 0; y < h; y++ {
+			off := y*stride + col
+			for c := 0; c < 4; c++ {
+				dst.Pix[off+c] = uint8(sum[c] / div)
+			}
+			addOff := clampIndex(y+r+1, h-1)*stride + col
+			subOff := clampIndex(y-r, h-1)*stride + col
+			for c := 0; c < 4; c++ {
+				sum[c] += int(src.Pix[addOff+c]) - int(src.Pix[subOff+c])
+			}
+		}
+	}
+}
+
+// dimImage 降低亮度，amount 为 0 时不变，为 1 时全黑
+func dimImage(img image.Image, amount float64) image.Image {
+	dst := toNRGBA(img)
+	if dst == img {
+		dst = cloneNRGBA(dst)
+	}
+	factor := 1 - amount
+	for i := 0; i < len(dst.Pix); i += 4 {
+		dst.Pix[i] = uint8(float64(dst.Pix[i]) * factor)
+		dst.Pix[i+1] = uint8(float64(dst.Pix[i+1]) * factor)
+		dst.Pix[i+2] = uint8(float64(dst.Pix[i+2]) * factor)
+	}
+	return dst
+}
+
+func grayscaleImage(img image.Image) image.Image {
+	dst := toNRGBA(img)
+	if dst == img {
+		dst = cloneNRGBA(dst)
+	}
+	for i := 0; i < len(dst.Pix); i += 4 {
+		// ITU-R BT.601
+		y := (299*int(dst.Pix[i]) + 587*int(dst.Pix[i+1]) + 114*int(dst.Pix[i+2]) + 500) / 1000
+		dst.Pix[i] = uint8(y)
+		dst.Pix[i+1] = uint8(y)
+		dst.Pix[i+2] = uint8(y)
+	}
+	return dst
+}
+
+func cloneNRGBA(img *image.NRGBA) *image.NRGBA {
+	dst := image.NewNRGBA(img.Rect)
+	copy(dst.Pix, img.Pix)
+	return dst
+}
+
+// resizeImageFill 将图片按比例缩放到刚好覆盖 width x height，再居中裁剪
+func resizeImageFill(img image.Image, width, height int) image.Image {
+	b := img.Bounds()
+	if b.Dx() == width && b.Dy() == height {
+		return img
+	}
+
+	var scaledW, scaledH int
+	if b.Dx()*height > b.Dy()*width {
+		// 图片更宽，按高度缩放
+		scaledH = height
+		scaledW = (b.Dx()*height + b.Dy() - 1) / b.Dy()
+	} else {
+		scaledW = width
+		scaledH = (b.Dy()*width + b.Dx() - 1) / b.Dx()
+	}
+	scaled := resize.Resize(uint(scaledW), uint(scaledH), img, resize.Bilinear)
+
+	sb := scaled.Bounds()
+	x0 := sb.Min.X + (sb.Dx()-width)/2
+	y0 := sb.Min.Y + (sb.Dy()-height)/2
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), scaled, image.Pt(x0, y0), draw.Src)
+	return dst
+}
@@ -3,12 +3,15 @@ package image_effect
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"os/user"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"pkg.deepin.io/lib/utils"
@@ -21,6 +24,103 @@ func getOutputFile(effect, filename string) (outputFile string) {
 	return
 }
 
+// getNativeOutputFile 返回原生效果的输出文件，文件名包含规范化后的参数，参数不同的结果分别缓存
+func getNativeOutputFile(pipeline effectPipeline, filename string) (outputFile string) {
+	outputDir := filepath.Join(cacheDir, pipeline.name())
+	md5sum, _ := utils.SumStrMd5(pipeline.String() + "\x00" + filename)
+	outputFile = filepath.Join(outputDir, md5sum+".jpg")
+	return
+}
+
+var systemWallpaperDirs = []string{
+	"/usr/share/wallpapers/deepin",
+}
+
+// getWallpaperDirs 返回系统壁纸目录和用户 uid 的自定义壁纸目录
+func getWallpaperDirs(uid int) []string {
+	dirs := append([]string{}, systemWallpaperDirs...)
+	u, err := user.LookupId(strconv.Itoa(uid))
+	if err != nil {
+		logger.Warning(err)
+		return dirs
+	}
+	return append(dirs, filepath.Join(u.HomeDir,
+		".config/deepin/dde-daemon/appearance/custom-wallpapers"))
+}
+
+func isFileInDirs(file string, dirs []string) bool {
+	for _, dir := range dirs {
+		if filepath.Dir(file) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFileReadable 检查用户 uid 是否可以读取 filename，包括路径上各级目录的执行权限
+func checkFileReadable(uid int, filename string) error {
+	if uid == 0 {
+		return nil
+	}
+
+	var gids []uint32
+	u, err := user.LookupId(strconv.Itoa(uid))
+	if err == nil {
+		groupIds, err := u.GroupIds()
+		if err != nil {
+			logger.Warning(err)
+		}
+		for _, groupId := range groupIds {
+			gid, err := strconv.ParseUint(groupId, 10, 32)
+			if err == nil {
+				gids = append(gids, uint32(gid))
+			}
+		}
+	} else {
+		logger.Warning(err)
+	}
+
+	check := func(path string, perm uint32) error {
+		var stat syscall.Stat_t
+		err := syscall.Stat(path, &stat)
+		if err != nil {
+			return err
+		}
+		var mode uint32
+		if stat.Uid == uint32(uid) {
+			mode = stat.Mode >> 6
+		} else if uint32InSlice(stat.Gid, gids) {
+			mode = stat.Mode >> 3
+		} else {
+			mode = stat.Mode
+		}
+		if mode&perm != perm {
+			return fmt.Errorf("permission denied: %q", path)
+		}
+		return nil
+	}
+
+	for dir := filepath.Dir(filename); ; dir = filepath.Dir(dir) {
+		err = check(dir, 01)
+		if err != nil {
+			return err
+		}
+		if dir == "/" || dir == "." {
+			break
+		}
+	}
+	return check(filename, 04)
+}
+
+func uint32InSlice(v uint32, slice []uint32) bool {
+	for _, item := range slice {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
 func modTimeEqual(t1, t2 time.Time) bool {
 	return t1.Unix() == t2.Unix() &&
 		(t1.Nanosecond()/1000) == (t2.Nanosecond()/1000)
@@ -0,0 +1,145 @@
+package keybinding
+
+import (
+	"time"
+
+	"github.com/godbus/dbus"
+	x "github.com/linuxdeepin/go-x11-client"
+	"github.com/linuxdeepin/go-x11-client/util/wm/ewmh"
+	"github.com/linuxdeepin/go-x11-client/util/wm/icccm"
+	"pkg.deepin.io/dde/daemon/keybinding/shortcuts"
+)
+
+const (
+	dockServiceName = "com.deepin.dde.daemon.Dock"
+	dockPath        = "/com/deepin/dde/daemon/Dock"
+	dockInterface   = "com.deepin.dde.daemon.Dock"
+
+	windowAppCacheMax = 100
+
+	getWindowAppIdTimeout = 300 * time.Millisecond
+)
+
+// 跟踪焦点窗口所属的应用，用于限定了应用的自定义快捷键。
+
+func (m *Manager) listenActiveWindowChanged() {
+	rootWin := m.conn.GetDefaultScreen().Root
+	// 设置事件掩码会替换这个连接在根窗口上已经选择的事件，需要保留原来的掩码
+	eventMask := uint32(x.EventMaskPropertyChange)
+	attrs, err := x.GetWindowAttributes(m.conn, rootWin).Reply(m.conn)
+	if err == nil {
+		eventMask |= uint32(attrs.YourEventMask)
+	} else {
+		logger.Warning(err)
+	}
+	err = x.ChangeWindowAttributesChecked(m.conn, rootWin, x.CWEventMask,
+		[]uint32{eventMask}).Check(m.conn)
+	if err != nil {
+		logger.Warning(err)
+	}
+
+	atomActiveWin, err := m.conn.GetAtom("_NET_ACTIVE_WINDOW")
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+
+	eventChan := make(chan x.GenericEvent, 10)
+	m.conn.AddEventChan(eventChan)
+	go func() {
+		for ev := range eventChan {
+			if ev.GetEventCode() != x.PropertyNotifyEventCode {
+				continue
+			}
+			event, _ := x.NewPropertyNotifyEvent(ev)
+			if event.Window == rootWin && event.Atom == atomActiveWin {
+				m.handleActiveWindowChanged()
+			}
+		}
+	}()
+	m.handleActiveWindowChanged()
+}
+
+func (m *Manager) handleActiveWindowChanged() {
+	activeWindow, err := ewmh.GetActiveWindow(m.conn).Reply(m.conn)
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+
+	m.activeAppMu.Lock()
+	if activeWindow == m.activeWindow {
+		m.activeAppMu.Unlock()
+		return
+	}
+	m.activeWindow = activeWindow
+	m.activeAppMu.Unlock()
+
+	if !m.shortcutManager.HasAppScopedShortcuts() {
+		return
+	}
+	m.shortcutManager.SetActiveApp(m.getWindowApp(activeWindow))
+}
+
+// updateActiveApp 在限定了应用的快捷键改变后调用，确保 ShortcutManager 知道焦点应用
+func (m *Manager) updateActiveApp() {
+	m.activeAppMu.Lock()
+	activeWindow := m.activeWindow
+	m.activeAppMu.Unlock()
+	m.shortcutManager.SetActiveApp(m.getWindowApp(activeWindow))
+}
+
+func (m *Manager) getWindowApp(win x.Window) *shortcuts.ActiveApp {
+	if win == 0 {
+		return nil
+	}
+
+	m.activeAppMu.Lock()
+	app, ok := m.windowAppCache[win]
+	m.activeAppMu.Unlock()
+	if ok {
+		return app
+	}
+
+	app = &shortcuts.ActiveApp{}
+	wmClass, err := icccm.GetWMClass(m.conn, win).Reply(m.conn)
+	if err == nil {
+		app.WMInstance = wmClass.Instance
+		app.WMClass = wmClass.Class
+	} else {
+		logger.Debug(err)
+	}
+	app.DesktopId = m.getWindowDesktopId(win)
+
+	m.activeAppMu.Lock()
+	if len(m.windowAppCache) >= windowAppCacheMax {
+		m.windowAppCache = make(map[x.Window]*shortcuts.ActiveApp)
+	}
+	// 任务栏可能还没有识别出窗口，此时不缓存
+	if app.DesktopId != "" {
+		m.windowAppCache[win] = app
+	}
+	m.activeAppMu.Unlock()
+	return app
+}
+
+// getWindowDesktopId 从任务栏获取窗口所属应用的 desktop id，与任务栏的识别结果保持一致。
+// 在处理 X 事件时调用，任务栏没有及时回复时放弃，避免阻塞焦点切换。
+func (m *Manager) getWindowDesktopId(win x.Window) string {
+	obj := m.service.Conn().Object(dockServiceName, dockPath)
+	call := obj.Go(dockInterface+".GetWindowAppId", dbus.FlagNoAutoStart, nil, uint32(win))
+	select {
+	case <-call.Done:
+	case <-time.After(getWindowAppIdTimeout):
+		logger.Debug("get window app id from dock timeout:", win)
+		return ""
+	}
+
+	var appId string
+	err := call.Store(&appId)
+	if err != nil {
+		logger.Debug("failed to get window app id from dock:", err)
+		return ""
+	}
+	return appId
+}
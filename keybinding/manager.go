@@ -23,6 +23,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	dbus "github.com/godbus/dbus"
@@ -125,6 +126,15 @@ type Manager struct {
 	switchKbdLayoutState SKLState
 	sklWaitQuit          chan int
 
+	// 当前的快捷键方案
+	profileMu      sync.Mutex
+	currentProfile string
+
+	// 焦点窗口及其所属的应用
+	activeAppMu    sync.Mutex
+	activeWindow   x.Window
+	windowAppCache map[x.Window]*shortcuts.ActiveApp
+
 	//nolint
 	signals *struct {
 		Added, Deleted, Changed struct {
@@ -140,20 +150,34 @@ type Manager struct {
 
 	//nolint
 	methods *struct {
-		AddCustomShortcut         func() `in:"name,action,keystroke" out:"id,type"`
-		AddShortcutKeystroke      func() `in:"id,type,keystroke"`
-		ClearShortcutKeystrokes   func() `in:"id,type"`
-		DeleteCustomShortcut      func() `in:"id"`
-		DeleteShortcutKeystroke   func() `in:"id,type,keystroke"`
-		GetShortcut               func() `in:"id,type" out:"shortcut"`
-		ListAllShortcuts          func() `out:"shortcuts"`
-		ListShortcutsByType       func() `in:"type" out:"shortcuts"`
-		SearchShortcuts           func() `in:"query" out:"shortcuts"`
-		LookupConflictingShortcut func() `in:"keystroke" out:"shortcut"`
-		ModifyCustomShortcut      func() `in:"id,name,cmd,keystroke"`
-		SetNumLockState           func() `in:"state"`
-		GetCapsLockState          func() `out:"state"`
-		SetCapsLockState          func() `in:"state"`
+		AddCustomShortcut               func() `in:"name,action,keystroke" out:"id,type"`
+		AddCustomShortcutWithAction     func() `in:"name,actionType,actionArgs,keystroke" out:"id,type"`
+		ModifyCustomShortcutAction      func() `in:"id,actionType,actionArgs"`
+		ValidateCustomShortcutAction    func() `in:"actionType,actionArgs"`
+		ListCustomShortcutActionTypes   func() `out:"actionTypes"`
+		AddCustomShortcutKeySequence    func() `in:"id,sequence"`
+		DeleteCustomShortcutKeySequence func() `in:"id,sequence"`
+		ListProfiles                    func() `out:"profiles"`
+		GetCurrentProfile               func() `out:"id"`
+		ApplyProfile                    func() `in:"id" out:"conflicts"`
+		SaveProfile                     func() `in:"id,name"`
+		DeleteProfile                   func() `in:"id"`
+		ExportShortcuts                 func() `out:"data"`
+		ImportShortcuts                 func() `in:"data" out:"conflicts"`
+		SetCustomShortcutAppScope       func() `in:"id,scope"`
+		AddShortcutKeystroke            func() `in:"id,type,keystroke"`
+		ClearShortcutKeystrokes         func() `in:"id,type"`
+		DeleteCustomShortcut            func() `in:"id"`
+		DeleteShortcutKeystroke         func() `in:"id,type,keystroke"`
+		GetShortcut                     func() `in:"id,type" out:"shortcut"`
+		ListAllShortcuts                func() `out:"shortcuts"`
+		ListShortcutsByType             func() `in:"type" out:"shortcuts"`
+		SearchShortcuts                 func() `in:"query" out:"shortcuts"`
+		LookupConflictingShortcut       func() `in:"keystroke" out:"shortcut"`
+		ModifyCustomShortcut            func() `in:"id,name,cmd,keystroke"`
+		SetNumLockState                 func() `in:"state"`
+		GetCapsLockState                func() `out:"state"`
+		SetCapsLockState                func() `in:"state"`
 
 		// deprecated
 		Add            func() `in:"name,action,keystroke" out:"ret0,ret1"`
@@ -192,6 +216,7 @@ func newManager(service *dbusutil.Service) (*Manager, error) {
 		enableListenGSettings: true,
 		conn:                  conn,
 		keySymbols:            keysyms.NewKeySymbols(conn),
+		windowAppCache:        make(map[x.Window]*shortcuts.ActiveApp),
 	}
 
 	m.sessionSigLoop = dbusutil.NewSignalLoop(sessionBus, 10)
@@ -263,6 +288,7 @@ func newManager(service *dbusutil.Service) (*Manager, error) {
 	customConfigFilePath := filepath.Join(basedir.GetUserConfigDir(), customConfigFile)
 	m.customShortcutManager = shortcuts.NewCustomShortcutManager(customConfigFilePath)
 	m.shortcutManager.AddCustom(m.customShortcutManager)
+	m.initProfile()
 
 	m.backlightHelper = backlight.NewBacklight(sysBus)
 	m.audioController = NewAudioController(sessionBus, m.backlightHelper)
@@ -296,6 +322,7 @@ func newManager(service *dbusutil.Service) (*Manager, error) {
 	if err != nil {
 		logger.Warning(err)
 	}
+	m.listenActiveWindowChanged()
 
 	return &m, nil
 }
@@ -493,6 +520,10 @@ func (m *Manager) runDesktopFile(desktop string) error {
 	return m.startManager.LaunchApp(0, desktop, 0, []string{})
 }
 
+func (m *Manager) runDesktopFileAction(desktop, action string) error {
+	return m.startManager.LaunchAppAction(0, desktop, action, 0)
+}
+
 func (m *Manager) eliminateKeystrokeConflict() {
 	for _, ks := range m.shortcutManager.ConflictingKeystrokes {
 		shortcut := ks.Shortcut
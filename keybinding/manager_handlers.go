@@ -131,9 +131,25 @@ func (m *Manager) initHandlers() {
 
 	m.handlers[ActionTypeDesktopFile] = func(ev *KeyEvent) {
 		action := ev.Shortcut.GetAction()
+		var desktopFile, desktopAction string
+		switch arg := action.Arg.(type) {
+		case string:
+			desktopFile = arg
+		case *ActionDesktopFileArg:
+			desktopFile = arg.DesktopFile
+			desktopAction = arg.Action
+		default:
+			logger.Warning(ErrTypeAssertionFail)
+			return
+		}
 
 		go func() {
-			err := m.runDesktopFile(action.Arg.(string))
+			var err error
+			if desktopAction != "" {
+				err = m.runDesktopFileAction(desktopFile, desktopAction)
+			} else {
+				err = m.runDesktopFile(desktopFile)
+			}
 			if err != nil {
 				logger.Warning("runDesktopFile error:", err)
 			}
@@ -141,7 +157,7 @@ func (m *Manager) initHandlers() {
 	}
 
 	m.handlers[ActionTypeAudioCtrl] = buildHandlerFromController(m.audioController)
-	m.handlers[ActionTypeMediaPlayerCtrl] = buildHandlerFromController(m.mediaPlayerController)
+	m.handlers[ActionTypeMediaPlayerCtrl] = m.buildMediaPlayerCtrlHandler()
 	m.handlers[ActionTypeDisplayCtrl] = buildHandlerFromController(m.displayController)
 	m.handlers[ActionTypeKbdLightCtrl] = buildHandlerFromController(m.kbdLightController)
 	m.handlers[ActionTypeTouchpadCtrl] = buildHandlerFromController(m.touchPadController)
@@ -350,6 +366,28 @@ func buildHandlerFromController(c Controller) KeyEventFunc {
 	}
 }
 
+// 与 buildHandlerFromController 相同，但支持控制指定的播放器
+func (m *Manager) buildMediaPlayerCtrlHandler() KeyEventFunc {
+	controllerHandler := buildHandlerFromController(m.mediaPlayerController)
+	return func(ev *KeyEvent) {
+		action := ev.Shortcut.GetAction()
+		arg, ok := action.Arg.(*ActionMediaPlayerCtrlArg)
+		if !ok {
+			controllerHandler(ev)
+			return
+		}
+		c := m.mediaPlayerController
+		if c == nil {
+			logger.Warning("controller is nil")
+			return
+		}
+		logger.Debugf("%v Controller exec cmd %v for player %q", c.Name(), arg.Cmd, arg.Player)
+		if err := c.ExecCmdForPlayer(arg.Cmd, arg.Player); err != nil {
+			logger.Warning(c.Name(), "Controller exec cmd err:", err)
+		}
+	}
+}
+
 type ErrInvalidActionCmd struct {
 	Cmd ActionCmd
 }
@@ -62,8 +62,8 @@ func (*Manager) GetInterfaceName() string {
 	return dbusInterface
 }
 
-//true : ignore
-func (m *Manager)isIgnoreRepeat(name string) bool  {
+// true : ignore
+func (m *Manager) isIgnoreRepeat(name string) bool {
 	const minKeyEventInterval = 200 * time.Millisecond
 	now := time.Now()
 	duration := now.Sub(m.lastMethodCalledTime)
@@ -78,37 +78,12 @@ func (m *Manager)isIgnoreRepeat(name string) bool  {
 
 // Reset reset all shortcut
 func (m *Manager) Reset() *dbus.Error {
-	if (m.isIgnoreRepeat("Reset")) {
+	if m.isIgnoreRepeat("Reset") {
 		return nil
 	}
 
 	customShortcuts := m.customShortcutManager.List()
-	m.shortcutManager.UngrabAll()
-
-	m.enableListenGSettingsChanged(false)
-	// reset all gsettings
-	resetGSettings(m.gsSystem)
-	resetGSettings(m.gsMediaKey)
-	if m.gsGnomeWM != nil {
-		resetGSettings(m.gsGnomeWM)
-	}
-
-	// reset for KWin
-	if shouldUseDDEKwin() {
-		err := resetKWin(m.wm)
-		if err != nil {
-			logger.Warning("failed to reset for KWin:", err)
-		}
-		// 由于快捷键冲突原因，有必要重置两遍
-		err = resetKWin(m.wm)
-		if err != nil {
-			logger.Warning("failed to reset for KWin:", err)
-		}
-	}
-
-	changes := m.shortcutManager.ReloadAllShortcutsKeystrokes()
-	m.enableListenGSettingsChanged(true)
-	m.shortcutManager.GrabAll()
+	changes := m.resetShortcuts()
 
 	for _, cs := range customShortcuts {
 		keystrokes := cs.GetKeystrokes()
@@ -146,12 +121,45 @@ func (m *Manager) Reset() *dbus.Error {
 		}
 	}
 
+	m.setCurrentProfile(profileIdDefault)
 	for _, shortcut := range changes {
 		m.emitShortcutSignal(shortcutSignalChanged, shortcut)
 	}
 	return nil
 }
 
+// resetShortcuts 将系统、媒体和窗口管理器快捷键重置为默认值，自定义快捷键的按键会被清空，
+// 返回改变的快捷键。
+func (m *Manager) resetShortcuts() []shortcuts.Shortcut {
+	m.shortcutManager.UngrabAll()
+
+	m.enableListenGSettingsChanged(false)
+	// reset all gsettings
+	resetGSettings(m.gsSystem)
+	resetGSettings(m.gsMediaKey)
+	if m.gsGnomeWM != nil {
+		resetGSettings(m.gsGnomeWM)
+	}
+
+	// reset for KWin
+	if shouldUseDDEKwin() {
+		err := resetKWin(m.wm)
+		if err != nil {
+			logger.Warning("failed to reset for KWin:", err)
+		}
+		// 由于快捷键冲突原因，有必要重置两遍
+		err = resetKWin(m.wm)
+		if err != nil {
+			logger.Warning("failed to reset for KWin:", err)
+		}
+	}
+
+	changes := m.shortcutManager.ReloadAllShortcutsKeystrokes()
+	m.enableListenGSettingsChanged(true)
+	m.shortcutManager.GrabAll()
+	return changes
+}
+
 func (m *Manager) ListAllShortcuts() (string, *dbus.Error) {
 	list := m.shortcutManager.List()
 	ret, err := util.MarshalJSON(list)
@@ -174,33 +182,61 @@ func (m *Manager) AddCustomShortcut(name, action, keystroke string) (id string,
 	type0 int32, busErr *dbus.Error) {
 
 	logger.Debugf("Add custom key: %q %q %q", name, action, keystroke)
-	ks, err := shortcuts.ParseKeystroke(keystroke)
+	shortcut, err := m.addCustomShortcut(name, shortcuts.NewExecCustomAction(action), keystroke)
 	if err != nil {
 		busErr = dbusutil.ToError(err)
 		return
 	}
+	id = shortcut.GetId()
+	type0 = shortcut.GetType()
+	return
+}
 
-	conflictKeystroke, err := m.shortcutManager.FindConflictingKeystroke(ks)
+// AddCustomShortcutWithAction 添加执行指定类型动作的自定义快捷键
+//
+// actionType: 动作类型，可选值见 ListCustomShortcutActionTypes
+// actionArgs: JSON 格式的动作参数，例如 {"Cmd":"PlayPause","Player":"spotify"}
+func (m *Manager) AddCustomShortcutWithAction(name, actionType, actionArgs,
+	keystroke string) (id string, type0 int32, busErr *dbus.Error) {
+
+	logger.Debugf("Add custom key: %q %q %q %q", name, actionType, actionArgs, keystroke)
+	action, err := shortcuts.ParseCustomAction(actionType, actionArgs)
 	if err != nil {
 		busErr = dbusutil.ToError(err)
 		return
 	}
-	if conflictKeystroke != nil {
-		err = errKeystrokeUsed
+	shortcut, err := m.addCustomShortcut(name, action, keystroke)
+	if err != nil {
 		busErr = dbusutil.ToError(err)
 		return
 	}
+	id = shortcut.GetId()
+	type0 = shortcut.GetType()
+	return
+}
+
+func (m *Manager) addCustomShortcut(name string, action *shortcuts.CustomAction,
+	keystroke string) (shortcuts.Shortcut, error) {
+	ks, err := shortcuts.ParseKeystroke(keystroke)
+	if err != nil {
+		return nil, err
+	}
 
-	shortcut, err := m.customShortcutManager.Add(name, action, []*shortcuts.Keystroke{ks})
+	conflictKeystroke, err := m.shortcutManager.FindConflictingKeystroke(ks)
 	if err != nil {
-		busErr = dbusutil.ToError(err)
-		return
+		return nil, err
+	}
+	if conflictKeystroke != nil {
+		return nil, errKeystrokeUsed
+	}
+
+	shortcut, err := m.customShortcutManager.AddWithAction(name, action, []*shortcuts.Keystroke{ks})
+	if err != nil {
+		return nil, err
 	}
 	m.shortcutManager.Add(shortcut)
 	m.emitShortcutSignal(shortcutSignalAdded, shortcut)
-	id = shortcut.GetId()
-	type0 = shortcut.GetType()
-	return
+	return shortcut, nil
 }
 
 func (m *Manager) DeleteCustomShortcut(id string) *dbus.Error {
@@ -231,6 +267,10 @@ func (m *Manager) ClearShortcutKeystrokes(id string, type0 int32) *dbus.Error {
 }
 
 func (m *Manager) LookupConflictingShortcut(keystroke string) (string, *dbus.Error) {
+	if shortcuts.IsKeySequenceString(keystroke) {
+		return m.lookupConflictingKeySequence(keystroke)
+	}
+
 	ks, err := shortcuts.ParseKeystroke(keystroke)
 	if err != nil {
 		// parse keystroke error
@@ -251,6 +291,133 @@ func (m *Manager) LookupConflictingShortcut(keystroke string) (string, *dbus.Err
 	return "", nil
 }
 
+func (m *Manager) lookupConflictingKeySequence(sequence string) (string, *dbus.Error) {
+	seq, err := shortcuts.ParseKeySequence(sequence)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+
+	conflictShortcut, err := m.shortcutManager.FindConflictingKeySequence(seq)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	if conflictShortcut != nil {
+		detail, err := util.MarshalJSON(conflictShortcut)
+		if err != nil {
+			return "", dbusutil.ToError(err)
+		}
+		return detail, nil
+	}
+	return "", nil
+}
+
+func (m *Manager) getCustomKeySequenceShortcut(id string) (shortcuts.KeySequenceShortcut, error) {
+	const ty = shortcuts.ShortcutTypeCustom
+	shortcut := m.shortcutManager.GetByIdType(id, ty)
+	if shortcut == nil {
+		return nil, ErrShortcutNotFound{id, ty}
+	}
+	seqShortcut, ok := shortcut.(shortcuts.KeySequenceShortcut)
+	if !ok {
+		return nil, errTypeAssertionFail
+	}
+	return seqShortcut, nil
+}
+
+// AddCustomShortcutKeySequence 为自定义快捷键添加按键序列
+//
+// sequence: 以逗号分隔的多个 keystroke，例如 "<Super>W,H"；
+// 或者修饰键手势，例如 "Control_L@tap"、"Super_L@hold"
+func (m *Manager) AddCustomShortcutKeySequence(id, sequence string) *dbus.Error {
+	logger.Debug("AddCustomShortcutKeySequence", id, sequence)
+	shortcut, err := m.getCustomKeySequenceShortcut(id)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	seq, err := shortcuts.ParseKeySequence(sequence)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+
+	conflictShortcut, err := m.shortcutManager.FindConflictingKeySequence(seq)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	if conflictShortcut != nil && conflictShortcut != shortcuts.Shortcut(shortcut) {
+		return dbusutil.ToError(errKeystrokeUsed)
+	}
+
+	m.shortcutManager.AddShortcutKeySequence(shortcut, seq)
+	err = shortcut.SaveKeystrokes()
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	m.emitShortcutSignal(shortcutSignalChanged, shortcut)
+	return nil
+}
+
+func (m *Manager) DeleteCustomShortcutKeySequence(id, sequence string) *dbus.Error {
+	logger.Debug("DeleteCustomShortcutKeySequence", id, sequence)
+	shortcut, err := m.getCustomKeySequenceShortcut(id)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	seq, err := shortcuts.ParseKeySequence(sequence)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+
+	m.shortcutManager.DeleteShortcutKeySequence(shortcut, seq)
+	err = shortcut.SaveKeystrokes()
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	m.emitShortcutSignal(shortcutSignalChanged, shortcut)
+	return nil
+}
+
+// SetCustomShortcutAppScope 限定自定义快捷键生效的应用
+//
+// scope: JSON 格式，例如 {"Mode":"include","Apps":["deepin-terminal"]}，
+// Mode 为 include 时只在列出的应用是焦点窗口时生效，为 exclude 时在列出的应用中不生效；
+// Apps 中为任务栏识别的 desktop id 或者窗口的 WM_CLASS。为空时在所有应用中生效。
+func (m *Manager) SetCustomShortcutAppScope(id, scope string) *dbus.Error {
+	logger.Debug("SetCustomShortcutAppScope", id, scope)
+	const ty = shortcuts.ShortcutTypeCustom
+	shortcut := m.shortcutManager.GetByIdType(id, ty)
+	if shortcut == nil {
+		return dbusutil.ToError(ErrShortcutNotFound{id, ty})
+	}
+	customShortcut, ok := shortcut.(*shortcuts.CustomShortcut)
+	if !ok {
+		return dbusutil.ToError(errTypeAssertionFail)
+	}
+
+	appScope, err := shortcuts.ParseAppScope(scope)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	// 扩大生效的应用后可能与其他快捷键冲突
+	for _, ks := range customShortcut.GetKeystrokes() {
+		conflictKeystroke, err := m.shortcutManager.FindConflictingKeystrokeInScope(ks, appScope)
+		if err != nil {
+			return dbusutil.ToError(err)
+		}
+		if conflictKeystroke != nil && conflictKeystroke.Shortcut != shortcut {
+			return dbusutil.ToError(errKeystrokeUsed)
+		}
+	}
+
+	m.updateActiveApp()
+	m.shortcutManager.ModifyShortcutAppScope(customShortcut, appScope)
+	err = customShortcut.Save()
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	m.emitShortcutSignal(shortcutSignalChanged, shortcut)
+	return nil
+}
+
 // ModifyCustomShortcut modify custom shortcut
 //
 // id: shortcut id
@@ -277,7 +444,7 @@ func (m *Manager) ModifyCustomShortcut(id, name, cmd, keystroke string) *dbus.Er
 			return dbusutil.ToError(err)
 		}
 		// check conflicting
-		conflictKeystroke, err := m.shortcutManager.FindConflictingKeystroke(ks)
+		conflictKeystroke, err := m.shortcutManager.FindConflictingKeystrokeFor(shortcut, ks)
 		if err != nil {
 			return dbusutil.ToError(err)
 		}
@@ -289,7 +456,10 @@ func (m *Manager) ModifyCustomShortcut(id, name, cmd, keystroke string) *dbus.Er
 
 	// modify then save
 	customShortcut.SetName(name)
-	customShortcut.Cmd = cmd
+	// cmd 为空时保留非 Exec 类型的动作
+	if cmd != "" || customShortcut.ActionType == "" {
+		customShortcut.SetCustomAction(shortcuts.NewExecCustomAction(cmd))
+	}
 	m.shortcutManager.ModifyShortcutKeystrokes(shortcut, keystrokes)
 	err := customShortcut.Save()
 	if err != nil {
@@ -299,6 +469,48 @@ func (m *Manager) ModifyCustomShortcut(id, name, cmd, keystroke string) *dbus.Er
 	return nil
 }
 
+// ModifyCustomShortcutAction 修改自定义快捷键的动作，参数含义同 AddCustomShortcutWithAction
+func (m *Manager) ModifyCustomShortcutAction(id, actionType, actionArgs string) *dbus.Error {
+	logger.Debugf("ModifyCustomShortcutAction id: %q, actionType: %q, actionArgs: %q",
+		id, actionType, actionArgs)
+	const ty = shortcuts.ShortcutTypeCustom
+	shortcut := m.shortcutManager.GetByIdType(id, ty)
+	if shortcut == nil {
+		return dbusutil.ToError(ErrShortcutNotFound{id, ty})
+	}
+	customShortcut, ok := shortcut.(*shortcuts.CustomShortcut)
+	if !ok {
+		return dbusutil.ToError(errTypeAssertionFail)
+	}
+
+	action, err := shortcuts.ParseCustomAction(actionType, actionArgs)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	customShortcut.SetCustomAction(action)
+	err = customShortcut.Save()
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	m.emitShortcutSignal(shortcutSignalChanged, shortcut)
+	return nil
+}
+
+// ValidateCustomShortcutAction 检查动作是否有效，无效时返回错误
+func (m *Manager) ValidateCustomShortcutAction(actionType, actionArgs string) *dbus.Error {
+	_, err := shortcuts.ParseCustomAction(actionType, actionArgs)
+	return dbusutil.ToError(err)
+}
+
+// ListCustomShortcutActionTypes 返回自定义快捷键支持的动作类型及各类型可用的控制命令
+func (m *Manager) ListCustomShortcutActionTypes() (string, *dbus.Error) {
+	ret, err := util.MarshalJSON(shortcuts.ListCustomActionTypes())
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return ret, nil
+}
+
 func (m *Manager) AddShortcutKeystroke(id string, type0 int32, keystroke string) *dbus.Error {
 	logger.Debug("AddShortcutKeystroke", id, type0, keystroke)
 	shortcut := m.shortcutManager.GetByIdType(id, type0)
@@ -324,7 +536,7 @@ func (m *Manager) AddShortcutKeystroke(id string, type0 int32, keystroke string)
 		}
 	}
 
-	conflictKeystroke, err := m.shortcutManager.FindConflictingKeystroke(ks)
+	conflictKeystroke, err := m.shortcutManager.FindConflictingKeystrokeFor(shortcut, ks)
 	if err != nil {
 		return dbusutil.ToError(err)
 	}
@@ -0,0 +1,477 @@
+package keybinding
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/godbus/dbus"
+	"pkg.deepin.io/dde/daemon/keybinding/shortcuts"
+	"pkg.deepin.io/lib/dbusutil"
+	"pkg.deepin.io/lib/xdg/basedir"
+)
+
+func getUserProfilesDir() string {
+	return filepath.Join(basedir.GetUserConfigDir(), userProfilesDir)
+}
+
+func getProfileStateFile() string {
+	return filepath.Join(basedir.GetUserConfigDir(), profileStateFile)
+}
+
+func (m *Manager) initProfile() {
+	m.profileMu.Lock()
+	m.currentProfile = loadCurrentProfileId(getProfileStateFile())
+	m.profileMu.Unlock()
+}
+
+func (m *Manager) getCurrentProfile() string {
+	m.profileMu.Lock()
+	defer m.profileMu.Unlock()
+	return m.currentProfile
+}
+
+func (m *Manager) setCurrentProfile(id string) {
+	m.profileMu.Lock()
+	if m.currentProfile == id {
+		m.profileMu.Unlock()
+		return
+	}
+	m.currentProfile = id
+	m.profileMu.Unlock()
+
+	err := saveCurrentProfileId(getProfileStateFile(), id)
+	if err != nil {
+		logger.Warning("failed to save current profile:", err)
+	}
+}
+
+func (m *Manager) listProfiles() []*Profile {
+	return append(getBuiltinProfiles(), loadUserProfiles(getUserProfilesDir())...)
+}
+
+func (m *Manager) getProfile(id string) (*Profile, error) {
+	for _, profile := range m.listProfiles() {
+		if profile.Id == id {
+			return profile, nil
+		}
+	}
+	return nil, fmt.Errorf("profile %q is not found", id)
+}
+
+func getConflictShortcut(ks *shortcuts.Keystroke) shortcuts.Shortcut {
+	if ks == nil {
+		return nil
+	}
+	return ks.Shortcut
+}
+
+// applyProfile 先将快捷键重置为默认值，再应用方案中的设置。
+// 自定义快捷键会被保留，并且优先于方案中的快捷键，被占用的按键记录在返回的冲突列表中。
+func (m *Manager) applyProfile(profile *Profile) []ShortcutConflict {
+	customShortcuts := m.customShortcutManager.List()
+	changes := m.resetShortcuts()
+	changed := make(map[string]shortcuts.Shortcut)
+	for _, shortcut := range changes {
+		changed[shortcut.GetUid()] = shortcut
+	}
+
+	var conflicts []ShortcutConflict
+	m.enableListenGSettingsChanged(false)
+	for _, cs := range customShortcuts {
+		cs0 := m.shortcutManager.GetByUid(cs.GetUid())
+		if cs0 == nil {
+			logger.Warning("cs0 is nil")
+			continue
+		}
+
+		var keystrokes []*shortcuts.Keystroke
+		for _, ks := range cs.GetKeystrokes() {
+			conflictKs, err := m.shortcutManager.FindConflictingKeystroke(ks)
+			if err != nil {
+				logger.Warning(err)
+				continue
+			}
+			other := getConflictShortcut(conflictKs)
+			if other != nil && other != cs0 {
+				// 默认快捷键让位于自定义快捷键
+				m.shortcutManager.DeleteShortcutKeystroke(other, conflictKs)
+				err = other.SaveKeystrokes()
+				if err != nil {
+					logger.Warning(err)
+				}
+				changed[other.GetUid()] = other
+				conflicts = append(conflicts, ShortcutConflict{
+					Id:           other.GetId(),
+					Type:         other.GetType(),
+					Keystroke:    ks.String(),
+					ConflictId:   cs0.GetId(),
+					ConflictType: cs0.GetType(),
+				})
+			}
+			keystrokes = append(keystrokes, ks)
+		}
+		m.shortcutManager.ModifyShortcutKeystrokes(cs0, keystrokes)
+	}
+	m.enableListenGSettingsChanged(true)
+
+	conflicts = append(conflicts, m.applyShortcutsData(profile.Shortcuts, changed)...)
+	m.setCurrentProfile(profile.Id)
+
+	for _, shortcut := range changed {
+		m.emitShortcutSignal(shortcutSignalChanged, shortcut)
+	}
+	return conflicts
+}
+
+type shortcutKeystrokes struct {
+	shortcut   shortcuts.Shortcut
+	keystrokes []*shortcuts.Keystroke
+}
+
+// applyShortcutsData 修改系统、媒体和窗口管理器快捷键的按键，与其他快捷键冲突的按键不会被设置。
+func (m *Manager) applyShortcutsData(list []ShortcutData,
+	changed map[string]shortcuts.Shortcut) []ShortcutConflict {
+	var conflicts []ShortcutConflict
+	var targets []shortcutKeystrokes
+	for _, data := range list {
+		if data.Type == shortcuts.ShortcutTypeCustom {
+			continue
+		}
+		shortcut := m.shortcutManager.GetByIdType(data.Id, data.Type)
+		if shortcut == nil || !shortcut.GetKeystrokesModifiable() {
+			conflicts = append(conflicts, ShortcutConflict{
+				Id:     data.Id,
+				Type:   data.Type,
+				Reason: "shortcut is not found or unmodifiable",
+			})
+			continue
+		}
+		targets = append(targets, shortcutKeystrokes{
+			shortcut:   shortcut,
+			keystrokes: parseKeystrokes(data.Id, data.Type, data.Keystrokes, &conflicts),
+		})
+	}
+
+	m.enableListenGSettingsChanged(false)
+	defer m.enableListenGSettingsChanged(true)
+
+	// 先清空所有要修改的快捷键，使得快捷键之间可以互换按键
+	for _, t := range targets {
+		m.shortcutManager.ModifyShortcutKeystrokes(t.shortcut, nil)
+	}
+
+	for _, t := range targets {
+		conflicts = append(conflicts, m.addKeystrokesNoConflict(t.shortcut, t.keystrokes)...)
+		err := t.shortcut.SaveKeystrokes()
+		if err != nil {
+			logger.Warning(err)
+		}
+		changed[t.shortcut.GetUid()] = t.shortcut
+	}
+	return conflicts
+}
+
+// addKeystrokesNoConflict 为快捷键添加按键，跳过已被其他快捷键占用的按键
+func (m *Manager) addKeystrokesNoConflict(shortcut shortcuts.Shortcut,
+	keystrokes []*shortcuts.Keystroke) []ShortcutConflict {
+	var conflicts []ShortcutConflict
+	for _, ks := range keystrokes {
+		conflictKs, err := m.shortcutManager.FindConflictingKeystrokeFor(shortcut, ks)
+		if err != nil {
+			conflicts = append(conflicts, ShortcutConflict{
+				Id:        shortcut.GetId(),
+				Type:      shortcut.GetType(),
+				Keystroke: ks.String(),
+				Reason:    err.Error(),
+			})
+			continue
+		}
+		other := getConflictShortcut(conflictKs)
+		if other != nil && other != shortcut {
+			conflicts = append(conflicts, ShortcutConflict{
+				Id:           shortcut.GetId(),
+				Type:         shortcut.GetType(),
+				Keystroke:    ks.String(),
+				ConflictId:   other.GetId(),
+				ConflictType: other.GetType(),
+			})
+			continue
+		}
+		m.shortcutManager.AddShortcutKeystroke(shortcut, ks)
+	}
+	return conflicts
+}
+
+// importCustomShortcuts 导入自定义快捷键，Id 已存在的进行修改，否则新建。
+func (m *Manager) importCustomShortcuts(list []CustomShortcutData,
+	changed map[string]shortcuts.Shortcut) (added []shortcuts.Shortcut, conflicts []ShortcutConflict) {
+	const ty = shortcuts.ShortcutTypeCustom
+	for _, data := range list {
+		action := &shortcuts.CustomAction{
+			Type: data.ActionType,
+			Args: data.ActionArgs,
+		}
+		if action.Type == "" {
+			action.Type = shortcuts.CustomActionTypeExec
+		}
+		err := action.Validate()
+		if err != nil {
+			conflicts = append(conflicts, ShortcutConflict{
+				Id:     data.Id,
+				Type:   ty,
+				Reason: err.Error(),
+			})
+			continue
+		}
+
+		var customShortcut *shortcuts.CustomShortcut
+		if shortcut := m.shortcutManager.GetByIdType(data.Id, ty); shortcut != nil {
+			cs, ok := shortcut.(*shortcuts.CustomShortcut)
+			if !ok {
+				logger.Warning(errTypeAssertionFail)
+				continue
+			}
+			cs.SetName(data.Name)
+			cs.SetCustomAction(action)
+			m.shortcutManager.ModifyShortcutKeystrokes(cs, nil)
+			m.shortcutManager.ModifyShortcutKeySequences(cs, nil)
+			changed[cs.GetUid()] = cs
+			customShortcut = cs
+		} else {
+			shortcut, err := m.customShortcutManager.AddWithAction(data.Name, action, nil)
+			if err != nil {
+				logger.Warning(err)
+				continue
+			}
+			cs, ok := shortcut.(*shortcuts.CustomShortcut)
+			if !ok {
+				logger.Warning(errTypeAssertionFail)
+				continue
+			}
+			m.shortcutManager.Add(cs)
+			added = append(added, cs)
+			customShortcut = cs
+		}
+
+		if data.AppScope != nil {
+			if err := data.AppScope.Validate(); err != nil {
+				logger.Warningf("ignore invalid app scope of custom shortcut %q: %v", data.Name, err)
+				data.AppScope = nil
+			} else {
+				m.updateActiveApp()
+			}
+		}
+		m.shortcutManager.ModifyShortcutAppScope(customShortcut, data.AppScope)
+
+		keystrokes := parseKeystrokes(customShortcut.GetId(), ty, data.Keystrokes, &conflicts)
+		conflicts = append(conflicts, m.addKeystrokesNoConflict(customShortcut, keystrokes)...)
+		for _, str := range data.Sequences {
+			conflict := m.addKeySequenceNoConflict(customShortcut, str)
+			if conflict != nil {
+				conflicts = append(conflicts, *conflict)
+			}
+		}
+
+		err = customShortcut.Save()
+		if err != nil {
+			logger.Warning(err)
+		}
+	}
+	return
+}
+
+func (m *Manager) addKeySequenceNoConflict(shortcut shortcuts.KeySequenceShortcut,
+	str string) *ShortcutConflict {
+	conflict := &ShortcutConflict{
+		Id:        shortcut.GetId(),
+		Type:      shortcut.GetType(),
+		Keystroke: str,
+	}
+	seq, err := shortcuts.ParseKeySequence(str)
+	if err != nil {
+		conflict.Reason = err.Error()
+		return conflict
+	}
+	other, err := m.shortcutManager.FindConflictingKeySequence(seq)
+	if err != nil {
+		conflict.Reason = err.Error()
+		return conflict
+	}
+	if other != nil && other != shortcuts.Shortcut(shortcut) {
+		conflict.ConflictId = other.GetId()
+		conflict.ConflictType = other.GetType()
+		return conflict
+	}
+	m.shortcutManager.AddShortcutKeySequence(shortcut, seq)
+	return nil
+}
+
+func (m *Manager) exportShortcuts() *ShortcutsData {
+	data := &ShortcutsData{
+		Version: shortcutsDataVersion,
+		Profile: m.getCurrentProfile(),
+	}
+	for _, shortcut := range m.shortcutManager.List() {
+		if !shortcut.GetKeystrokesModifiable() {
+			continue
+		}
+		keystrokes := shortcut.GetKeystrokes()
+		strv := make([]string, len(keystrokes))
+		for i, ks := range keystrokes {
+			strv[i] = ks.String()
+		}
+
+		switch shortcut.GetType() {
+		case shortcuts.ShortcutTypeCustom:
+			cs, ok := shortcut.(*shortcuts.CustomShortcut)
+			if !ok {
+				continue
+			}
+			action := cs.GetCustomAction()
+			csData := CustomShortcutData{
+				Id:         cs.GetId(),
+				Name:       cs.GetName(),
+				ActionType: action.Type,
+				ActionArgs: action.Args,
+				Keystrokes: strv,
+				AppScope:   cs.GetAppScope(),
+			}
+			for _, seq := range cs.GetKeySequences() {
+				csData.Sequences = append(csData.Sequences, seq.String())
+			}
+			data.Custom = append(data.Custom, csData)
+
+		case shortcuts.ShortcutTypeSystem, shortcuts.ShortcutTypeMedia, shortcuts.ShortcutTypeWM:
+			data.Shortcuts = append(data.Shortcuts, ShortcutData{
+				Id:         shortcut.GetId(),
+				Type:       shortcut.GetType(),
+				Keystrokes: strv,
+			})
+		}
+	}
+
+	sort.Slice(data.Shortcuts, func(i, j int) bool {
+		a, b := data.Shortcuts[i], data.Shortcuts[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Id < b.Id
+	})
+	sort.Slice(data.Custom, func(i, j int) bool {
+		return data.Custom[i].Name < data.Custom[j].Name
+	})
+	return data
+}
+
+func marshalConflicts(conflicts []ShortcutConflict) (string, error) {
+	if conflicts == nil {
+		conflicts = []ShortcutConflict{}
+	}
+	data, err := json.Marshal(conflicts)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ListProfiles 列出所有快捷键方案，包括内置方案和用户保存的方案
+func (m *Manager) ListProfiles() (string, *dbus.Error) {
+	data, err := json.Marshal(m.listProfiles())
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return string(data), nil
+}
+
+func (m *Manager) GetCurrentProfile() (string, *dbus.Error) {
+	return m.getCurrentProfile(), nil
+}
+
+// ApplyProfile 切换到快捷键方案，自定义快捷键会被保留。
+// 返回 JSON 格式的冲突列表，列出因为冲突而没有设置的按键。
+func (m *Manager) ApplyProfile(id string) (string, *dbus.Error) {
+	logger.Debug("ApplyProfile", id)
+	profile, err := m.getProfile(id)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+
+	conflicts := m.applyProfile(profile)
+	ret, err := marshalConflicts(conflicts)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return ret, nil
+}
+
+// SaveProfile 将当前的系统、媒体和窗口管理器快捷键保存为用户方案，不包括自定义快捷键
+func (m *Manager) SaveProfile(id, name string) *dbus.Error {
+	logger.Debug("SaveProfile", id, name)
+	for _, profile := range getBuiltinProfiles() {
+		if profile.Id == id {
+			return dbusutil.ToError(fmt.Errorf("can not overwrite builtin profile %q", id))
+		}
+	}
+
+	profile := &Profile{
+		Id:        id,
+		Name:      name,
+		Shortcuts: m.exportShortcuts().Shortcuts,
+	}
+	err := saveUserProfile(getUserProfilesDir(), profile)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	m.setCurrentProfile(id)
+	return nil
+}
+
+func (m *Manager) DeleteProfile(id string) *dbus.Error {
+	logger.Debug("DeleteProfile", id)
+	err := deleteUserProfile(getUserProfilesDir(), id)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	if m.getCurrentProfile() == id {
+		m.setCurrentProfile(profileIdDefault)
+	}
+	return nil
+}
+
+// ExportShortcuts 导出所有可修改的快捷键，包括自定义快捷键
+func (m *Manager) ExportShortcuts() (string, *dbus.Error) {
+	data, err := json.MarshalIndent(m.exportShortcuts(), "", "  ")
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return string(data), nil
+}
+
+// ImportShortcuts 导入 ExportShortcuts 导出的快捷键，未包含在数据中的自定义快捷键保持不变。
+// 返回 JSON 格式的冲突列表，列出因为冲突或者无效而没有设置的按键。
+func (m *Manager) ImportShortcuts(data string) (string, *dbus.Error) {
+	shortcutsData, err := parseShortcutsData(data)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+
+	changed := make(map[string]shortcuts.Shortcut)
+	conflicts := m.applyShortcutsData(shortcutsData.Shortcuts, changed)
+	added, customConflicts := m.importCustomShortcuts(shortcutsData.Custom, changed)
+	conflicts = append(conflicts, customConflicts...)
+
+	for _, shortcut := range added {
+		m.emitShortcutSignal(shortcutSignalAdded, shortcut)
+	}
+	for _, shortcut := range changed {
+		m.emitShortcutSignal(shortcutSignalChanged, shortcut)
+	}
+
+	ret, err := marshalConflicts(conflicts)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return ret, nil
+}
@@ -74,7 +74,17 @@ func (c *MediaPlayerController) Name() string {
 }
 
 func (c *MediaPlayerController) ExecCmd(cmd ActionCmd) error {
-	player := c.getActiveMpris()
+	return c.ExecCmdForPlayer(cmd, "")
+}
+
+// ExecCmdForPlayer 对名称为 playerName 的播放器执行命令，playerName 为空时使用当前活跃的播放器。
+func (c *MediaPlayerController) ExecCmdForPlayer(cmd ActionCmd, playerName string) error {
+	var player *mpris2.MediaPlayer
+	if playerName == "" {
+		player = c.getActiveMpris()
+	} else {
+		player = c.getMprisByName(playerName)
+	}
 	if player == nil {
 		return errors.New("no player found")
 	}
@@ -194,3 +204,14 @@ func (c *MediaPlayerController) getActiveMpris() *mpris2.MediaPlayer {
 	player := mpris2.NewMediaPlayer(c.conn, senders[0])
 	return player
 }
+
+// getMprisByName 查找服务名为 org.mpris.MediaPlayer2.<name> 或以其为前缀(多实例)的播放器
+func (c *MediaPlayerController) getMprisByName(name string) *mpris2.MediaPlayer {
+	serviceName := senderTypeMpris + "." + name
+	for _, sender := range c.getMprisSender() {
+		if sender == serviceName || strings.HasPrefix(sender, serviceName+".") {
+			return mpris2.NewMediaPlayer(c.conn, sender)
+		}
+	}
+	return nil
+}
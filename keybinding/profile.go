@@ -0,0 +1,333 @@
+package keybinding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"pkg.deepin.io/dde/daemon/keybinding/shortcuts"
+	"pkg.deepin.io/lib/gettext"
+)
+
+const (
+	profileIdDefault = "default"
+	profileIdGnome   = "gnome"
+	profileIdWindows = "windows"
+	profileIdMacOS   = "macos"
+
+	userProfilesDir  = "deepin/dde-daemon/keybinding/profiles"
+	profileStateFile = "deepin/dde-daemon/keybinding/profile.json"
+
+	shortcutsDataVersion = 1
+)
+
+// ShortcutData 是一个快捷键的按键设置，Id 和 Type 与 ListAllShortcuts 中的一致
+type ShortcutData struct {
+	Id         string
+	Type       int32
+	Keystrokes []string
+}
+
+// CustomShortcutData 是导入导出时使用的自定义快捷键
+type CustomShortcutData struct {
+	Id         string
+	Name       string
+	ActionType string
+	ActionArgs shortcuts.CustomActionArgs
+	Keystrokes []string
+	Sequences  []string            `json:",omitempty"`
+	AppScope   *shortcuts.AppScope `json:",omitempty"`
+}
+
+// ShortcutsData 是导入导出快捷键时使用的 JSON 格式
+type ShortcutsData struct {
+	Version   int
+	Profile   string `json:",omitempty"`
+	Shortcuts []ShortcutData
+	Custom    []CustomShortcutData `json:",omitempty"`
+}
+
+// ShortcutConflict 描述导入或者切换方案时，因为冲突而没有设置的按键
+type ShortcutConflict struct {
+	Id           string
+	Type         int32
+	Keystroke    string
+	ConflictId   string `json:",omitempty"`
+	ConflictType int32  `json:",omitempty"`
+	Reason       string `json:",omitempty"`
+}
+
+// Profile 是一组预设的快捷键方案，Shortcuts 中的设置会覆盖默认值，未列出的快捷键使用默认值
+type Profile struct {
+	Id        string
+	Name      string
+	Builtin   bool `json:",omitempty"`
+	Shortcuts []ShortcutData
+}
+
+func sysShortcut(id string, keystrokes ...string) ShortcutData {
+	return ShortcutData{Id: id, Type: shortcuts.ShortcutTypeSystem, Keystrokes: keystrokes}
+}
+
+func wmShortcut(id string, keystrokes ...string) ShortcutData {
+	return ShortcutData{Id: id, Type: shortcuts.ShortcutTypeWM, Keystrokes: keystrokes}
+}
+
+func getBuiltinProfiles() []*Profile {
+	return []*Profile{
+		{
+			Id:      profileIdDefault,
+			Name:    gettext.Tr("Default"),
+			Builtin: true,
+		},
+		{
+			Id:      profileIdGnome,
+			Name:    gettext.Tr("GNOME"),
+			Builtin: true,
+			Shortcuts: []ShortcutData{
+				sysShortcut("launcher", "Super_L"),
+				sysShortcut("lock-screen", "<Super>L"),
+				sysShortcut("screenshot", "Print"),
+				sysShortcut("screenshot-window", "<Alt>Print"),
+				sysShortcut("file-manager", "<Super>E"),
+				wmShortcut("close", "<Alt>F4"),
+				wmShortcut("maximize", "<Super>Up"),
+				wmShortcut("unmaximize", "<Super>Down"),
+				wmShortcut("minimize", "<Super>H"),
+				wmShortcut("begin-move", "<Alt>F7"),
+				wmShortcut("begin-resize", "<Alt>F8"),
+				wmShortcut("switch-to-workspace-left", "<Super>Page_Up", "<Control><Alt>Left"),
+				wmShortcut("switch-to-workspace-right", "<Super>Page_Down", "<Control><Alt>Right"),
+				wmShortcut("move-to-workspace-left", "<Super><Shift>Page_Up", "<Control><Shift><Alt>Left"),
+				wmShortcut("move-to-workspace-right", "<Super><Shift>Page_Down", "<Control><Shift><Alt>Right"),
+			},
+		},
+		{
+			Id:      profileIdWindows,
+			Name:    gettext.Tr("Windows"),
+			Builtin: true,
+			Shortcuts: []ShortcutData{
+				sysShortcut("launcher", "Super_L"),
+				sysShortcut("lock-screen", "<Super>L"),
+				sysShortcut("file-manager", "<Super>E"),
+				sysShortcut("screenshot", "<Super><Shift>S"),
+				sysShortcut("system-monitor", "<Control><Shift>Escape"),
+				wmShortcut("show-desktop", "<Super>D"),
+				wmShortcut("close", "<Alt>F4"),
+				wmShortcut("maximize", "<Super>Up"),
+				wmShortcut("unmaximize", "<Super>Down"),
+				wmShortcut("switch-applications", "<Alt>Tab"),
+				wmShortcut("switch-applications-backward", "<Alt><Shift>Tab"),
+				wmShortcut("expose-windows", "<Super>Tab"),
+				wmShortcut("switch-to-workspace-left", "<Control><Super>Left"),
+				wmShortcut("switch-to-workspace-right", "<Control><Super>Right"),
+			},
+		},
+		{
+			Id:      profileIdMacOS,
+			Name:    gettext.Tr("macOS"),
+			Builtin: true,
+			Shortcuts: []ShortcutData{
+				sysShortcut("launcher", "<Super>space"),
+				sysShortcut("lock-screen", "<Control><Super>Q"),
+				sysShortcut("file-manager", "<Super><Alt>space"),
+				sysShortcut("screenshot", "<Super><Shift>4"),
+				sysShortcut("screenshot-fullscreen", "<Super><Shift>3"),
+				sysShortcut("system-monitor", "<Super><Alt>Escape"),
+				wmShortcut("close", "<Super>W"),
+				wmShortcut("minimize", "<Super>M"),
+				wmShortcut("toggle-fullscreen", "<Control><Super>F"),
+				wmShortcut("switch-applications", "<Super>Tab"),
+				wmShortcut("switch-applications-backward", "<Super><Shift>Tab"),
+				wmShortcut("switch-group", "<Super>grave"),
+				wmShortcut("expose-windows", "<Control>Up"),
+				wmShortcut("show-desktop", "F11"),
+				wmShortcut("switch-to-workspace-left", "<Control>Left"),
+				wmShortcut("switch-to-workspace-right", "<Control>Right"),
+			},
+		},
+	}
+}
+
+var profileIdReg = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+func checkProfileId(id string) error {
+	if !profileIdReg.MatchString(id) {
+		return fmt.Errorf("invalid profile id %q", id)
+	}
+	return nil
+}
+
+func (p *Profile) check() error {
+	if err := checkProfileId(p.Id); err != nil {
+		return err
+	}
+	for _, s := range p.Shortcuts {
+		if s.Type == shortcuts.ShortcutTypeCustom {
+			return fmt.Errorf("profile %q: custom shortcut %q is not allowed", p.Id, s.Id)
+		}
+		for _, keystroke := range s.Keystrokes {
+			_, err := shortcuts.ParseKeystroke(keystroke)
+			if err != nil {
+				return fmt.Errorf("profile %q: shortcut %q: %v", p.Id, s.Id, err)
+			}
+		}
+	}
+	return nil
+}
+
+func loadProfile(filename string) (*Profile, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var profile Profile
+	err = json.Unmarshal(data, &profile)
+	if err != nil {
+		return nil, err
+	}
+	profile.Builtin = false
+	err = profile.check()
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// loadUserProfiles 加载 dir 中用户保存的方案，忽略无效的文件和与内置方案同名的方案
+func loadUserProfiles(dir string) []*Profile {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		logger.Warning(err)
+		return nil
+	}
+	sort.Strings(files)
+
+	builtinIds := make(map[string]bool)
+	for _, p := range getBuiltinProfiles() {
+		builtinIds[p.Id] = true
+	}
+
+	var result []*Profile
+	for _, file := range files {
+		profile, err := loadProfile(file)
+		if err != nil {
+			logger.Warningf("failed to load profile %q: %v", file, err)
+			continue
+		}
+		if builtinIds[profile.Id] {
+			logger.Warningf("ignore profile %q, id conflicts with builtin profile", file)
+			continue
+		}
+		result = append(result, profile)
+	}
+	return result
+}
+
+func saveUserProfile(dir string, profile *Profile) error {
+	err := profile.check()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	filename := filepath.Join(dir, profile.Id+".json")
+	tmpFile := filename + ".tmp"
+	err = ioutil.WriteFile(tmpFile, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, filename)
+}
+
+func deleteUserProfile(dir, id string) error {
+	err := checkProfileId(id)
+	if err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(dir, id+".json"))
+}
+
+type profileState struct {
+	Current string
+}
+
+func loadCurrentProfileId(filename string) string {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warning(err)
+		}
+		return profileIdDefault
+	}
+	var state profileState
+	err = json.Unmarshal(data, &state)
+	if err != nil || state.Current == "" {
+		logger.Warning("failed to load profile state:", err)
+		return profileIdDefault
+	}
+	return state.Current
+}
+
+func saveCurrentProfileId(filename, id string) error {
+	data, err := json.Marshal(&profileState{Current: id})
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+var errShortcutsDataVersion = errors.New("unsupported shortcuts data version")
+
+func parseShortcutsData(str string) (*ShortcutsData, error) {
+	var data ShortcutsData
+	err := json.Unmarshal([]byte(str), &data)
+	if err != nil {
+		return nil, err
+	}
+	if data.Version <= 0 || data.Version > shortcutsDataVersion {
+		return nil, errShortcutsDataVersion
+	}
+	for _, cs := range data.Custom {
+		if strings.TrimSpace(cs.Name) == "" {
+			return nil, errors.New("name of custom shortcut is empty")
+		}
+	}
+	return &data, nil
+}
+
+// parseKeystrokes 解析 strv 中的按键，无法解析的按键记录到 conflicts 中
+func parseKeystrokes(id string, type0 int32, strv []string,
+	conflicts *[]ShortcutConflict) []*shortcuts.Keystroke {
+	result := make([]*shortcuts.Keystroke, 0, len(strv))
+	for _, str := range strv {
+		ks, err := shortcuts.ParseKeystroke(str)
+		if err != nil {
+			*conflicts = append(*conflicts, ShortcutConflict{
+				Id:        id,
+				Type:      type0,
+				Keystroke: str,
+				Reason:    err.Error(),
+			})
+			continue
+		}
+		result = append(result, ks)
+	}
+	return result
+}
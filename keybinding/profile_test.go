@@ -0,0 +1,89 @@
+package keybinding
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"pkg.deepin.io/dde/daemon/keybinding/shortcuts"
+)
+
+func Test_builtinProfiles(t *testing.T) {
+	ids := make(map[string]bool)
+	for _, profile := range getBuiltinProfiles() {
+		assert.Nil(t, profile.check())
+		assert.False(t, ids[profile.Id])
+		ids[profile.Id] = true
+	}
+	assert.True(t, ids[profileIdDefault])
+}
+
+func Test_userProfiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keybinding-profiles")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	profile := &Profile{
+		Id:   "my-profile",
+		Name: "My profile",
+		Shortcuts: []ShortcutData{
+			sysShortcut("terminal", "<Control><Alt>T"),
+		},
+	}
+	assert.Nil(t, saveUserProfile(dir, profile))
+
+	// 无效的方案和与内置方案同名的方案被忽略
+	err = ioutil.WriteFile(filepath.Join(dir, "bad.json"), []byte("{"), 0644)
+	assert.Nil(t, err)
+	err = ioutil.WriteFile(filepath.Join(dir, "gnome.json"), []byte(`{"Id":"gnome"}`), 0644)
+	assert.Nil(t, err)
+
+	profiles := loadUserProfiles(dir)
+	assert.Len(t, profiles, 1)
+	assert.Equal(t, profile, profiles[0])
+
+	assert.NotNil(t, saveUserProfile(dir, &Profile{Id: "../x"}))
+	assert.NotNil(t, saveUserProfile(dir, &Profile{
+		Id: "custom",
+		Shortcuts: []ShortcutData{
+			{Id: "abc", Type: shortcuts.ShortcutTypeCustom},
+		},
+	}))
+
+	assert.Nil(t, deleteUserProfile(dir, "my-profile"))
+	assert.Len(t, loadUserProfiles(dir), 0)
+}
+
+func Test_currentProfileId(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keybinding-profiles")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "sub", "profile.json")
+	assert.Equal(t, profileIdDefault, loadCurrentProfileId(filename))
+	assert.Nil(t, saveCurrentProfileId(filename, profileIdMacOS))
+	assert.Equal(t, profileIdMacOS, loadCurrentProfileId(filename))
+}
+
+func Test_parseShortcutsData(t *testing.T) {
+	data, err := parseShortcutsData(`{"Version":1,"Shortcuts":[{"Id":"terminal","Type":0,"Keystrokes":["<Control><Alt>T"]}],
+"Custom":[{"Name":"term","ActionType":"Exec","ActionArgs":{"Cmd":"deepin-terminal"},"Keystrokes":["<Super>T"]}]}`)
+	assert.Nil(t, err)
+	assert.Len(t, data.Shortcuts, 1)
+	assert.Equal(t, "deepin-terminal", data.Custom[0].ActionArgs.Cmd)
+
+	_, err = parseShortcutsData(`{"Version":100}`)
+	assert.Equal(t, errShortcutsDataVersion, err)
+
+	_, err = parseShortcutsData(`{"Version":1,"Custom":[{"Name":" "}]}`)
+	assert.NotNil(t, err)
+
+	var conflicts []ShortcutConflict
+	keystrokes := parseKeystrokes("terminal", shortcuts.ShortcutTypeSystem,
+		[]string{"<Control><Alt>T", "<Control><"}, &conflicts)
+	assert.Len(t, keystrokes, 1)
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, "<Control><", conflicts[0].Keystroke)
+}
@@ -0,0 +1,155 @@
+package shortcuts
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+const (
+	// 只在列出的应用是焦点窗口时生效
+	AppScopeModeInclude = "include"
+	// 列出的应用是焦点窗口时不生效
+	AppScopeModeExclude = "exclude"
+)
+
+// AppScope 限定快捷键生效的应用。
+// Apps 中的每一项与焦点窗口的 desktop id(与任务栏识别的结果一致，例如 deepin-terminal)
+// 或者 WM_CLASS 的 instance、class 进行比较，不区分大小写。
+type AppScope struct {
+	Mode string
+	Apps []string
+}
+
+// ActiveApp 是当前焦点窗口所属的应用
+type ActiveApp struct {
+	DesktopId  string
+	WMInstance string
+	WMClass    string
+}
+
+// AppScopedShortcut 是可以限定生效应用的快捷键
+type AppScopedShortcut interface {
+	Shortcut
+	GetAppScope() *AppScope
+	setAppScope(*AppScope)
+}
+
+var errAppScopeEmpty = errors.New("apps of app scope is empty")
+
+// ParseAppScope 解析 JSON 格式的 AppScope，str 为空时返回 nil，表示不限定应用。
+func ParseAppScope(str string) (*AppScope, error) {
+	if strings.TrimSpace(str) == "" {
+		return nil, nil
+	}
+	var scope AppScope
+	err := json.Unmarshal([]byte(str), &scope)
+	if err != nil {
+		return nil, err
+	}
+	err = scope.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &scope, nil
+}
+
+func (s *AppScope) Validate() error {
+	switch s.Mode {
+	case AppScopeModeInclude, AppScopeModeExclude:
+	default:
+		return errors.New("invalid app scope mode " + s.Mode)
+	}
+	apps := s.Apps[:0]
+	for _, app := range s.Apps {
+		app = strings.TrimSpace(app)
+		if app != "" {
+			apps = append(apps, app)
+		}
+	}
+	s.Apps = apps
+	if len(s.Apps) == 0 {
+		return errAppScopeEmpty
+	}
+	return nil
+}
+
+func (s *AppScope) containsApp(app *ActiveApp) bool {
+	if app == nil {
+		return false
+	}
+	for _, item := range s.Apps {
+		item = strings.TrimSuffix(item, ".desktop")
+		if app.DesktopId != "" && strings.EqualFold(item, app.DesktopId) {
+			return true
+		}
+		if app.WMInstance != "" && strings.EqualFold(item, app.WMInstance) {
+			return true
+		}
+		if app.WMClass != "" && strings.EqualFold(item, app.WMClass) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match 返回快捷键在焦点应用为 app 时是否生效，app 为 nil 表示没有焦点窗口。
+func (s *AppScope) Match(app *ActiveApp) bool {
+	if s == nil {
+		return true
+	}
+	if s.Mode == AppScopeModeExclude {
+		return !s.containsApp(app)
+	}
+	return s.containsApp(app)
+}
+
+func (s *AppScope) hasApp(app string) bool {
+	app = strings.TrimSuffix(app, ".desktop")
+	for _, item := range s.Apps {
+		if strings.EqualFold(strings.TrimSuffix(item, ".desktop"), app) {
+			return true
+		}
+	}
+	return false
+}
+
+// Overlaps 返回限定了这两个范围的快捷键是否可能同时生效，nil 表示不限定应用。
+// 只按 Apps 中的名字比较，同一个应用用 desktop id 和 WM_CLASS 表示时认为是不同的应用。
+func (s *AppScope) Overlaps(other *AppScope) bool {
+	if s == nil || other == nil {
+		return true
+	}
+	if s.Mode == AppScopeModeExclude && other.Mode == AppScopeModeExclude {
+		return true
+	}
+	include, other0 := s, other
+	if include.Mode == AppScopeModeExclude {
+		include, other0 = other, s
+	}
+	for _, app := range include.Apps {
+		if other0.hasApp(app) == (other0.Mode == AppScopeModeInclude) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *AppScope) String() string {
+	if s == nil {
+		return ""
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func getShortcutAppScope(shortcut Shortcut) *AppScope {
+	scoped, ok := shortcut.(AppScopedShortcut)
+	if !ok {
+		return nil
+	}
+	return scoped.GetAppScope()
+}
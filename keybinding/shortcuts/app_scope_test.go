@@ -0,0 +1,82 @@
+package shortcuts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAppScope(t *testing.T) {
+	scope, err := ParseAppScope("")
+	assert.Nil(t, err)
+	assert.Nil(t, scope)
+
+	scope, err = ParseAppScope(`{"Mode":"include","Apps":["deepin-terminal", " "]}`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"deepin-terminal"}, scope.Apps)
+
+	_, err = ParseAppScope(`{"Mode":"only","Apps":["deepin-terminal"]}`)
+	assert.NotNil(t, err)
+
+	_, err = ParseAppScope(`{"Mode":"exclude","Apps":[]}`)
+	assert.Equal(t, errAppScopeEmpty, err)
+
+	_, err = ParseAppScope(`{"Mode":`)
+	assert.NotNil(t, err)
+}
+
+func TestAppScopeMatch(t *testing.T) {
+	terminal := &ActiveApp{
+		DesktopId:  "deepin-terminal",
+		WMInstance: "deepin-terminal",
+		WMClass:    "Deepin-terminal",
+	}
+	chrome := &ActiveApp{
+		DesktopId:  "google-chrome",
+		WMInstance: "google-chrome",
+		WMClass:    "Google-chrome",
+	}
+
+	include := &AppScope{Mode: AppScopeModeInclude, Apps: []string{"deepin-terminal.desktop"}}
+	assert.True(t, include.Match(terminal))
+	assert.False(t, include.Match(chrome))
+	assert.False(t, include.Match(nil))
+
+	// 按 WM_CLASS 匹配，不区分大小写
+	exclude := &AppScope{Mode: AppScopeModeExclude, Apps: []string{"google-CHROME"}}
+	assert.False(t, exclude.Match(chrome))
+	assert.True(t, exclude.Match(terminal))
+	assert.True(t, exclude.Match(nil))
+
+	var noScope *AppScope
+	assert.True(t, noScope.Match(chrome))
+	assert.Equal(t, "", noScope.String())
+}
+
+func TestAppScopeOverlaps(t *testing.T) {
+	terminal := &AppScope{Mode: AppScopeModeInclude, Apps: []string{"deepin-terminal.desktop"}}
+	terminal2 := &AppScope{Mode: AppScopeModeInclude, Apps: []string{"Deepin-Terminal", "dde-file-manager"}}
+	chrome := &AppScope{Mode: AppScopeModeInclude, Apps: []string{"google-chrome"}}
+	notTerminal := &AppScope{Mode: AppScopeModeExclude, Apps: []string{"deepin-terminal"}}
+	notChrome := &AppScope{Mode: AppScopeModeExclude, Apps: []string{"google-chrome"}}
+	var global *AppScope
+
+	tests := []struct {
+		a, b *AppScope
+		want bool
+	}{
+		{global, global, true},
+		{global, terminal, true},
+		{notChrome, global, true},
+		{terminal, terminal2, true},
+		{terminal, chrome, false},
+		{terminal, notTerminal, false},
+		{terminal2, notTerminal, true},
+		{chrome, notTerminal, true},
+		{notTerminal, notChrome, true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.a.Overlaps(tt.b), "%v %v", tt.a, tt.b)
+		assert.Equal(t, tt.want, tt.b.Overlaps(tt.a), "%v %v", tt.b, tt.a)
+	}
+}
@@ -0,0 +1,283 @@
+package shortcuts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"pkg.deepin.io/lib/appinfo/desktopappinfo"
+)
+
+// 自定义快捷键支持的动作类型
+const (
+	CustomActionTypeExec            = "Exec"
+	CustomActionTypeOpenMimeType    = "OpenMimeType"
+	CustomActionTypeDesktopFile     = "DesktopFile"
+	CustomActionTypeAudioCtrl       = "AudioCtrl"
+	CustomActionTypeDisplayCtrl     = "DisplayCtrl"
+	CustomActionTypeMediaPlayerCtrl = "MediaPlayerCtrl"
+	CustomActionTypeKbdLightCtrl    = "KbdLightCtrl"
+)
+
+// CustomActionArgs 是自定义快捷键动作的参数，不同的动作类型使用不同的字段。
+type CustomActionArgs struct {
+	// Exec 类型为命令行，各种 Ctrl 类型为控制命令名称
+	Cmd string `json:",omitempty"`
+	// OpenMimeType 类型使用，例如 x-scheme-handler/mailto
+	MimeType string `json:",omitempty"`
+	// DesktopFile 类型使用，desktop 文件路径或 id，以及可选的 desktop action
+	DesktopFile   string `json:",omitempty"`
+	DesktopAction string `json:",omitempty"`
+	// MediaPlayerCtrl 类型使用，可选的 MPRIS 播放器名称，例如 spotify
+	Player string `json:",omitempty"`
+}
+
+type CustomAction struct {
+	Type string
+	Args CustomActionArgs
+}
+
+// 运行 desktop 文件的参数
+type ActionDesktopFileArg struct {
+	DesktopFile string
+	Action      string
+}
+
+// 控制指定 MPRIS 播放器的参数，Player 为空时控制当前活跃的播放器
+type ActionMediaPlayerCtrlArg struct {
+	Cmd    ActionCmd
+	Player string
+}
+
+type ErrInvalidCustomAction struct {
+	Type   string
+	Reason string
+}
+
+func (err ErrInvalidCustomAction) Error() string {
+	return fmt.Sprintf("invalid custom action %q: %s", err.Type, err.Reason)
+}
+
+var customActionCmds = map[string]map[string]ActionCmd{
+	CustomActionTypeAudioCtrl: {
+		"SinkMuteToggle":   AudioSinkMuteToggle,
+		"SinkVolumeUp":     AudioSinkVolumeUp,
+		"SinkVolumeDown":   AudioSinkVolumeDown,
+		"SourceMuteToggle": AudioSourceMuteToggle,
+	},
+	CustomActionTypeDisplayCtrl: {
+		"BrightnessUp":           MonitorBrightnessUp,
+		"BrightnessDown":         MonitorBrightnessDown,
+		"ModeSwitch":             DisplayModeSwitch,
+		"AdjustBrightnessSwitch": AdjustBrightnessSwitch,
+	},
+	CustomActionTypeMediaPlayerCtrl: {
+		"PlayPause": MediaPlayerPlay,
+		"Pause":     MediaPlayerPause,
+		"Stop":      MediaPlayerStop,
+		"Previous":  MediaPlayerPrevious,
+		"Next":      MediaPlayerNext,
+		"Rewind":    MediaPlayerRewind,
+		"Forward":   MediaPlayerForword,
+		"Repeat":    MediaPlayerRepeat,
+	},
+	CustomActionTypeKbdLightCtrl: {
+		"Toggle":         KbdLightToggle,
+		"BrightnessUp":   KbdLightBrightnessUp,
+		"BrightnessDown": KbdLightBrightnessDown,
+	},
+}
+
+// CustomActionTypeInfo 描述一种动作类型，用于前端展示可选的动作。
+type CustomActionTypeInfo struct {
+	Type string
+	Cmds []string `json:",omitempty"`
+}
+
+func ListCustomActionTypes() []CustomActionTypeInfo {
+	types := []string{
+		CustomActionTypeExec,
+		CustomActionTypeOpenMimeType,
+		CustomActionTypeDesktopFile,
+		CustomActionTypeAudioCtrl,
+		CustomActionTypeDisplayCtrl,
+		CustomActionTypeMediaPlayerCtrl,
+		CustomActionTypeKbdLightCtrl,
+	}
+	result := make([]CustomActionTypeInfo, 0, len(types))
+	for _, type0 := range types {
+		info := CustomActionTypeInfo{Type: type0}
+		for cmd := range customActionCmds[type0] {
+			info.Cmds = append(info.Cmds, cmd)
+		}
+		sort.Strings(info.Cmds)
+		result = append(result, info)
+	}
+	return result
+}
+
+// ParseCustomAction 从动作类型和 JSON 格式的参数创建 CustomAction，并检查其是否有效。
+func ParseCustomAction(type0, argsJSON string) (*CustomAction, error) {
+	ca := &CustomAction{Type: type0}
+	if argsJSON != "" {
+		err := json.Unmarshal([]byte(argsJSON), &ca.Args)
+		if err != nil {
+			return nil, ErrInvalidCustomAction{type0, err.Error()}
+		}
+	}
+	err := ca.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return ca, nil
+}
+
+func NewExecCustomAction(cmd string) *CustomAction {
+	return &CustomAction{
+		Type: CustomActionTypeExec,
+		Args: CustomActionArgs{Cmd: cmd},
+	}
+}
+
+func (ca *CustomAction) IsExec() bool {
+	return ca == nil || ca.Type == "" || ca.Type == CustomActionTypeExec
+}
+
+func (ca *CustomAction) getCmd() (ActionCmd, error) {
+	cmds := customActionCmds[ca.Type]
+	cmd, ok := cmds[ca.Args.Cmd]
+	if !ok {
+		return 0, ErrInvalidCustomAction{ca.Type, fmt.Sprintf("unknown cmd %q", ca.Args.Cmd)}
+	}
+	return cmd, nil
+}
+
+func (ca *CustomAction) Validate() error {
+	switch ca.Type {
+	case CustomActionTypeExec:
+		if strings.TrimSpace(ca.Args.Cmd) == "" {
+			return ErrInvalidCustomAction{ca.Type, "cmd is empty"}
+		}
+
+	case CustomActionTypeOpenMimeType:
+		if !strings.Contains(ca.Args.MimeType, "/") {
+			return ErrInvalidCustomAction{ca.Type, fmt.Sprintf("bad mime type %q", ca.Args.MimeType)}
+		}
+
+	case CustomActionTypeDesktopFile:
+		return ca.validateDesktopFile()
+
+	case CustomActionTypeAudioCtrl, CustomActionTypeDisplayCtrl,
+		CustomActionTypeMediaPlayerCtrl, CustomActionTypeKbdLightCtrl:
+		_, err := ca.getCmd()
+		return err
+
+	default:
+		return ErrInvalidCustomAction{ca.Type, "unknown action type"}
+	}
+	return nil
+}
+
+func getDesktopAppInfo(desktopFile string) *desktopappinfo.DesktopAppInfo {
+	if strings.HasPrefix(desktopFile, "/") {
+		ai, err := desktopappinfo.NewDesktopAppInfoFromFile(desktopFile)
+		if err != nil {
+			return nil
+		}
+		return ai
+	}
+	return desktopappinfo.NewDesktopAppInfo(desktopFile)
+}
+
+func (ca *CustomAction) validateDesktopFile() error {
+	desktopFile := ca.Args.DesktopFile
+	if desktopFile == "" {
+		return ErrInvalidCustomAction{ca.Type, "desktop file is empty"}
+	}
+	ai := getDesktopAppInfo(desktopFile)
+	if ai == nil {
+		return ErrInvalidCustomAction{ca.Type, fmt.Sprintf("desktop file %q not found", desktopFile)}
+	}
+	if ca.Args.DesktopAction == "" {
+		return nil
+	}
+	for _, action := range ai.GetActions() {
+		if action.Section == ca.Args.DesktopAction {
+			return nil
+		}
+	}
+	return ErrInvalidCustomAction{ca.Type,
+		fmt.Sprintf("desktop action %q not found in %q", ca.Args.DesktopAction, desktopFile)}
+}
+
+// toAction 转换为快捷键管理器可处理的 Action
+func (ca *CustomAction) toAction() (*Action, error) {
+	switch ca.Type {
+	case CustomActionTypeExec:
+		return NewExecCmdAction(ca.Args.Cmd, false), nil
+
+	case CustomActionTypeOpenMimeType:
+		return NewOpenMimeTypeAction(ca.Args.MimeType), nil
+
+	case CustomActionTypeDesktopFile:
+		desktopFile := ca.Args.DesktopFile
+		if !strings.HasPrefix(desktopFile, "/") {
+			ai := getDesktopAppInfo(desktopFile)
+			if ai == nil {
+				return nil, ErrInvalidCustomAction{ca.Type,
+					fmt.Sprintf("desktop file %q not found", desktopFile)}
+			}
+			desktopFile = ai.GetFileName()
+		} else if _, err := os.Stat(desktopFile); err != nil {
+			return nil, err
+		}
+		return &Action{
+			Type: ActionTypeDesktopFile,
+			Arg: &ActionDesktopFileArg{
+				DesktopFile: desktopFile,
+				Action:      ca.Args.DesktopAction,
+			},
+		}, nil
+
+	case CustomActionTypeAudioCtrl:
+		cmd, err := ca.getCmd()
+		if err != nil {
+			return nil, err
+		}
+		return NewAudioCtrlAction(cmd), nil
+
+	case CustomActionTypeDisplayCtrl:
+		cmd, err := ca.getCmd()
+		if err != nil {
+			return nil, err
+		}
+		return NewDisplayCtrlAction(cmd), nil
+
+	case CustomActionTypeKbdLightCtrl:
+		cmd, err := ca.getCmd()
+		if err != nil {
+			return nil, err
+		}
+		return NewKbdBrightnessCtrlAction(cmd), nil
+
+	case CustomActionTypeMediaPlayerCtrl:
+		cmd, err := ca.getCmd()
+		if err != nil {
+			return nil, err
+		}
+		if ca.Args.Player == "" {
+			return NewMediaPlayerCtrlAction(cmd), nil
+		}
+		return &Action{
+			Type: ActionTypeMediaPlayerCtrl,
+			Arg: &ActionMediaPlayerCtrlArg{
+				Cmd:    cmd,
+				Player: ca.Args.Player,
+			},
+		}, nil
+	}
+	return nil, errors.New("unknown action type " + ca.Type)
+}
@@ -0,0 +1,73 @@
+package shortcuts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCustomAction(t *testing.T) {
+	ca, err := ParseCustomAction(CustomActionTypeExec, `{"Cmd":"deepin-terminal"}`)
+	assert.Nil(t, err)
+	assert.True(t, ca.IsExec())
+	assert.Equal(t, "deepin-terminal", ca.Args.Cmd)
+
+	_, err = ParseCustomAction(CustomActionTypeExec, `{"Cmd":" "}`)
+	assert.NotNil(t, err)
+
+	_, err = ParseCustomAction("NoSuchType", "")
+	assert.NotNil(t, err)
+
+	_, err = ParseCustomAction(CustomActionTypeAudioCtrl, `{"Cmd":`)
+	assert.NotNil(t, err)
+
+	_, err = ParseCustomAction(CustomActionTypeAudioCtrl, `{"Cmd":"PlayPause"}`)
+	assert.NotNil(t, err)
+
+	_, err = ParseCustomAction(CustomActionTypeOpenMimeType, `{"MimeType":"mailto"}`)
+	assert.NotNil(t, err)
+
+	_, err = ParseCustomAction(CustomActionTypeDesktopFile, `{}`)
+	assert.NotNil(t, err)
+}
+
+func TestCustomActionToAction(t *testing.T) {
+	ca, err := ParseCustomAction(CustomActionTypeOpenMimeType,
+		`{"MimeType":"x-scheme-handler/mailto"}`)
+	assert.Nil(t, err)
+	action, err := ca.toAction()
+	assert.Nil(t, err)
+	assert.Equal(t, ActionTypeOpenMimeType, action.Type)
+	assert.Equal(t, "x-scheme-handler/mailto", action.Arg)
+
+	ca, err = ParseCustomAction(CustomActionTypeKbdLightCtrl, `{"Cmd":"Toggle"}`)
+	assert.Nil(t, err)
+	action, err = ca.toAction()
+	assert.Nil(t, err)
+	assert.Equal(t, ActionTypeKbdLightCtrl, action.Type)
+	assert.Equal(t, KbdLightToggle, action.Arg)
+
+	ca, err = ParseCustomAction(CustomActionTypeMediaPlayerCtrl, `{"Cmd":"PlayPause"}`)
+	assert.Nil(t, err)
+	action, err = ca.toAction()
+	assert.Nil(t, err)
+	assert.Equal(t, MediaPlayerPlay, action.Arg)
+
+	ca, err = ParseCustomAction(CustomActionTypeMediaPlayerCtrl,
+		`{"Cmd":"PlayPause","Player":"spotify"}`)
+	assert.Nil(t, err)
+	action, err = ca.toAction()
+	assert.Nil(t, err)
+	assert.Equal(t, ActionTypeMediaPlayerCtrl, action.Type)
+	assert.Equal(t, &ActionMediaPlayerCtrlArg{Cmd: MediaPlayerPlay, Player: "spotify"}, action.Arg)
+}
+
+func TestListCustomActionTypes(t *testing.T) {
+	types := ListCustomActionTypes()
+	assert.Len(t, types, 7)
+	for _, info := range types {
+		if info.Type == CustomActionTypeMediaPlayerCtrl {
+			assert.Contains(t, info.Cmds, "PlayPause")
+		}
+	}
+}
@@ -20,6 +20,7 @@
 package shortcuts
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -33,12 +34,22 @@ const (
 	kfKeyName       = "Name"
 	kfKeyKeystrokes = "Accels"
 	kfKeyAction     = "Action"
+	kfKeyActionType = "ActionType"
+	kfKeyActionArgs = "ActionArgs"
+	kfKeySequences  = "Sequences"
+	kfKeyAppScope   = "AppScope"
 )
 
 type CustomShortcut struct {
 	BaseShortcut
 	manager *CustomShortcutManager
 	Cmd     string `json:"Exec"`
+	// 非 Exec 类型的动作，为空时执行 Cmd
+	ActionType string            `json:",omitempty"`
+	ActionArgs *CustomActionArgs `json:",omitempty"`
+	Sequences  []*KeySequence    `json:",omitempty"`
+	// 为空时在所有应用中生效
+	AppScope *AppScope `json:",omitempty"`
 }
 
 func (cs *CustomShortcut) Marshal() (string, error) {
@@ -51,9 +62,34 @@ func (cs *CustomShortcut) SaveKeystrokes() error {
 	section := cs.GetId()
 	csm := cs.manager
 	csm.kfile.SetStringList(section, kfKeyKeystrokes, cs.getKeystrokesStrv())
+	setKeySequencesToKeyFile(csm.kfile, section, cs.GetKeySequences())
 	return csm.Save()
 }
 
+func (cs *CustomShortcut) GetKeySequences() []*KeySequence {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.Sequences
+}
+
+func (cs *CustomShortcut) setKeySequences(val []*KeySequence) {
+	cs.mu.Lock()
+	cs.Sequences = val
+	cs.mu.Unlock()
+}
+
+func (cs *CustomShortcut) GetAppScope() *AppScope {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.AppScope
+}
+
+func (cs *CustomShortcut) setAppScope(val *AppScope) {
+	cs.mu.Lock()
+	cs.AppScope = val
+	cs.mu.Unlock()
+}
+
 // 经过 Reset 重置后， 自定义快捷键的 keystrokes 被设置为空，始终返回 false
 // 是为了另外计算改变的自定义快捷键项目。
 func (cs *CustomShortcut) ReloadKeystrokes() bool {
@@ -70,11 +106,48 @@ func (cs *CustomShortcut) Save() error {
 	kfile := cs.manager.kfile
 	kfile.SetString(section, kfKeyName, cs.Name)
 	kfile.SetString(section, kfKeyAction, cs.Cmd)
+	setCustomActionToKeyFile(kfile, section, cs.GetCustomAction())
 	kfile.SetStringList(section, kfKeyKeystrokes, cs.getKeystrokesStrv())
+	setKeySequencesToKeyFile(kfile, section, cs.GetKeySequences())
+	setAppScopeToKeyFile(kfile, section, cs.GetAppScope())
 	return cs.manager.Save()
 }
 
+// GetCustomAction 返回快捷键的动作，旧配置中只有 Cmd 的快捷键返回 Exec 类型的动作。
+func (cs *CustomShortcut) GetCustomAction() *CustomAction {
+	if cs.ActionType == "" || cs.ActionArgs == nil {
+		return NewExecCustomAction(cs.Cmd)
+	}
+	return &CustomAction{
+		Type: cs.ActionType,
+		Args: *cs.ActionArgs,
+	}
+}
+
+// SetCustomAction 修改快捷键的动作，Exec 类型的动作保存在 Cmd 中以兼容旧的配置。
+func (cs *CustomShortcut) SetCustomAction(action *CustomAction) {
+	if action.IsExec() {
+		cs.Cmd = action.Args.Cmd
+		cs.ActionType = ""
+		cs.ActionArgs = nil
+		return
+	}
+	cs.Cmd = ""
+	cs.ActionType = action.Type
+	args := action.Args
+	cs.ActionArgs = &args
+}
+
 func (cs *CustomShortcut) GetAction() *Action {
+	if cs.ActionType != "" {
+		action, err := cs.GetCustomAction().toAction()
+		if err != nil {
+			logger.Warningf("custom shortcut %q get action failed: %v", cs.Id, err)
+			return ActionNoOp
+		}
+		return action
+	}
+
 	_, err := os.Stat(cs.Cmd)
 	if !os.IsNotExist(err) {
 		if strings.HasSuffix(cs.Cmd, ".desktop") {
@@ -131,6 +204,8 @@ func (csm *CustomShortcutManager) List() []Shortcut {
 		name, _ := kfile.GetString(section, kfKeyName)
 		cmd, _ := kfile.GetString(section, kfKeyAction)
 		keystrokes, _ := kfile.GetStringList(section, kfKeyKeystrokes)
+		customAction := getCustomActionFromKeyFile(kfile, section)
+		sequences, _ := kfile.GetStringList(section, kfKeySequences)
 
 		shortcut := &CustomShortcut{
 			BaseShortcut: BaseShortcut{
@@ -139,8 +214,13 @@ func (csm *CustomShortcutManager) List() []Shortcut {
 				Keystrokes: ParseKeystrokes(keystrokes),
 				Name:       name,
 			},
-			manager: csm,
-			Cmd:     cmd,
+			manager:   csm,
+			Cmd:       cmd,
+			Sequences: ParseKeySequences(sequences),
+			AppScope:  getAppScopeFromKeyFile(kfile, section),
+		}
+		if customAction != nil {
+			shortcut.SetCustomAction(customAction)
 		}
 
 		ret = append(ret, shortcut)
@@ -157,16 +237,12 @@ func (csm *CustomShortcutManager) Save() error {
 }
 
 func (csm *CustomShortcutManager) Add(name, action string, keystrokes []*Keystroke) (Shortcut, error) {
-	id := dutils.GenUuid()
-	csm.kfile.SetString(id, kfKeyName, name)
-	csm.kfile.SetString(id, kfKeyAction, action)
-
-	keystrokesStrv := make([]string, 0, len(keystrokes))
-	for _, ks := range keystrokes {
-		keystrokesStrv = append(keystrokesStrv, ks.String())
-	}
-	csm.kfile.SetStringList(id, kfKeyKeystrokes, keystrokesStrv)
+	return csm.AddWithAction(name, NewExecCustomAction(action), keystrokes)
+}
 
+func (csm *CustomShortcutManager) AddWithAction(name string, action *CustomAction,
+	keystrokes []*Keystroke) (Shortcut, error) {
+	id := dutils.GenUuid()
 	shortcut := &CustomShortcut{
 		BaseShortcut: BaseShortcut{
 			Id:         id,
@@ -175,8 +251,18 @@ func (csm *CustomShortcutManager) Add(name, action string, keystrokes []*Keystro
 			Name:       name,
 		},
 		manager: csm,
-		Cmd:     action,
 	}
+	shortcut.SetCustomAction(action)
+
+	csm.kfile.SetString(id, kfKeyName, name)
+	csm.kfile.SetString(id, kfKeyAction, shortcut.Cmd)
+	setCustomActionToKeyFile(csm.kfile, id, action)
+
+	keystrokesStrv := make([]string, 0, len(keystrokes))
+	for _, ks := range keystrokes {
+		keystrokesStrv = append(keystrokesStrv, ks.String())
+	}
+	csm.kfile.SetStringList(id, kfKeyKeystrokes, keystrokesStrv)
 	return shortcut, csm.Save()
 }
 
@@ -188,3 +274,63 @@ func (csm *CustomShortcutManager) Delete(id string) error {
 	csm.kfile.DeleteSection(id)
 	return csm.Save()
 }
+
+func getCustomActionFromKeyFile(kfile *keyfile.KeyFile, section string) *CustomAction {
+	type0, _ := kfile.GetString(section, kfKeyActionType)
+	if type0 == "" {
+		return nil
+	}
+	argsJSON, _ := kfile.GetString(section, kfKeyActionArgs)
+	action := &CustomAction{Type: type0}
+	err := json.Unmarshal([]byte(argsJSON), &action.Args)
+	if err != nil {
+		logger.Warningf("failed to parse action args of custom shortcut %q: %v", section, err)
+		return nil
+	}
+	return action
+}
+
+func setCustomActionToKeyFile(kfile *keyfile.KeyFile, section string, action *CustomAction) {
+	if action.IsExec() {
+		_ = kfile.DeleteKey(section, kfKeyActionType)
+		_ = kfile.DeleteKey(section, kfKeyActionArgs)
+		return
+	}
+	argsJSON, err := json.Marshal(&action.Args)
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+	kfile.SetString(section, kfKeyActionType, action.Type)
+	kfile.SetString(section, kfKeyActionArgs, string(argsJSON))
+}
+
+func setKeySequencesToKeyFile(kfile *keyfile.KeyFile, section string, sequences []*KeySequence) {
+	if len(sequences) == 0 {
+		_ = kfile.DeleteKey(section, kfKeySequences)
+		return
+	}
+	strv := make([]string, len(sequences))
+	for i, seq := range sequences {
+		strv[i] = seq.String()
+	}
+	kfile.SetStringList(section, kfKeySequences, strv)
+}
+
+func getAppScopeFromKeyFile(kfile *keyfile.KeyFile, section string) *AppScope {
+	str, _ := kfile.GetString(section, kfKeyAppScope)
+	scope, err := ParseAppScope(str)
+	if err != nil {
+		logger.Warningf("failed to parse app scope of custom shortcut %q: %v", section, err)
+		return nil
+	}
+	return scope
+}
+
+func setAppScopeToKeyFile(kfile *keyfile.KeyFile, section string, scope *AppScope) {
+	if scope == nil {
+		_ = kfile.DeleteKey(section, kfKeyAppScope)
+		return
+	}
+	kfile.SetString(section, kfKeyAppScope, scope.String())
+}
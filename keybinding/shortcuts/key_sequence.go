@@ -0,0 +1,321 @@
+package shortcuts
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/linuxdeepin/go-x11-client/util/keysyms"
+)
+
+const (
+	keySequenceSep         = ","
+	keySequenceMaxLen      = 4
+	modGestureTapSuffix    = "@tap"
+	modGestureHoldSuffix   = "@hold"
+	DefaultSequenceTimeout = 1000 * time.Millisecond
+	DefaultModHoldDuration = 500 * time.Millisecond
+)
+
+type ModGesture uint
+
+const (
+	ModGestureNone ModGesture = iota
+	// 单独按下并快速释放修饰键
+	ModGestureTap
+	// 单独按住修饰键超过一定时间后释放
+	ModGestureHold
+)
+
+// KeySequence 是按顺序按下的多个按键组合，例如 "<Super>W,H" 表示先按 Super+W，再按 H。
+// 也可以表示单个修饰键的轻按或长按，例如 "Control_L@tap"、"Super_L@hold"。
+type KeySequence struct {
+	Keystrokes []*Keystroke
+	Gesture    ModGesture
+	Shortcut   Shortcut
+}
+
+var errKeySequenceEmpty = errors.New("key sequence is empty")
+
+func ParseKeySequence(str string) (*KeySequence, error) {
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return nil, errKeySequenceEmpty
+	}
+
+	gesture := ModGestureNone
+	if strings.HasSuffix(str, modGestureTapSuffix) {
+		gesture = ModGestureTap
+		str = strings.TrimSuffix(str, modGestureTapSuffix)
+	} else if strings.HasSuffix(str, modGestureHoldSuffix) {
+		gesture = ModGestureHold
+		str = strings.TrimSuffix(str, modGestureHoldSuffix)
+	}
+
+	parts := strings.Split(str, keySequenceSep)
+	if len(parts) > keySequenceMaxLen {
+		return nil, errors.New("key sequence is too long")
+	}
+	seq := &KeySequence{
+		Gesture:    gesture,
+		Keystrokes: make([]*Keystroke, 0, len(parts)),
+	}
+	for _, part := range parts {
+		ks, err := ParseKeystroke(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		seq.Keystrokes = append(seq.Keystrokes, ks)
+	}
+
+	if gesture != ModGestureNone {
+		if len(seq.Keystrokes) != 1 {
+			return nil, errors.New("modifier gesture must be a single key")
+		}
+		ks := seq.Keystrokes[0]
+		if ks.Mods != 0 || !keysyms.IsModifierKey(ks.Keysym) {
+			return nil, errors.New("modifier gesture must be a modifier key without modifiers")
+		}
+	} else if len(seq.Keystrokes) < 2 {
+		return nil, errors.New("key sequence must contain at least two keystrokes")
+	}
+	return seq, nil
+}
+
+func ParseKeySequences(list []string) []*KeySequence {
+	result := make([]*KeySequence, 0, len(list))
+	for _, str := range list {
+		seq, err := ParseKeySequence(str)
+		if err != nil {
+			logger.Warningf("failed to parse key sequence %q: %v", str, err)
+			continue
+		}
+		result = append(result, seq)
+	}
+	return result
+}
+
+func (seq *KeySequence) String() string {
+	strs := make([]string, len(seq.Keystrokes))
+	for i, ks := range seq.Keystrokes {
+		strs[i] = ks.String()
+	}
+	str := strings.Join(strs, keySequenceSep)
+	switch seq.Gesture {
+	case ModGestureTap:
+		str += modGestureTapSuffix
+	case ModGestureHold:
+		str += modGestureHoldSuffix
+	}
+	return str
+}
+
+func (seq *KeySequence) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(seq.String())), nil
+}
+
+func (seq *KeySequence) UnmarshalJSON(data []byte) error {
+	var str string
+	err := json.Unmarshal(data, &str)
+	if err != nil {
+		return err
+	}
+	seq0, err := ParseKeySequence(str)
+	if err != nil {
+		return err
+	}
+	*seq = *seq0
+	return nil
+}
+
+func (seq *KeySequence) IsModGesture() bool {
+	return seq.Gesture != ModGestureNone
+}
+
+// modGestureKey 返回用于查找修饰键手势的键，例如 "super_l@hold"
+func (seq *KeySequence) modGestureKey() string {
+	return modGestureKey(seq.Keystrokes[0].Keystr, seq.Gesture)
+}
+
+func modGestureKey(keystr string, gesture ModGesture) string {
+	keystr = strings.ToLower(keystr)
+	if gesture == ModGestureHold {
+		return keystr + modGestureHoldSuffix
+	}
+	return keystr + modGestureTapSuffix
+}
+
+func (seq *KeySequence) Equal(keySymbols *keysyms.KeySymbols, other *KeySequence) bool {
+	if seq.Gesture != other.Gesture || len(seq.Keystrokes) != len(other.Keystrokes) {
+		return false
+	}
+	for i, ks := range seq.Keystrokes {
+		if !ks.Equal(keySymbols, other.Keystrokes[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (seq *KeySequence) toKeySteps(keySymbols *keysyms.KeySymbols) ([][]Key, error) {
+	steps := make([][]Key, len(seq.Keystrokes))
+	for i, ks := range seq.Keystrokes {
+		keyList, err := ks.ToKeyList(keySymbols)
+		if err != nil {
+			return nil, err
+		}
+		if len(keyList) == 0 {
+			return nil, errors.New("no key for keystroke " + ks.String())
+		}
+		steps[i] = keyList
+	}
+	return steps, nil
+}
+
+type seqCandidate struct {
+	seq   *KeySequence
+	steps [][]Key
+}
+
+func (c *seqCandidate) matchStep(idx int, key Key) bool {
+	if idx >= len(c.steps) {
+		return false
+	}
+	for _, k := range c.steps[idx] {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// keySequenceMatcher 负责按键序列的匹配，不涉及 X 的按键抓取。
+type keySequenceMatcher struct {
+	leaders map[Key][]*seqCandidate
+	pending []*seqCandidate
+	pos     int
+}
+
+func newKeySequenceMatcher() *keySequenceMatcher {
+	return &keySequenceMatcher{
+		leaders: make(map[Key][]*seqCandidate),
+	}
+}
+
+// add 返回值 newLeaders 为新增的首个按键，需要进行抓取
+func (m *keySequenceMatcher) add(seq *KeySequence, steps [][]Key) (newLeaders []Key) {
+	c := &seqCandidate{seq: seq, steps: steps}
+	for _, key := range steps[0] {
+		if len(m.leaders[key]) == 0 {
+			newLeaders = append(newLeaders, key)
+		}
+		m.leaders[key] = append(m.leaders[key], c)
+	}
+	return
+}
+
+// remove 返回值 unusedLeaders 为不再使用的首个按键，需要取消抓取
+func (m *keySequenceMatcher) remove(seq *KeySequence) (unusedLeaders []Key) {
+	for key, candidates := range m.leaders {
+		var rest []*seqCandidate
+		for _, c := range candidates {
+			if c.seq != seq {
+				rest = append(rest, c)
+			}
+		}
+		if len(rest) == len(candidates) {
+			continue
+		}
+		if len(rest) == 0 {
+			delete(m.leaders, key)
+			unusedLeaders = append(unusedLeaders, key)
+		} else {
+			m.leaders[key] = rest
+		}
+	}
+	m.reset()
+	return
+}
+
+func (m *keySequenceMatcher) isLeader(key Key) bool {
+	return len(m.leaders[key]) > 0
+}
+
+func (m *keySequenceMatcher) isPending() bool {
+	return len(m.pending) > 0
+}
+
+func (m *keySequenceMatcher) begin(key Key) bool {
+	candidates := m.leaders[key]
+	if len(candidates) == 0 {
+		return false
+	}
+	m.pending = candidates
+	m.pos = 1
+	return true
+}
+
+// feed 处理序列中的后续按键，返回完整匹配的序列，或者是否还需要继续等待按键。
+func (m *keySequenceMatcher) feed(key Key) (matched *KeySequence, pending bool) {
+	var next []*seqCandidate
+	for _, c := range m.pending {
+		if !c.matchStep(m.pos, key) {
+			continue
+		}
+		if m.pos == len(c.steps)-1 {
+			matched = c.seq
+			break
+		}
+		next = append(next, c)
+	}
+
+	if matched != nil || len(next) == 0 {
+		m.reset()
+		return matched, false
+	}
+	m.pending = next
+	m.pos++
+	return nil, true
+}
+
+func (m *keySequenceMatcher) reset() {
+	m.pending = nil
+	m.pos = 0
+}
+
+// findConflict 查找与 steps 冲突的序列，即一方是另一方的前缀(包括相等)。
+func (m *keySequenceMatcher) findConflict(steps [][]Key) *KeySequence {
+	for _, key := range steps[0] {
+		for _, c := range m.leaders[key] {
+			if isKeyStepsPrefix(c.steps, steps) || isKeyStepsPrefix(steps, c.steps) {
+				return c.seq
+			}
+		}
+	}
+	return nil
+}
+
+func isKeyStepsPrefix(prefix, steps [][]Key) bool {
+	if len(prefix) > len(steps) {
+		return false
+	}
+	for i := range prefix {
+		if !isKeyListIntersect(prefix[i], steps[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isKeyListIntersect(a, b []Key) bool {
+	for _, k1 := range a {
+		for _, k2 := range b {
+			if k1 == k2 {
+				return true
+			}
+		}
+	}
+	return false
+}
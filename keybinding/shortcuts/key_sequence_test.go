@@ -0,0 +1,133 @@
+package shortcuts
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeySequence(t *testing.T) {
+	seq, err := ParseKeySequence("<Super>W, H")
+	assert.Nil(t, err)
+	assert.Len(t, seq.Keystrokes, 2)
+	assert.Equal(t, ModGestureNone, seq.Gesture)
+	assert.Equal(t, "<Super>W,H", seq.String())
+
+	seq, err = ParseKeySequence("Super_L@hold")
+	assert.Nil(t, err)
+	assert.Equal(t, ModGestureHold, seq.Gesture)
+	assert.Equal(t, "super_l@hold", seq.modGestureKey())
+
+	seq, err = ParseKeySequence("Control_L@tap")
+	assert.Nil(t, err)
+	assert.True(t, seq.IsModGesture())
+
+	_, err = ParseKeySequence("")
+	assert.NotNil(t, err)
+
+	// 只有一个按键的不是序列
+	_, err = ParseKeySequence("<Super>W")
+	assert.NotNil(t, err)
+
+	_, err = ParseKeySequence("A,B,C,D,E")
+	assert.NotNil(t, err)
+
+	// 手势只能用于单个修饰键
+	_, err = ParseKeySequence("A@tap")
+	assert.NotNil(t, err)
+	_, err = ParseKeySequence("<Control>Super_L@hold")
+	assert.NotNil(t, err)
+
+	assert.True(t, IsKeySequenceString("<Super>W,H"))
+	assert.True(t, IsKeySequenceString("Super_L@tap"))
+	assert.False(t, IsKeySequenceString("<Super>W"))
+}
+
+func TestKeySequenceJSON(t *testing.T) {
+	var seqs []*KeySequence
+	for _, str := range []string{"<Super>W,H", "Super_L@hold", "Control_L@tap"} {
+		seq, err := ParseKeySequence(str)
+		assert.Nil(t, err)
+		seqs = append(seqs, seq)
+	}
+	data, err := json.Marshal(seqs)
+	assert.Nil(t, err)
+
+	var result []*KeySequence
+	err = json.Unmarshal(data, &result)
+	assert.Nil(t, err)
+	assert.Len(t, result, len(seqs))
+	for i, seq := range result {
+		assert.Equal(t, seqs[i].String(), seq.String())
+		assert.Equal(t, seqs[i].Gesture, seq.Gesture)
+	}
+
+	var seq KeySequence
+	assert.NotNil(t, json.Unmarshal([]byte(`"<Super>W"`), &seq))
+	assert.NotNil(t, json.Unmarshal([]byte(`1`), &seq))
+}
+
+func TestKeySequenceMatcher(t *testing.T) {
+	keyW := Key{Mods: 64, Code: 25}
+	keyH := Key{Code: 43}
+	keyL := Key{Code: 46}
+	keyA := Key{Code: 38}
+
+	seqWH := &KeySequence{}
+	seqWL := &KeySequence{}
+	m := newKeySequenceMatcher()
+	assert.Equal(t, []Key{keyW}, m.add(seqWH, [][]Key{{keyW}, {keyH}}))
+	assert.Nil(t, m.add(seqWL, [][]Key{{keyW}, {keyL}}))
+	assert.True(t, m.isLeader(keyW))
+
+	assert.False(t, m.begin(keyA))
+	assert.True(t, m.begin(keyW))
+	matched, pending := m.feed(keyL)
+	assert.Equal(t, seqWL, matched)
+	assert.False(t, pending)
+	assert.False(t, m.isPending())
+
+	assert.True(t, m.begin(keyW))
+	matched, pending = m.feed(keyA)
+	assert.Nil(t, matched)
+	assert.False(t, pending)
+
+	assert.Nil(t, m.remove(seqWH))
+	assert.Equal(t, []Key{keyW}, m.remove(seqWL))
+	assert.False(t, m.isLeader(keyW))
+}
+
+func TestKeySequenceMatcherMultiStep(t *testing.T) {
+	keyW := Key{Mods: 64, Code: 25}
+	keyH := Key{Code: 43}
+	keyL := Key{Code: 46}
+
+	seq := &KeySequence{}
+	m := newKeySequenceMatcher()
+	m.add(seq, [][]Key{{keyW}, {keyH}, {keyL}})
+
+	assert.True(t, m.begin(keyW))
+	matched, pending := m.feed(keyH)
+	assert.Nil(t, matched)
+	assert.True(t, pending)
+	matched, pending = m.feed(keyL)
+	assert.Equal(t, seq, matched)
+	assert.False(t, pending)
+}
+
+func TestKeySequenceMatcherFindConflict(t *testing.T) {
+	keyW := Key{Mods: 64, Code: 25}
+	keyH := Key{Code: 43}
+	keyL := Key{Code: 46}
+
+	seq := &KeySequence{}
+	m := newKeySequenceMatcher()
+	m.add(seq, [][]Key{{keyW}, {keyH}})
+
+	assert.Equal(t, seq, m.findConflict([][]Key{{keyW}, {keyH}}))
+	// 互为前缀
+	assert.Equal(t, seq, m.findConflict([][]Key{{keyW}, {keyH}, {keyL}}))
+	assert.Nil(t, m.findConflict([][]Key{{keyW}, {keyL}}))
+	assert.Nil(t, m.findConflict([][]Key{{keyH}, {keyW}}))
+}
@@ -79,6 +79,20 @@ type ShortcutManager struct {
 
 	ConflictingKeystrokes []*Keystroke
 	EliminateConflictDone bool
+
+	// for key sequences and modifier gestures
+	seqMatcher      *keySequenceMatcher
+	modGestureMap   map[string]*KeySequence
+	seqMu           sync.Mutex
+	seqTimer        *time.Timer
+	seqTimeout      time.Duration
+	modHoldDuration time.Duration
+
+	// for app scoped shortcuts
+	activeApp        *ActiveApp
+	activeAppMu      sync.Mutex
+	scopeInactiveMap map[string]struct{} // 因为不在生效应用中而没有抓取按键的快捷键
+	scopeMu          sync.Mutex
 }
 
 type KeyEvent struct {
@@ -97,10 +111,21 @@ func NewShortcutManager(conn *x.Conn, keySymbols *keysyms.KeySymbols, eventCb Ke
 		keyKeystrokeMap: make(map[Key]*Keystroke),
 		layoutChanged:   make(chan struct{}),
 		pinyinEnabled:   isZH(),
+		seqMatcher:      newKeySequenceMatcher(),
+		modGestureMap:   make(map[string]*KeySequence),
+		seqTimeout:      DefaultSequenceTimeout,
+		modHoldDuration: DefaultModHoldDuration,
+
+		scopeInactiveMap: make(map[string]struct{}),
 	}
+	ss.seqTimer = time.AfterFunc(ss.seqTimeout, ss.cancelKeySequence)
+	ss.seqTimer.Stop()
 
 	ss.xRecordEventHandler = NewXRecordEventHandler(keySymbols)
-	ss.xRecordEventHandler.modKeyReleasedCb = func(code uint8, mods uint16) {
+	ss.xRecordEventHandler.modKeyReleasedCb = func(code uint8, mods uint16, duration time.Duration) {
+		if ss.handleModGesture(code, mods, duration) {
+			return
+		}
 		isGrabbed := isKbdAlreadyGrabbed(ss.conn)
 		switch mods {
 		case keysyms.ModMaskCapsLock, keysyms.ModMaskSuper:
@@ -377,7 +402,8 @@ func (sm *ShortcutManager) grabKeystroke(shortcut Shortcut, ks *Keystroke, dummy
 	}
 }
 
-func (sm *ShortcutManager) ungrabKeystroke(ks *Keystroke, dummy bool) {
+// ungrabKeystroke 只取消抓取属于 shortcut 的按键，与其他快捷键冲突而没有抓取的按键保持不变
+func (sm *ShortcutManager) ungrabKeystroke(shortcut Shortcut, ks *Keystroke, dummy bool) {
 	keyList, err := ks.ToKeyList(sm.keySymbols)
 	if err != nil {
 		logger.Debug(err)
@@ -390,6 +416,10 @@ func (sm *ShortcutManager) ungrabKeystroke(ks *Keystroke, dummy bool) {
 	sm.keyKeystrokeMapMu.Lock()
 	defer sm.keyKeystrokeMapMu.Unlock()
 	for _, key := range keyList {
+		owner, ok := sm.keyKeystrokeMap[key]
+		if !ok || (owner != ks && !isKeystrokeOwnedBy(owner, shortcut)) {
+			continue
+		}
 		delete(sm.keyKeystrokeMap, key)
 		if !dummy {
 			key.Ungrab(sm.conn)
@@ -397,22 +427,55 @@ func (sm *ShortcutManager) ungrabKeystroke(ks *Keystroke, dummy bool) {
 	}
 }
 
+func isKeystrokeOwnedBy(ks *Keystroke, shortcut Shortcut) bool {
+	return ks.Shortcut != nil && ks.Shortcut.GetUid() == shortcut.GetUid()
+}
+
 func (sm *ShortcutManager) grabShortcut(shortcut Shortcut) {
 	//logger.Debug("grabShortcut shortcut id:", shortcut.GetId())
+	if !sm.isInAppScope(shortcut) {
+		sm.setScopeInactive(shortcut, true)
+		for _, ks := range shortcut.GetKeystrokes() {
+			ks.Shortcut = shortcut
+		}
+		return
+	}
+	sm.setScopeInactive(shortcut, false)
+
 	for _, ks := range shortcut.GetKeystrokes() {
 		dummy := dummyGrab(shortcut, ks)
 		sm.grabKeystroke(shortcut, ks, dummy)
 		ks.Shortcut = shortcut
 	}
+
+	if seqShortcut, ok := shortcut.(KeySequenceShortcut); ok {
+		for _, seq := range seqShortcut.GetKeySequences() {
+			sm.grabKeySequence(shortcut, seq)
+		}
+	}
 }
 
 func (sm *ShortcutManager) ungrabShortcut(shortcut Shortcut) {
+	if sm.isScopeInactive(shortcut) {
+		// 没有抓取按键
+		sm.setScopeInactive(shortcut, false)
+		for _, ks := range shortcut.GetKeystrokes() {
+			ks.Shortcut = nil
+		}
+		return
+	}
 
 	for _, ks := range shortcut.GetKeystrokes() {
 		dummy := dummyGrab(shortcut, ks)
-		sm.ungrabKeystroke(ks, dummy)
+		sm.ungrabKeystroke(shortcut, ks, dummy)
 		ks.Shortcut = nil
 	}
+
+	if seqShortcut, ok := shortcut.(KeySequenceShortcut); ok {
+		for _, seq := range seqShortcut.GetKeySequences() {
+			sm.ungrabKeySequence(seq)
+		}
+	}
 }
 
 func (sm *ShortcutManager) ModifyShortcutKeystrokes(shortcut Shortcut, newVal []*Keystroke) {
@@ -437,8 +500,10 @@ func (sm *ShortcutManager) AddShortcutKeystroke(shortcut Shortcut, ks *Keystroke
 		logger.Debug("shortcut.Keystrokes append", ks.DebugString())
 
 		// grab keystroke
-		dummy := dummyGrab(shortcut, ks)
-		sm.grabKeystroke(shortcut, ks, dummy)
+		if !sm.isScopeInactive(shortcut) {
+			dummy := dummyGrab(shortcut, ks)
+			sm.grabKeystroke(shortcut, ks, dummy)
+		}
 	}
 	ks.Shortcut = shortcut
 }
@@ -457,8 +522,10 @@ func (sm *ShortcutManager) DeleteShortcutKeystroke(shortcut Shortcut, ks *Keystr
 	logger.Debugf("shortcut.Keystrokes  %v -> %v", oldVal, newVal)
 
 	// ungrab keystroke
-	dummy := dummyGrab(shortcut, ks)
-	sm.ungrabKeystroke(ks, dummy)
+	if !sm.isScopeInactive(shortcut) {
+		dummy := dummyGrab(shortcut, ks)
+		sm.ungrabKeystroke(shortcut, ks, dummy)
+	}
 	ks.Shortcut = nil
 }
 
@@ -487,6 +554,19 @@ func (sm *ShortcutManager) UngrabAll() {
 	count := len(sm.keyKeystrokeMap)
 	sm.keyKeystrokeMap = make(map[Key]*Keystroke, count)
 	sm.keyKeystrokeMapMu.Unlock()
+
+	sm.seqMu.Lock()
+	sm.endKeySequenceLocked()
+	for key := range sm.seqMatcher.leaders {
+		key.Ungrab(sm.conn)
+	}
+	sm.seqMatcher = newKeySequenceMatcher()
+	sm.modGestureMap = make(map[string]*KeySequence)
+	sm.seqMu.Unlock()
+
+	sm.scopeMu.Lock()
+	sm.scopeInactiveMap = make(map[string]struct{})
+	sm.scopeMu.Unlock()
 }
 
 func (sm *ShortcutManager) GrabAll() {
@@ -562,6 +642,9 @@ func (sm *ShortcutManager) handleKeyEvent(pressed bool, detail x.Keycode, state
 
 	if pressed {
 		// key press
+		if sm.handleKeySequence(key) {
+			return
+		}
 		sm.emitKeyEvent(Modifiers(state), key)
 	}
 }
@@ -579,6 +662,11 @@ func (sm *ShortcutManager) emitKeyEvent(mods Modifiers, key Key) {
 	sm.keyKeystrokeMapMu.Unlock()
 	if ok {
 		logger.Debugf("emitKeyEvent keystroke: %#v", keystroke)
+		if keystroke.Shortcut != nil && !sm.isInAppScope(keystroke.Shortcut) {
+			// 焦点窗口已经改变，但还没有来得及取消抓取
+			logger.Debug("shortcut is not in app scope")
+			return
+		}
 		keyEvent := &KeyEvent{
 			Mods:     mods,
 			Code:     key.Code,
@@ -752,6 +840,17 @@ func (sm *ShortcutManager) GetByUid(uid string) Shortcut {
 // ret0: Conflicting keystroke
 // ret1: error
 func (sm *ShortcutManager) FindConflictingKeystroke(ks *Keystroke) (*Keystroke, error) {
+	return sm.FindConflictingKeystrokeInScope(ks, getShortcutAppScope(ks.Shortcut))
+}
+
+// FindConflictingKeystrokeFor 查找与将要添加到 shortcut 的按键 ks 冲突的按键，
+// 生效的应用与 shortcut 没有交集的快捷键不算冲突。
+func (sm *ShortcutManager) FindConflictingKeystrokeFor(shortcut Shortcut, ks *Keystroke) (*Keystroke, error) {
+	return sm.FindConflictingKeystrokeInScope(ks, getShortcutAppScope(shortcut))
+}
+
+// FindConflictingKeystrokeInScope 查找与限定在 scope 中生效的按键 ks 冲突的按键，scope 为 nil 表示不限定应用。
+func (sm *ShortcutManager) FindConflictingKeystrokeInScope(ks *Keystroke, scope *AppScope) (*Keystroke, error) {
 	keyList, err := ks.ToKeyList(sm.keySymbols)
 	if err != nil {
 		return nil, err
@@ -764,7 +863,6 @@ func (sm *ShortcutManager) FindConflictingKeystroke(ks *Keystroke) (*Keystroke,
 	logger.Debug("key list:", keyList)
 
 	sm.keyKeystrokeMapMu.Lock()
-	defer sm.keyKeystrokeMapMu.Unlock()
 	var count = 0
 	var ks1 *Keystroke
 	for _, key := range keyList {
@@ -775,11 +873,19 @@ func (sm *ShortcutManager) FindConflictingKeystroke(ks *Keystroke) (*Keystroke,
 		count++
 		ks1 = tmp
 	}
+	sm.keyKeystrokeMapMu.Unlock()
 
-	if count == len(keyList) {
+	if count == len(keyList) && ks1 != ks && scope.Overlaps(getShortcutAppScope(ks1.Shortcut)) {
 		return ks1, nil
 	}
-	return nil, nil
+
+	// 与当前不生效、没有抓取按键的限定了应用的快捷键冲突
+	if ks1 := sm.findConflictingInactiveKeystroke(ks, scope); ks1 != nil {
+		return ks1, nil
+	}
+
+	// 与按键序列的第一个按键冲突
+	return sm.findConflictingSequenceLeader(keyList), nil
 }
 
 func (sm *ShortcutManager) AddSystem(gsettings *gio.Settings, wmObj *wm.Wm) {
@@ -0,0 +1,110 @@
+package shortcuts
+
+// 限定了应用的快捷键只在生效时抓取按键，不生效时不抓取，使得应用可以收到这些按键。
+
+func (sm *ShortcutManager) getActiveApp() *ActiveApp {
+	sm.activeAppMu.Lock()
+	defer sm.activeAppMu.Unlock()
+	return sm.activeApp
+}
+
+// SetActiveApp 在焦点窗口改变时调用，重新抓取或取消抓取限定了应用的快捷键。
+func (sm *ShortcutManager) SetActiveApp(app *ActiveApp) {
+	sm.activeAppMu.Lock()
+	old := sm.activeApp
+	if old == app || (old != nil && app != nil && *old == *app) {
+		sm.activeAppMu.Unlock()
+		return
+	}
+	sm.activeApp = app
+	sm.activeAppMu.Unlock()
+	logger.Debugf("active app changed: %+v", app)
+
+	var changed []Shortcut
+	for _, shortcut := range sm.List() {
+		scope := getShortcutAppScope(shortcut)
+		if scope == nil {
+			continue
+		}
+		if scope.Match(app) == sm.isScopeInactive(shortcut) {
+			changed = append(changed, shortcut)
+		}
+	}
+	// 生效的应用没有交集的快捷键可以使用相同的按键，先全部取消抓取再重新抓取，
+	// 避免将要生效的快捷键的按键还被将要不生效的快捷键占用
+	for _, shortcut := range changed {
+		sm.ungrabShortcut(shortcut)
+	}
+	for _, shortcut := range changed {
+		sm.grabShortcut(shortcut)
+	}
+}
+
+// HasAppScopedShortcuts 返回是否存在限定了应用的快捷键，没有时不必跟踪焦点窗口所属的应用。
+func (sm *ShortcutManager) HasAppScopedShortcuts() bool {
+	sm.idShortcutMapMu.Lock()
+	defer sm.idShortcutMapMu.Unlock()
+	for _, shortcut := range sm.idShortcutMap {
+		if getShortcutAppScope(shortcut) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (sm *ShortcutManager) isInAppScope(shortcut Shortcut) bool {
+	scope := getShortcutAppScope(shortcut)
+	if scope == nil {
+		return true
+	}
+	return scope.Match(sm.getActiveApp())
+}
+
+func (sm *ShortcutManager) isScopeInactive(shortcut Shortcut) bool {
+	sm.scopeMu.Lock()
+	_, ok := sm.scopeInactiveMap[shortcut.GetUid()]
+	sm.scopeMu.Unlock()
+	return ok
+}
+
+func (sm *ShortcutManager) setScopeInactive(shortcut Shortcut, inactive bool) {
+	sm.scopeMu.Lock()
+	if inactive {
+		sm.scopeInactiveMap[shortcut.GetUid()] = struct{}{}
+	} else {
+		delete(sm.scopeInactiveMap, shortcut.GetUid())
+	}
+	sm.scopeMu.Unlock()
+}
+
+// findConflictingInactiveKeystroke 在不生效的限定了应用的快捷键中查找与 ks 相同的按键，
+// 这些按键不在 keyKeystrokeMap 中，但是生效时需要抓取。生效的应用与 scope 没有交集的不算冲突。
+func (sm *ShortcutManager) findConflictingInactiveKeystroke(ks *Keystroke, scope *AppScope) *Keystroke {
+	sm.scopeMu.Lock()
+	uids := make([]string, 0, len(sm.scopeInactiveMap))
+	for uid := range sm.scopeInactiveMap {
+		uids = append(uids, uid)
+	}
+	sm.scopeMu.Unlock()
+
+	for _, uid := range uids {
+		shortcut := sm.GetByUid(uid)
+		if shortcut == nil || !scope.Overlaps(getShortcutAppScope(shortcut)) {
+			continue
+		}
+		for _, ks0 := range shortcut.GetKeystrokes() {
+			if ks0 != ks && ks0.Equal(sm.keySymbols, ks) {
+				return ks0
+			}
+		}
+	}
+	return nil
+}
+
+// ModifyShortcutAppScope 修改快捷键生效的应用，scope 为 nil 表示不限定应用。
+func (sm *ShortcutManager) ModifyShortcutAppScope(shortcut AppScopedShortcut, scope *AppScope) {
+	logger.Debug("ShortcutManager.ModifyShortcutAppScope", shortcut, scope)
+	sm.ungrabShortcut(shortcut)
+	shortcut.setAppScope(scope)
+	sm.grabShortcut(shortcut)
+}
@@ -0,0 +1,329 @@
+package shortcuts
+
+import (
+	"strings"
+	"time"
+
+	x "github.com/linuxdeepin/go-x11-client"
+	"github.com/linuxdeepin/go-x11-client/util/keybind"
+)
+
+// KeySequenceShortcut 是支持按键序列的快捷键
+type KeySequenceShortcut interface {
+	Shortcut
+	GetKeySequences() []*KeySequence
+	setKeySequences([]*KeySequence)
+}
+
+// IsKeySequenceString 判断 str 是否为按键序列或修饰键手势，而不是普通的 keystroke
+func IsKeySequenceString(str string) bool {
+	return strings.Contains(str, keySequenceSep) ||
+		strings.HasSuffix(str, modGestureTapSuffix) ||
+		strings.HasSuffix(str, modGestureHoldSuffix)
+}
+
+func (sm *ShortcutManager) SetKeySequenceTimeout(timeout time.Duration) {
+	sm.seqMu.Lock()
+	sm.seqTimeout = timeout
+	sm.seqMu.Unlock()
+}
+
+func (sm *ShortcutManager) SetModHoldDuration(duration time.Duration) {
+	sm.seqMu.Lock()
+	sm.modHoldDuration = duration
+	sm.seqMu.Unlock()
+}
+
+func (sm *ShortcutManager) grabKeySequence(shortcut Shortcut, seq *KeySequence) {
+	seq.Shortcut = shortcut
+	for _, ks := range seq.Keystrokes {
+		ks.Shortcut = shortcut
+	}
+
+	if seq.IsModGesture() {
+		sm.seqMu.Lock()
+		sm.modGestureMap[seq.modGestureKey()] = seq
+		sm.seqMu.Unlock()
+		return
+	}
+
+	steps, err := seq.toKeySteps(sm.keySymbols)
+	if err != nil {
+		logger.Debugf("grabKeySequence failed, shortcut: %v, seq: %v, err: %v",
+			shortcut.GetId(), seq, err)
+		return
+	}
+
+	sm.seqMu.Lock()
+	conflictSeq := sm.seqMatcher.findConflict(steps)
+	if conflictSeq != nil {
+		sm.seqMu.Unlock()
+		logger.Warningf("key sequence %v of %v conflicts with %v", seq, shortcut.GetId(), conflictSeq)
+		return
+	}
+	newLeaders := sm.seqMatcher.add(seq, steps)
+	sm.seqMu.Unlock()
+
+	for _, key := range newLeaders {
+		sm.keyKeystrokeMapMu.Lock()
+		_, grabbed := sm.keyKeystrokeMap[key]
+		sm.keyKeystrokeMapMu.Unlock()
+		if grabbed {
+			continue
+		}
+		err = key.Grab(sm.conn)
+		if err != nil {
+			logger.Debug(err)
+		}
+	}
+}
+
+func (sm *ShortcutManager) ungrabKeySequence(seq *KeySequence) {
+	seq.Shortcut = nil
+	if seq.IsModGesture() {
+		sm.seqMu.Lock()
+		if sm.modGestureMap[seq.modGestureKey()] == seq {
+			delete(sm.modGestureMap, seq.modGestureKey())
+		}
+		sm.seqMu.Unlock()
+		return
+	}
+
+	sm.seqMu.Lock()
+	sm.endKeySequenceLocked()
+	unusedLeaders := sm.seqMatcher.remove(seq)
+	sm.seqMu.Unlock()
+
+	for _, key := range unusedLeaders {
+		sm.keyKeystrokeMapMu.Lock()
+		_, grabbed := sm.keyKeystrokeMap[key]
+		sm.keyKeystrokeMapMu.Unlock()
+		if !grabbed {
+			key.Ungrab(sm.conn)
+		}
+	}
+}
+
+func (sm *ShortcutManager) AddShortcutKeySequence(shortcut KeySequenceShortcut, seq *KeySequence) {
+	logger.Debug("ShortcutManager.AddShortcutKeySequence", shortcut, seq)
+	oldVal := shortcut.GetKeySequences()
+	for _, seq0 := range oldVal {
+		if seq.Equal(sm.keySymbols, seq0) {
+			return
+		}
+	}
+	shortcut.setKeySequences(append(oldVal, seq))
+	if sm.isScopeInactive(shortcut) {
+		seq.Shortcut = shortcut
+		return
+	}
+	sm.grabKeySequence(shortcut, seq)
+}
+
+func (sm *ShortcutManager) DeleteShortcutKeySequence(shortcut KeySequenceShortcut, seq *KeySequence) {
+	logger.Debug("ShortcutManager.DeleteShortcutKeySequence", shortcut, seq)
+	oldVal := shortcut.GetKeySequences()
+	var newVal []*KeySequence
+	for _, seq0 := range oldVal {
+		if seq.Equal(sm.keySymbols, seq0) {
+			if sm.isScopeInactive(shortcut) {
+				seq0.Shortcut = nil
+			} else {
+				sm.ungrabKeySequence(seq0)
+			}
+		} else {
+			newVal = append(newVal, seq0)
+		}
+	}
+	shortcut.setKeySequences(newVal)
+}
+
+func (sm *ShortcutManager) ModifyShortcutKeySequences(shortcut KeySequenceShortcut, newVal []*KeySequence) {
+	logger.Debug("ShortcutManager.ModifyShortcutKeySequences", shortcut, newVal)
+	sm.ungrabShortcut(shortcut)
+	shortcut.setKeySequences(newVal)
+	sm.grabShortcut(shortcut)
+}
+
+// FindConflictingKeySequence 查找与 seq 冲突的快捷键。
+// 按键序列的第一个按键不能被普通快捷键占用，且不能与其他按键序列互为前缀；
+// 修饰键轻按不能与该修饰键的普通快捷键冲突。
+func (sm *ShortcutManager) FindConflictingKeySequence(seq *KeySequence) (Shortcut, error) {
+	if seq.IsModGesture() {
+		sm.seqMu.Lock()
+		seq0 := sm.modGestureMap[seq.modGestureKey()]
+		sm.seqMu.Unlock()
+		if seq0 != nil {
+			return seq0.Shortcut, nil
+		}
+		if seq.Gesture == ModGestureTap {
+			return sm.findConflictingKeystrokeShortcut(seq.Keystrokes[0])
+		}
+		return nil, nil
+	}
+
+	steps, err := seq.toKeySteps(sm.keySymbols)
+	if err != nil {
+		return nil, err
+	}
+
+	sm.keyKeystrokeMapMu.Lock()
+	var conflictKs *Keystroke
+	for _, key := range steps[0] {
+		if ks, ok := sm.keyKeystrokeMap[key]; ok {
+			conflictKs = ks
+			break
+		}
+	}
+	sm.keyKeystrokeMapMu.Unlock()
+	if conflictKs != nil {
+		return conflictKs.Shortcut, nil
+	}
+
+	sm.seqMu.Lock()
+	conflictSeq := sm.seqMatcher.findConflict(steps)
+	sm.seqMu.Unlock()
+	if conflictSeq != nil {
+		return conflictSeq.Shortcut, nil
+	}
+	return nil, nil
+}
+
+func (sm *ShortcutManager) findConflictingKeystrokeShortcut(ks *Keystroke) (Shortcut, error) {
+	conflictKs, err := sm.FindConflictingKeystroke(ks)
+	if err != nil || conflictKs == nil {
+		return nil, err
+	}
+	return conflictKs.Shortcut, nil
+}
+
+func (sm *ShortcutManager) findConflictingSequenceLeader(keyList []Key) *Keystroke {
+	sm.seqMu.Lock()
+	defer sm.seqMu.Unlock()
+
+	for _, key := range keyList {
+		candidates := sm.seqMatcher.leaders[key]
+		if len(candidates) > 0 {
+			return candidates[0].seq.Keystrokes[0]
+		}
+	}
+	return nil
+}
+
+func (sm *ShortcutManager) isModifierKeycode(code Keycode) bool {
+	str, ok := sm.keySymbols.LookupString(x.Keycode(code), 0)
+	if !ok {
+		return false
+	}
+	_, isMod := key2Mod(str)
+	return isMod
+}
+
+// handleKeySequence 处理按键序列，返回 true 表示按键已被按键序列消耗。
+// 按下序列的第一个按键后抓取整个键盘，以便接收后续的按键，直到匹配完成、不匹配或超时。
+func (sm *ShortcutManager) handleKeySequence(key Key) bool {
+	sm.seqMu.Lock()
+
+	if !sm.seqMatcher.isPending() {
+		if !sm.seqMatcher.begin(key) {
+			sm.seqMu.Unlock()
+			return false
+		}
+		rootWin := sm.conn.GetDefaultScreen().Root
+		err := keybind.GrabKeyboard(sm.conn, rootWin)
+		if err != nil {
+			logger.Warning("failed to grab keyboard for key sequence:", err)
+			sm.seqMatcher.reset()
+		} else {
+			sm.seqTimer.Reset(sm.seqTimeout)
+		}
+		sm.seqMu.Unlock()
+		return true
+	}
+
+	if sm.isModifierKeycode(key.Code) {
+		// 修饰键本身的按下事件，继续等待
+		sm.seqMu.Unlock()
+		return true
+	}
+
+	seq, pending := sm.seqMatcher.feed(key)
+	if pending {
+		sm.seqTimer.Reset(sm.seqTimeout)
+		sm.seqMu.Unlock()
+		return true
+	}
+	sm.endKeySequenceLocked()
+	sm.seqMu.Unlock()
+
+	if seq == nil {
+		logger.Debug("key sequence not matched")
+		return true
+	}
+	logger.Debugf("key sequence %v matched", seq)
+	shortcut := seq.Shortcut
+	if shortcut == nil || !sm.isInAppScope(shortcut) {
+		return true
+	}
+	sm.callEventCallback(&KeyEvent{
+		Mods:     key.Mods,
+		Code:     key.Code,
+		Shortcut: shortcut,
+	})
+	return true
+}
+
+func (sm *ShortcutManager) cancelKeySequence() {
+	sm.seqMu.Lock()
+	if sm.seqMatcher.isPending() {
+		logger.Debug("key sequence timeout")
+		sm.endKeySequenceLocked()
+	}
+	sm.seqMu.Unlock()
+}
+
+func (sm *ShortcutManager) endKeySequenceLocked() {
+	if !sm.seqMatcher.isPending() {
+		return
+	}
+	sm.seqMatcher.reset()
+	sm.seqTimer.Stop()
+	err := keybind.UngrabKeyboard(sm.conn)
+	if err != nil {
+		logger.Warning("failed to ungrab keyboard:", err)
+	}
+}
+
+// handleModGesture 处理单独按下再释放修饰键的手势，返回 true 表示已处理。
+func (sm *ShortcutManager) handleModGesture(code uint8, mods uint16, duration time.Duration) bool {
+	keystr, ok := sm.keySymbols.LookupString(x.Keycode(code), 0)
+	if !ok {
+		return false
+	}
+	// 只处理单个修饰键
+	mod, ok := key2Mod(keystr)
+	if !ok || mod != mods {
+		return false
+	}
+
+	sm.seqMu.Lock()
+	gesture := ModGestureTap
+	if duration >= sm.modHoldDuration {
+		gesture = ModGestureHold
+	}
+	seq := sm.modGestureMap[modGestureKey(keystr, gesture)]
+	sm.seqMu.Unlock()
+	if seq == nil || seq.Shortcut == nil || !sm.isInAppScope(seq.Shortcut) {
+		return false
+	}
+	if isKbdAlreadyGrabbed(sm.conn) {
+		return true
+	}
+
+	logger.Debugf("modifier gesture %v matched", seq)
+	sm.callEventCallback(&KeyEvent{
+		Code:     Keycode(code),
+		Shortcut: seq.Shortcut,
+	})
+	return true
+}
@@ -21,6 +21,7 @@ package shortcuts
 
 import (
 	"strings"
+	"time"
 
 	x "github.com/linuxdeepin/go-x11-client"
 	"github.com/linuxdeepin/go-x11-client/util/keysyms"
@@ -31,7 +32,8 @@ type XRecordEventHandler struct {
 	pressedMods          uint16
 	historyPressedMods   uint16
 	nonModKeyPressed     bool
-	modKeyReleasedCb     func(uint8, uint16)
+	modPressedTime       time.Time
+	modKeyReleasedCb     func(code uint8, mods uint16, duration time.Duration)
 	allModKeysReleasedCb func()
 }
 
@@ -64,6 +66,9 @@ func (h *XRecordEventHandler) handleKeyEvent(pressed bool, keycode uint8, state
 	if pressed {
 		mod, ok := key2Mod(keystr)
 		if ok {
+			if h.pressedMods == 0 {
+				h.modPressedTime = time.Now()
+			}
 			h.pressedMods |= mod
 			h.historyPressedMods |= mod
 		} else {
@@ -85,7 +90,7 @@ func (h *XRecordEventHandler) handleKeyEvent(pressed bool, keycode uint8, state
 			if h.modKeyReleasedCb != nil {
 				logger.Debugf("modKeyReleased keycode %d historyPressedMods: %s",
 					keycode, Modifiers(h.historyPressedMods))
-				h.modKeyReleasedCb(keycode, h.historyPressedMods)
+				h.modKeyReleasedCb(keycode, h.historyPressedMods, time.Since(h.modPressedTime))
 			}
 		}
 		h.pressedMods &^= mod
@@ -28,8 +28,6 @@ import (
 	"pkg.deepin.io/lib/appinfo/desktopappinfo"
 )
 
-type SearchScore uint64
-
 type Item struct {
 	Path          string
 	Name          string // display name
@@ -45,7 +43,13 @@ type Item struct {
 	exec            string
 	genericName     string
 	comment         string
-	searchTargets   map[string]SearchScore
+	enComment       string
+	actions         []ItemAction
+	mimeTypes       []string
+	// 其他语言的名称，来自 appNameTranslationsFile
+	translations     []string
+	pinyin           string
+	pinyinShortening string
 }
 
 func (item *Item) String() string {
@@ -93,10 +97,17 @@ func NewItemWithDesktopAppInfo(appInfo *desktopappinfo.DesktopAppInfo) *Item {
 		Icon:            appInfo.GetIcon(),
 		exec:            appInfo.GetCommandline(),
 		genericName:     appInfo.GetGenericName(),
-		comment:         enComment,
-		searchTargets:   make(map[string]SearchScore),
+		comment:         appInfo.GetComment(),
+		enComment:       enComment,
 		xDeepinCategory: strings.ToLower(xDeepinCategory),
 	}
+	for _, action := range appInfo.GetActions() {
+		item.actions = append(item.actions, ItemAction{
+			Section: action.Section,
+			Name:    action.Name,
+		})
+	}
+	item.mimeTypes, _ = appInfo.GetStringList(desktopappinfo.MainSection, "MimeType")
 	for _, kw := range appInfo.GetKeywords() {
 		item.keywords = append(item.keywords, strings.ToLower(kw))
 	}
@@ -113,12 +124,16 @@ func (item *Item) getXCategory() CategoryID {
 	return getXCategory(item.categories)
 }
 
+// 搜索时各字段的权重
 const (
 	idScore          = 100
 	nameScore        = 80
 	genericNameScore = 70
 	keywordScore     = 60
 	categoryScore    = 60
+	translationScore = 50
+	actionScore      = 40
+	commentScore     = 30
 )
 
 var pinyinArgs = pinyin.NewArgs()
@@ -144,34 +159,33 @@ func toPinyinAndShortening(str string) (string, string) {
 	return strings.Join(pyStrSlice, ""), shortening.String()
 }
 
-func (item *Item) setSearchTargets(pinyinEnabled bool) {
-	item.addSearchTarget(nameScore, item.Name)
-	item.addSearchTarget(nameScore, item.enName)
-
+func (item *Item) setPinyin(pinyinEnabled bool) {
 	if pinyinEnabled {
-		pinyin, shortening := toPinyinAndShortening(item.Name)
-		item.addSearchTarget(nameScore, pinyin)
-		item.addSearchTarget(nameScore, shortening)
+		item.pinyin, item.pinyinShortening = toPinyinAndShortening(item.Name)
+	} else {
+		item.pinyin, item.pinyinShortening = "", ""
 	}
 }
 
-func (item *Item) addSearchTarget(score SearchScore, str string) {
-	if str == "" {
-		return
+func (item *Item) getSearchDoc(packageNameSearchEnabled bool) *searchDoc {
+	doc := &searchDoc{id: item.ID}
+	add := func(weight float64, texts ...string) {
+		for _, text := range texts {
+			if text != "" {
+				doc.fields = append(doc.fields, searchField{text: text, weight: weight})
+			}
+		}
 	}
-	str = strings.Replace(str, " ", "", -1)
-	str = strings.ToLower(str)
-	scoreInDict, ok := item.searchTargets[str]
-	if !ok || (ok && scoreInDict < score) {
-		item.searchTargets[str] = score
+	add(nameScore, item.Name, item.enName, item.pinyin, item.pinyinShortening)
+	add(genericNameScore, item.genericName)
+	add(keywordScore, item.keywords...)
+	add(translationScore, item.translations...)
+	for _, action := range item.actions {
+		add(actionScore, action.Name)
 	}
-}
-
-func (item *Item) deleteSearchTarget(str string) {
-	if str == "" {
-		return
+	add(commentScore, item.comment, item.enComment)
+	if packageNameSearchEnabled {
+		add(idScore, item.ID)
 	}
-	str = strings.Replace(str, " ", "", -1)
-	str = strings.ToLower(str)
-	delete(item.searchTargets, str)
+	return doc
 }
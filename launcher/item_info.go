@@ -26,6 +26,16 @@ type ItemInfo struct {
 	Icon          string
 	CategoryID    CategoryID
 	TimeInstalled int64
+	// desktop 文件中的动作，如 "New Private Window"
+	Actions []ItemAction
+	// 最近使用的、应用能打开的文件
+	RecentFiles []string
+}
+
+type ItemAction struct {
+	// desktop 文件中动作的 section，用于 LaunchAction
+	Section string
+	Name    string
 }
 
 func (item *Item) newItemInfo() ItemInfo {
@@ -36,6 +46,23 @@ func (item *Item) newItemInfo() ItemInfo {
 		Icon:          item.Icon,
 		CategoryID:    item.CategoryID,
 		TimeInstalled: item.TimeInstalled,
+		Actions:       item.actions,
 	}
 	return iInfo
 }
+
+// newItemInfo 返回包含最近使用文件的 ItemInfo，recentFiles 来自 m.recentFiles.get()
+func (m *Manager) newItemInfo(item *Item, recentFiles []*recentFile) ItemInfo {
+	iInfo := item.newItemInfo()
+	iInfo.RecentFiles = filterRecentFiles(recentFiles, item.mimeTypes, recentFilesMaxLen)
+	return iInfo
+}
+
+func (item *Item) hasAction(section string) bool {
+	for _, action := range item.actions {
+		if action.Section == section {
+			return true
+		}
+	}
+	return false
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/godbus/dbus"
 	libApps "github.com/linuxdeepin/go-dbus-factory/com.deepin.daemon.apps"
 	libLastore "github.com/linuxdeepin/go-dbus-factory/com.deepin.lastore"
+	"github.com/linuxdeepin/go-dbus-factory/com.deepin.sessionmanager"
 	notifications "github.com/linuxdeepin/go-dbus-factory/org.freedesktop.notifications"
 	"pkg.deepin.io/dde/daemon/common/dsync"
 	"pkg.deepin.io/dde/daemon/session/common"
@@ -76,19 +77,29 @@ type Manager struct {
 
 	appsObj        *libApps.Apps
 	notifications  *notifications.Notifications
+	startManager   *sessionmanager.StartManager
 	lastore        *libLastore.Lastore
 	pinyinEnabled  bool
 	desktopPkgMap  map[string]string
 	pkgCategoryMap map[string]CategoryID
 	nameMap        map[string]string
+	// 应用在所有语言中的名称，用于搜索
+	nameTranslations map[string][]string
 
-	searchTaskStack          *searchTaskStack
 	packageNameSearchEnabled bool
 
 	itemsChangedHit uint32
-	searchMu        sync.Mutex
-	currentRunes    []rune
-	popPushOpChan   chan *popPushOp
+	searchIndex     *searchIndex
+	searchIndexMu   sync.Mutex
+	searchGen       uint64
+	searchEmitMu    sync.Mutex
+	launchCounts    map[string]uint64
+	launchCountsMu  sync.Mutex
+
+	recentFiles recentFiles
+
+	uninstalling   map[string]bool
+	uninstallingMu sync.Mutex
 
 	noPkgItemIDs       map[string]int
 	appDirs            []string
@@ -104,8 +115,15 @@ type Manager struct {
 
 	//nolint
 	signals *struct {
-		// SearchDone 返回搜索结果列表
+		// SearchDone 返回搜索结果列表，key 为搜索的关键字
 		SearchDone struct {
+			key  string
+			apps []string
+		}
+
+		// SearchResult 在搜索完成之前返回已经找到的结果，之后可能还有 SearchResult 或 SearchDone
+		SearchResult struct {
+			key  string
 			apps []string
 		}
 
@@ -129,6 +147,13 @@ type Manager struct {
 			appId  string
 			errMsg string
 		}
+
+		// UninstallProgress 报告卸载的进度，status 为 start、running 或 rollback
+		UninstallProgress struct {
+			appId    string
+			progress float64
+			status   string
+		}
 	}
 
 	//nolint
@@ -146,6 +171,8 @@ type Manager struct {
 		SetUseProxy              func() `in:"id,value"`
 		GetDisableScaling        func() `in:"id" out:"value"`
 		SetDisableScaling        func() `in:"id,value"`
+		LaunchAction             func() `in:"id,action"`
+		GetUninstallReport       func() `in:"id" out:"report"`
 	}
 }
 
@@ -185,12 +212,14 @@ func NewManager(service *dbusutil.Service) (*Manager, error) {
 	m.packageNameSearchEnabled = m.settings.GetBoolean(gsKeyPackageNameSearch)
 
 	m.noPkgItemIDs = make(map[string]int)
+	m.uninstalling = make(map[string]bool)
 
 	m.appsHidden = m.settings.GetStrv(gsKeyAppsHidden)
 	logger.Debug("appsHidden: ", m.appsHidden)
 	m.listenSettingsChanged()
 
 	m.notifications = notifications.NewNotifications(service.Conn())
+	m.startManager = sessionmanager.NewStartManager(service.Conn())
 
 	m.appDirs = getAppDirs()
 	err = m.loadDesktopPkgMap()
@@ -209,13 +238,7 @@ func NewManager(service *dbusutil.Service) (*Manager, error) {
 		logger.Warning(err)
 	}
 	m.initItems()
-
-	// init searchTaskStack
-	m.searchTaskStack = newSearchTaskStack(m)
-
-	// init popPushOpChan
-	m.popPushOpChan = make(chan *popPushOp, 50)
-	go m.handlePopPushOps()
+	m.loadLaunchCounts()
 
 	m.sysSigLoop = dbusutil.NewSignalLoop(systemBus, 100)
 	m.sysSigLoop.Start()
@@ -316,21 +339,18 @@ func (m *Manager) addItem(item *Item) {
 	}
 	logger.Debugf("addItem path: %q, id: %q", item.Path, item.ID)
 
-	// NOTE: change name before call item.setSearchTargets
+	// NOTE: change name before call item.setPinyin
 	if m.nameMap != nil {
 		newName := m.nameMap[item.ID]
 		if newName != "" {
 			item.Name = newName
 		}
 	}
+	item.translations = m.nameTranslations[item.ID]
 
 	item.CategoryID = m.queryCategoryID(item)
 	logger.Debug("addItem category", item.CategoryID)
-	item.setSearchTargets(m.pinyinEnabled)
-	if m.packageNameSearchEnabled {
-		item.addSearchTarget(idScore, item.ID)
-	}
-	logger.Debug("item search targets:", item.searchTargets)
+	item.setPinyin(m.pinyinEnabled)
 	m.items[item.ID] = item
 }
 
@@ -424,6 +444,13 @@ func (m *Manager) loadNameMap() error {
 	lang := gettext.QueryLang()
 	m.nameMap = data[lang]
 	logger.Debugf("loadNameMap lang %v: %v", lang, m.nameMap)
+
+	m.nameTranslations = make(map[string][]string)
+	for _, names := range data {
+		for id, name := range names {
+			m.nameTranslations[id] = append(m.nameTranslations[id], name)
+		}
+	}
 	return nil
 }
 
@@ -504,18 +531,6 @@ func getNotifyAppName() string {
 	return "deepin-appstore"
 }
 
-func (m *Manager) emitSearchDone(result MatchResults) {
-	var ids []string
-	if result != nil {
-		ids = result.Copy().GetTruncatedOrderedIDs()
-	}
-	logger.Debug("emit SearchDone", ids)
-	err := m.service.Emit(m, "SearchDone", ids)
-	if err != nil {
-		logger.Warning(err)
-	}
-}
-
 func (m *Manager) getUseFeature(key, id string) (bool, *dbus.Error) {
 	item := m.getItemById(id)
 	if item == nil {
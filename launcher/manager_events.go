@@ -43,19 +43,15 @@ func isDesktopFile(path string) bool {
 func (m *Manager) handlePackageNameSearchChanged() {
 	enabled := m.settings.GetBoolean(gsKeyPackageNameSearch)
 	logger.Debug("itemSearchTarget update, search package name enable: ", enabled)
-	if enabled != m.packageNameSearchEnabled {
-		if enabled {
-			for _, item := range m.items {
-				item.addSearchTarget(idScore, item.ID)
-			}
-		} else {
-			for _, item := range m.items {
-				item.deleteSearchTarget(item.ID)
-			}
-		}
-	}
+	// 先修改再标记，重新建立索引时使用新的值
+	m.searchIndexMu.Lock()
+	changed := enabled != m.packageNameSearchEnabled
 	m.packageNameSearchEnabled = enabled
-
+	m.searchIndexMu.Unlock()
+	if changed {
+		// 重新建立搜索索引
+		atomic.StoreUint32(&m.itemsChangedHit, 1)
+	}
 }
 
 func (m *Manager) handleAppHiddenChanged() {
@@ -292,7 +288,7 @@ func (m *Manager) retryAddItem(appInfo *desktopappinfo.DesktopAppInfo, item *Ite
 
 func (m *Manager) emitItemChanged(item *Item, status string) {
 	atomic.StoreUint32(&m.itemsChangedHit, 1)
-	itemInfo := item.newItemInfo()
+	itemInfo := m.newItemInfo(item, m.recentFiles.get())
 	logger.Debugf("emit signal ItemChanged status: %v, itemInfo: %v", status, itemInfo)
 	err := m.service.Emit(m, "ItemChanged", status, itemInfo, itemInfo.CategoryID)
 	if err != nil {
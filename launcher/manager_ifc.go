@@ -50,8 +50,9 @@ func (m *Manager) GetInterfaceName() string {
 
 func (m *Manager) GetAllItemInfos() ([]ItemInfo, *dbus.Error) {
 	list := make([]ItemInfo, 0, len(m.items))
+	recentFiles := m.recentFiles.get()
 	for _, item := range m.items {
-		list = append(list, item.newItemInfo())
+		list = append(list, m.newItemInfo(item, recentFiles))
 	}
 	logger.Debug("GetAllItemInfos list length:", len(list))
 	return list, nil
@@ -62,7 +63,7 @@ func (m *Manager) GetItemInfo(id string) (ItemInfo, *dbus.Error) {
 	if item == nil {
 		return ItemInfo{}, dbusutil.ToError(errorInvalidID)
 	}
-	return item.newItemInfo(), nil
+	return m.newItemInfo(item, m.recentFiles.get()), nil
 }
 
 func (m *Manager) GetAllNewInstalledApps() ([]string, *dbus.Error) {
@@ -151,9 +152,14 @@ func (m *Manager) RequestSendToDesktop(id string) (bool, *dbus.Error) {
 	return true, nil
 }
 
-// MarkLaunched 废弃
+// MarkLaunched 记录应用的启动次数，启动次数多的应用在搜索结果中靠前
 func (m *Manager) MarkLaunched(id string) *dbus.Error {
-	return nil
+	item := m.getItemById(id)
+	if item == nil {
+		return dbusutil.ToError(errorInvalidID)
+	}
+	err := m.markLaunched(id)
+	return dbusutil.ToError(err)
 }
 
 // purge is useless
@@ -182,8 +188,6 @@ func (m *Manager) RequestUninstall(sender dbus.Sender, id string, purge bool) *d
 			}
 			return
 		}
-
-		m.removeAutostart(id)
 		logger.Infof("uninstall %q success", id)
 		err := m.service.Emit(m, "UninstallSuccess", id)
 		if err != nil {
@@ -198,29 +202,13 @@ func (m *Manager) isItemsChanged() bool {
 	return old > 0
 }
 
+// Search 在后台搜索应用，先通过 SearchResult 信号返回完全匹配和前缀匹配的结果，
+// 包含模糊匹配的最终结果通过 SearchDone 信号返回，新的搜索会取消还没有完成的搜索
 func (m *Manager) Search(key string) *dbus.Error {
 	key = strings.ToLower(key)
 	logger.Debug("Search key:", key)
-
-	keyRunes := []rune(key)
-
-	m.searchMu.Lock()
-
-	if m.isItemsChanged() {
-		// clear search cache
-		m.popPushOpChan <- &popPushOp{popCount: len(m.currentRunes)}
-		m.currentRunes = nil
-	}
-
-	popCount, runesPush := runeSliceDiff(keyRunes, m.currentRunes)
-
-	logger.Debugf("runeSliceDiff key %v, current %v", keyRunes, m.currentRunes)
-	logger.Debugf("runeSliceDiff popCount %v, runesPush %v", popCount, runesPush)
-
-	m.popPushOpChan <- &popPushOp{popCount, runesPush}
-	m.currentRunes = keyRunes
-
-	m.searchMu.Unlock()
+	gen := atomic.AddUint64(&m.searchGen, 1)
+	go m.search(key, gen)
 	return nil
 }
 
@@ -253,3 +241,26 @@ func (m *Manager) getExecutablePath(sender dbus.Sender) (string, error) {
 
 	return execPath, nil
 }
+
+// GetUninstallReport 返回卸载应用时会删除的软件包和文件，不会卸载应用
+func (m *Manager) GetUninstallReport(id string) (UninstallReport, *dbus.Error) {
+	report, err := m.getUninstallReport(id)
+	if err != nil {
+		return UninstallReport{}, dbusutil.ToError(err)
+	}
+	return *report, nil
+}
+
+// LaunchAction 启动应用 desktop 文件中的动作，action 是 ItemInfo.Actions 中的 Section
+func (m *Manager) LaunchAction(id, action string) *dbus.Error {
+	item := m.getItemById(id)
+	if item == nil {
+		return dbusutil.ToError(errorInvalidID)
+	}
+	if !item.hasAction(action) {
+		return dbusutil.ToError(fmt.Errorf("invalid action %q", action))
+	}
+	logger.Debugf("launch action %q of %q", action, id)
+	err := m.startManager.LaunchAppAction(dbus.FlagNoAutoStart, item.Path, action, 0)
+	return dbusutil.ToError(err)
+}
@@ -66,38 +66,6 @@ func shouldCheckDesktopFile(filename string) bool {
 	return dir != skipDir
 }
 
-type popPushOp struct {
-	popCount  int
-	runesPush []rune
-}
-
-func (m *Manager) handlePopPushOps() {
-	stack := m.searchTaskStack
-	for op := range m.popPushOpChan {
-		logger.Debug("op:", op)
-
-		for i := 0; i < op.popCount; i++ {
-			stack.Pop()
-		}
-		if len(op.runesPush) == 0 {
-			// emit top result
-			top := stack.topTask()
-			if top == nil {
-				logger.Debug("emit SearchDone []")
-				err := m.service.Emit(m, "SearchDone", []string{})
-				if err != nil {
-					logger.Warning("emit SearchDone Failed:", err)
-				}
-			} else {
-				top.emitResult()
-			}
-		}
-		for _, v := range op.runesPush {
-			stack.Push(v)
-		}
-	}
-}
-
 func (m *Manager) destroy() {
 	m.appsObj.RemoveHandler(proxy.RemoveAllHandlers)
 	m.syncConfig.Destroy()
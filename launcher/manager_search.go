@@ -0,0 +1,136 @@
+package launcher
+
+import (
+	"sync/atomic"
+
+	"pkg.deepin.io/dde/daemon/appinfo"
+)
+
+// loadLaunchCounts 从 appinfo 的启动频率记录中读取应用的启动次数
+func (m *Manager) loadLaunchCounts() {
+	counts := make(map[string]uint64)
+	f, err := appinfo.GetFrequencyRecordFile()
+	if err != nil {
+		logger.Warning("failed to load launch counts:", err)
+	} else {
+		m.itemsMutex.Lock()
+		for id := range m.items {
+			if count := appinfo.GetFrequency(id, f); count > 0 {
+				counts[id] = count
+			}
+		}
+		m.itemsMutex.Unlock()
+		f.Free()
+	}
+
+	m.launchCountsMu.Lock()
+	m.launchCounts = counts
+	m.launchCountsMu.Unlock()
+}
+
+func (m *Manager) markLaunched(id string) error {
+	f, err := appinfo.GetFrequencyRecordFile()
+	if err != nil {
+		return err
+	}
+	defer f.Free()
+
+	count := appinfo.GetFrequency(id, f) + 1
+	appinfo.SetFrequency(id, count, f)
+
+	m.launchCountsMu.Lock()
+	m.launchCounts[id] = count
+	m.launchCountsMu.Unlock()
+	return nil
+}
+
+func (m *Manager) getLaunchCounts() map[string]uint64 {
+	m.launchCountsMu.Lock()
+	defer m.launchCountsMu.Unlock()
+	counts := make(map[string]uint64, len(m.launchCounts))
+	for id, count := range m.launchCounts {
+		counts[id] = count
+	}
+	return counts
+}
+
+// getSearchIndex 返回搜索索引，应用改变后重新建立索引
+func (m *Manager) getSearchIndex() *searchIndex {
+	m.searchIndexMu.Lock()
+	defer m.searchIndexMu.Unlock()
+
+	if m.searchIndex != nil && !m.isItemsChanged() {
+		return m.searchIndex
+	}
+
+	m.itemsMutex.Lock()
+	docs := make([]*searchDoc, 0, len(m.items))
+	for _, item := range m.items {
+		docs = append(docs, item.getSearchDoc(m.packageNameSearchEnabled))
+	}
+	m.itemsMutex.Unlock()
+
+	m.searchIndex = newSearchIndex(docs)
+	logger.Debugf("search index rebuilt, docs: %d, terms: %d", len(docs), len(m.searchIndex.vocab))
+	return m.searchIndex
+}
+
+func (m *Manager) search(key string, gen uint64) {
+	idx := m.getSearchIndex()
+	launchCounts := m.getLaunchCounts()
+
+	hits := idx.search(key, false, launchCounts)
+	if len(hits) > 0 {
+		if !m.emitSearchResult(key, gen, hits) {
+			logger.Debugf("search %q canceled", key)
+			return
+		}
+	} else if m.isSearchCanceled(gen) {
+		logger.Debugf("search %q canceled", key)
+		return
+	}
+
+	hits = idx.search(key, true, launchCounts)
+	if !m.emitSearchDone(key, gen, hits) {
+		logger.Debugf("search %q canceled", key)
+	}
+}
+
+func (m *Manager) isSearchCanceled(gen uint64) bool {
+	return atomic.LoadUint64(&m.searchGen) != gen
+}
+
+// emitSearchResult 和 emitSearchDone 在发送信号前检查搜索是否已被取消，
+// 检查和发送在 searchEmitMu 中完成，被取消的搜索不会在新的搜索之后发送结果。
+// 返回 false 表示搜索已被取消。
+func (m *Manager) emitSearchResult(key string, gen uint64, hits []searchHit) bool {
+	m.searchEmitMu.Lock()
+	defer m.searchEmitMu.Unlock()
+	if m.isSearchCanceled(gen) {
+		return false
+	}
+
+	ids := getSearchHitIds(hits)
+	logger.Debug("emit SearchResult", key, ids)
+	err := m.service.Emit(m, "SearchResult", key, ids)
+	if err != nil {
+		logger.Warning(err)
+	}
+	return true
+}
+
+func (m *Manager) emitSearchDone(key string, gen uint64, hits []searchHit) bool {
+	m.searchEmitMu.Lock()
+	defer m.searchEmitMu.Unlock()
+	if m.isSearchCanceled(gen) {
+		return false
+	}
+
+	ids := getSearchHitIds(hits)
+	logger.Debug("emit SearchDone", key, ids)
+	err := m.service.Emit(m, "SearchDone", key, ids)
+	if err != nil {
+		logger.Warning(err)
+	}
+	return true
+}
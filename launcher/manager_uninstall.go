@@ -47,14 +47,6 @@ func isChromeShortcut(item *Item) bool {
 		chromeShortcurtExecRegexp.MatchString(item.exec)
 }
 
-// Simply remove the desktop file
-func (m *Manager) uninstallDesktopFile(item *Item) error {
-	logger.Debugf("remove desktop file %q", item.Path)
-	err := os.Remove(item.Path)
-	go m.notifyUninstallDone(item, err == nil)
-	return err
-}
-
 func (m *Manager) removeAutostart(id string) {
 	name := filepath.Base(id) + desktopExt
 	file := filepath.Join(basedir.GetUserConfigDir(), "autostart", name)
@@ -87,14 +79,6 @@ func isCrossOver(appInfo *desktopappinfo.DesktopAppInfo) bool {
 		(execBase == "crossover" || execBase == "cxuninstall")
 }
 
-func (m *Manager) uninstallDeepinWineApp(item *Item) error {
-	logger.Debug("uninstallDeepinWineApp", item.Path)
-	cmd := exec.Command("/opt/deepinwine/tools/uninstall.sh", item.Path)
-	err := cmd.Run()
-	go m.notifyUninstallDone(item, err == nil)
-	return err
-}
-
 type flatpakAppInfo struct {
 	name, arch, branch string
 }
@@ -158,53 +142,11 @@ func isFlatpakApp(appInfo *desktopappinfo.DesktopAppInfo) (bool, *flatpakAppInfo
 	return true, fpAppInfo, nil
 }
 
-func (m *Manager) uninstallFlatpakApp(item *Item, fpAppInfo *flatpakAppInfo) error {
-	logger.Debug("uninstallFlatpakApp", item.Path, fpAppInfo.name)
-
-	homeDir := basedir.GetUserHomeDir()
-	if homeDir == "" {
-		return errors.New("get home dir failed")
-	}
-
-	userInstallation := strings.HasPrefix(item.Path, homeDir)
-
-	sysOrUser := "--user"
-	if !userInstallation {
-		// system wide installation
-		sysOrUser = "--system"
-		pkgFile := filepath.Join("/usr/share/deepin-flatpak/app/",
-			fpAppInfo.name, fpAppInfo.arch, fpAppInfo.branch, "pkg")
-		logger.Debug("pkg file:", pkgFile)
-		content, err := ioutil.ReadFile(pkgFile)
-		if err == nil {
-			pkgName := string(bytes.TrimSpace(content))
-			return m.uninstallSystemPackage(item.Name, pkgName)
-		}
-	}
-
-	ref := fmt.Sprintf("app/%s/%s/%s", fpAppInfo.name, fpAppInfo.arch, fpAppInfo.branch)
-	logger.Debug("ref:", ref)
-	cmd := exec.Command("flatpak", sysOrUser, "uninstall", ref)
-	err := cmd.Run()
-	go m.notifyUninstallDone(item, err == nil)
-	return err
-}
-
-func (m *Manager) uninstall(id string) error {
-	item := m.getItemById(id)
-	if item == nil {
-		logger.Warning("RequestUninstall failed", errorInvalidID)
-		return errorInvalidID
-	}
-
+// getUninstallBackend 根据应用的安装方式选择卸载的后端
+func (m *Manager) getUninstallBackend(item *Item) (uninstallBackend, error) {
 	appInfo, err := desktopappinfo.NewDesktopAppInfoFromFile(item.Path)
 	if err != nil {
-		return err
-	}
-
-	err = m.appsObj.UninstallHints(0, []string{item.Path})
-	if err != nil {
-		logger.Warning("failed to call apps UninstallHints:", err)
+		return nil, err
 	}
 
 	// uninstall system package
@@ -225,42 +167,156 @@ func (m *Manager) uninstall(id string) error {
 		// is pkg installed?
 		installed, err := m.lastore.PackageExists(0, pkg)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if installed {
-			return m.uninstallSystemPackage(item.Name, pkg)
+			return &debBackend{m: m, jobName: item.Name, pkg: pkg}, nil
 		}
 	}
 
 	// uninstall flatpak app
 	isFlatpakApp, fpAppInfo, err := isFlatpakApp(appInfo)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if isFlatpakApp {
 		logger.Debugf("fpAppInfo: %#v", fpAppInfo)
-		return m.uninstallFlatpakApp(item, fpAppInfo)
+		return m.getFlatpakBackend(item, fpAppInfo)
 	}
 
 	// uninstall chrome shortcut
 	if isChromeShortcut(item) {
 		logger.Debug("item is chrome shortcut")
-		return m.uninstallDesktopFile(item)
+		return &localBackend{m: m, item: item, files: []string{item.Path}}, nil
 	}
 
 	// uninstall CrossOver
 	if isCrossOver(appInfo) {
 		logger.Debug("item is CrossOver")
-		return m.uninstallSystemPackage(item.Name, "crossover")
+		return &debBackend{m: m, jobName: item.Name, pkg: "crossover"}, nil
 	}
 
 	// uninstall wine app
 	if isWineApp(appInfo) {
 		logger.Debug("item is wine app")
-		return m.uninstallDeepinWineApp(item)
+		return &wineBackend{m: m, item: item}, nil
+	}
+
+	return newLocalBackend(m, item, appInfo.GetExecutable(), appInfo.GetIcon()), nil
+}
+
+func (m *Manager) getFlatpakBackend(item *Item, fpAppInfo *flatpakAppInfo) (uninstallBackend, error) {
+	homeDir := basedir.GetUserHomeDir()
+	if homeDir == "" {
+		return nil, errors.New("get home dir failed")
+	}
+
+	userInstallation := strings.HasPrefix(item.Path, homeDir)
+	if !userInstallation {
+		// system wide installation
+		pkgFile := filepath.Join("/usr/share/deepin-flatpak/app/",
+			fpAppInfo.name, fpAppInfo.arch, fpAppInfo.branch, "pkg")
+		logger.Debug("pkg file:", pkgFile)
+		content, err := ioutil.ReadFile(pkgFile)
+		if err == nil {
+			pkgName := string(bytes.TrimSpace(content))
+			return &debBackend{m: m, jobName: item.Name, pkg: pkgName}, nil
+		}
+	}
+	return &flatpakBackend{m: m, item: item, info: fpAppInfo, user: userInstallation}, nil
+}
+
+func (m *Manager) getUninstallReport(id string) (*UninstallReport, error) {
+	item := m.getItemById(id)
+	if item == nil {
+		return nil, errorInvalidID
+	}
+	backend, err := m.getUninstallBackend(item)
+	if err != nil {
+		return nil, err
+	}
+	return backend.DryRun()
+}
+
+// uninstall 卸载应用，卸载期间应用从启动器中移除，失败时恢复启动器的状态
+func (m *Manager) uninstall(id string) error {
+	item := m.getItemById(id)
+	if item == nil {
+		logger.Warning("RequestUninstall failed", errorInvalidID)
+		return errorInvalidID
+	}
+
+	m.uninstallingMu.Lock()
+	if m.uninstalling[id] {
+		m.uninstallingMu.Unlock()
+		return fmt.Errorf("%q is being uninstalled", id)
+	}
+	m.uninstalling[id] = true
+	m.uninstallingMu.Unlock()
+
+	defer func() {
+		m.uninstallingMu.Lock()
+		delete(m.uninstalling, id)
+		m.uninstallingMu.Unlock()
+	}()
+
+	backend, err := m.getUninstallBackend(item)
+	if err != nil {
+		return err
+	}
+	logger.Debugf("uninstall %q with backend %s", id, backend.Name())
+
+	err = m.appsObj.UninstallHints(0, []string{item.Path})
+	if err != nil {
+		logger.Warning("failed to call apps UninstallHints:", err)
+	}
+
+	m.emitUninstallProgress(id, 0, UninstallStatusStart)
+	m.removeItem(id)
+	m.emitItemChanged(item, AppStatusDeleted)
+
+	err = backend.Uninstall(func(progress float64) {
+		m.emitUninstallProgress(id, progress, UninstallStatusRunning)
+	})
+	if err != nil {
+		m.emitUninstallProgress(id, 0, UninstallStatusRollback)
+		m.rollbackUninstall(item)
+		return err
 	}
 
-	return m.uninstallDesktopFile(item)
+	m.removeAutostart(id)
+	if desktopappinfo.NewDesktopAppInfo(id) == nil {
+		// remove desktop file in user's desktop directory
+		err = os.Remove(appInDesktop(id))
+		if err != nil && !os.IsNotExist(err) {
+			logger.Warning(err)
+		}
+	} else {
+		// 应用的 desktop 文件还在
+		m.rollbackUninstall(item)
+	}
+	return nil
+}
+
+// rollbackUninstall 如果应用的 desktop 文件还在，把应用加回启动器
+func (m *Manager) rollbackUninstall(item *Item) {
+	if m.getItemById(item.ID) != nil {
+		return
+	}
+	_, err := os.Stat(item.Path)
+	if err != nil {
+		return
+	}
+	logger.Debugf("restore item %q", item.ID)
+	m.addItemWithLock(item)
+	m.emitItemChanged(item, AppStatusCreated)
+}
+
+func (m *Manager) emitUninstallProgress(id string, progress float64, status string) {
+	err := m.service.Emit(m, "UninstallProgress", id, progress, status)
+	if err != nil {
+		logger.Warning("emit UninstallProgress Failed:", err)
+	}
 }
 
 func queryPkgNameWithDpkg(itemPath string) (string, error) {
@@ -284,22 +340,28 @@ const (
 	JobStatusEnd     = "end"
 )
 
-func (m *Manager) uninstallSystemPackage(jobName, pkg string) error {
+const (
+	UninstallStatusStart    = "start"
+	UninstallStatusRunning  = "running"
+	UninstallStatusRollback = "rollback"
+)
+
+func (m *Manager) uninstallSystemPackage(jobName, pkg string, progress uninstallProgressFunc) error {
 	jobPath, err := m.lastore.RemovePackage(0, jobName, pkg)
 	logger.Debugf("uninstallSystemPackage pkg: %q jobPath: %q", pkg, jobPath)
 	if err != nil {
 		return err
 	}
-	return m.waitJobDone(string(jobPath))
+	return m.waitJobDone(string(jobPath), progress)
 }
 
-func (m *Manager) waitJobDone(jobPath string) error {
+func (m *Manager) waitJobDone(jobPath string, progress uninstallProgressFunc) error {
 	logger.Debug("waitJobDone", jobPath)
 	defer logger.Debug("waitJobDone end")
-	return m.monitorJobStatusChange(jobPath)
+	return m.monitorJobStatusChange(jobPath, progress)
 }
 
-func (m *Manager) monitorJobStatusChange(jobPath string) error {
+func (m *Manager) monitorJobStatusChange(jobPath string, progress uninstallProgressFunc) error {
 	sysBus, err := dbus.SystemBus()
 	if err != nil {
 		return err
@@ -330,6 +392,11 @@ func (m *Manager) monitorJobStatusChange(jobPath string) error {
 		}
 
 		props, _ := v.Body[1].(map[string]dbus.Variant)
+		if p, ok := props["Progress"]; ok {
+			if val, ok := p.Value().(float64); ok {
+				progress(val)
+			}
+		}
 		status, ok := props["Status"]
 		if !ok {
 			continue
@@ -0,0 +1,145 @@
+package launcher
+
+import (
+	"encoding/xml"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"pkg.deepin.io/lib/xdg/basedir"
+)
+
+// 每个应用最多返回的最近使用文件个数
+const recentFilesMaxLen = 10
+
+var recentlyUsedFile = filepath.Join(basedir.GetUserDataDir(), "recently-used.xbel")
+
+type recentFile struct {
+	path     string
+	mimeType string
+	modified time.Time
+}
+
+type xbelBookmark struct {
+	Href     string `xml:"href,attr"`
+	Modified string `xml:"modified,attr"`
+	MimeType struct {
+		Type string `xml:"type,attr"`
+	} `xml:"info>metadata>mime-type"`
+}
+
+type xbel struct {
+	Bookmarks []xbelBookmark `xml:"bookmark"`
+}
+
+// parseRecentlyUsed 解析 recently-used.xbel，只保留本地文件，按修改时间从新到旧排序
+func parseRecentlyUsed(r io.Reader) ([]*recentFile, error) {
+	var data xbel
+	err := xml.NewDecoder(r).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*recentFile, 0, len(data.Bookmarks))
+	for _, bookmark := range data.Bookmarks {
+		u, err := url.Parse(bookmark.Href)
+		if err != nil || u.Scheme != "file" || u.Path == "" {
+			continue
+		}
+		modified, _ := time.Parse(time.RFC3339Nano, bookmark.Modified)
+		files = append(files, &recentFile{
+			path:     u.Path,
+			mimeType: bookmark.MimeType.Type,
+			modified: modified,
+		})
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].modified.After(files[j].modified)
+	})
+	return files, nil
+}
+
+// matchMimeType 判断 mimeType 是否和 patterns 中的一个匹配，支持 "image/*" 这样的通配
+func matchMimeType(patterns []string, mimeType string) bool {
+	if mimeType == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if pattern == mimeType {
+			return true
+		}
+		if strings.HasSuffix(pattern, "/*") &&
+			strings.HasPrefix(mimeType, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterRecentFiles 返回 MIME 类型和 mimeTypes 匹配并且仍然存在的文件，最多 max 个
+func filterRecentFiles(files []*recentFile, mimeTypes []string, max int) []string {
+	var result []string
+	if len(mimeTypes) == 0 {
+		return result
+	}
+	for _, file := range files {
+		if len(result) >= max {
+			break
+		}
+		if !matchMimeType(mimeTypes, file.mimeType) {
+			continue
+		}
+		_, err := os.Stat(file.path)
+		if err != nil {
+			continue
+		}
+		result = append(result, file.path)
+	}
+	return result
+}
+
+// recentFiles 缓存 recently-used.xbel 的内容，文件修改后重新读取
+type recentFiles struct {
+	mu      sync.Mutex
+	modTime time.Time
+	files   []*recentFile
+}
+
+func (rf *recentFiles) get() []*recentFile {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	info, err := os.Stat(recentlyUsedFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warning(err)
+		}
+		rf.files = nil
+		rf.modTime = time.Time{}
+		return nil
+	}
+	if rf.files != nil && info.ModTime().Equal(rf.modTime) {
+		return rf.files
+	}
+
+	fh, err := os.Open(recentlyUsedFile)
+	if err != nil {
+		logger.Warning(err)
+		return rf.files
+	}
+	defer fh.Close()
+
+	files, err := parseRecentlyUsed(fh)
+	if err != nil {
+		logger.Warningf("failed to parse %q: %v", recentlyUsedFile, err)
+		return rf.files
+	}
+	rf.files = files
+	rf.modTime = info.ModTime()
+	return rf.files
+}
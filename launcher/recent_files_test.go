@@ -0,0 +1,81 @@
+package launcher
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRecentlyUsed = `<?xml version="1.0" encoding="UTF-8"?>
+<xbel version="1.0"
+      xmlns:bookmark="http://www.freedesktop.org/standards/desktop-bookmarks"
+      xmlns:mime="http://www.freedesktop.org/standards/shared-mime-info">
+  <bookmark href="file://%DIR%/a.txt" added="2020-05-01T08:00:00Z" modified="2020-05-01T08:00:00Z" visited="2020-05-01T08:00:00Z">
+    <info><metadata owner="http://freedesktop.org">
+      <mime:mime-type type="text/plain"/>
+    </metadata></info>
+  </bookmark>
+  <bookmark href="file://%DIR%/b%20c.png" added="2020-05-03T08:00:00.123456Z" modified="2020-05-03T08:00:00.123456Z" visited="2020-05-03T08:00:00Z">
+    <info><metadata owner="http://freedesktop.org">
+      <mime:mime-type type="image/png"/>
+    </metadata></info>
+  </bookmark>
+  <bookmark href="file://%DIR%/deleted.txt" added="2020-05-04T08:00:00Z" modified="2020-05-04T08:00:00Z" visited="2020-05-04T08:00:00Z">
+    <info><metadata owner="http://freedesktop.org">
+      <mime:mime-type type="text/plain"/>
+    </metadata></info>
+  </bookmark>
+  <bookmark href="smb://server/share/d.txt" added="2020-05-05T08:00:00Z" modified="2020-05-05T08:00:00Z" visited="2020-05-05T08:00:00Z">
+    <info><metadata owner="http://freedesktop.org">
+      <mime:mime-type type="text/plain"/>
+    </metadata></info>
+  </bookmark>
+  <bookmark href="file://%DIR%/e.txt" added="2020-05-02T08:00:00Z" modified="2020-05-02T08:00:00Z" visited="2020-05-02T08:00:00Z">
+    <info><metadata owner="http://freedesktop.org">
+      <mime:mime-type type="text/plain"/>
+    </metadata></info>
+  </bookmark>
+</xbel>
+`
+
+func Test_matchMimeType(t *testing.T) {
+	patterns := []string{"text/plain", "image/*"}
+	assert.True(t, matchMimeType(patterns, "text/plain"))
+	assert.True(t, matchMimeType(patterns, "image/png"))
+	assert.False(t, matchMimeType(patterns, "text/html"))
+	assert.False(t, matchMimeType(patterns, "imagex/png"))
+	assert.False(t, matchMimeType(patterns, ""))
+}
+
+func Test_recentFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "launcher-recent")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"a.txt", "b c.png", "e.txt"} {
+		err = ioutil.WriteFile(filepath.Join(dir, name), nil, 0644)
+		require.NoError(t, err)
+	}
+
+	content := strings.Replace(testRecentlyUsed, "%DIR%", dir, -1)
+	files, err := parseRecentlyUsed(strings.NewReader(content))
+	require.NoError(t, err)
+	require.Len(t, files, 4)
+	assert.Equal(t, filepath.Join(dir, "deleted.txt"), files[0].path)
+	assert.Equal(t, filepath.Join(dir, "b c.png"), files[1].path)
+	assert.Equal(t, "image/png", files[1].mimeType)
+
+	assert.Equal(t, []string{filepath.Join(dir, "e.txt"), filepath.Join(dir, "a.txt")},
+		filterRecentFiles(files, []string{"text/plain"}, 10))
+	assert.Equal(t, []string{filepath.Join(dir, "b c.png"), filepath.Join(dir, "e.txt")},
+		filterRecentFiles(files, []string{"text/plain", "image/*"}, 2))
+	assert.Len(t, filterRecentFiles(files, nil, 10), 0)
+
+	_, err = parseRecentlyUsed(strings.NewReader("<xbel>"))
+	assert.Error(t, err)
+}
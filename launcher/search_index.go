@@ -0,0 +1,267 @@
+package launcher
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// searchIndex 是应用搜索的倒排索引，词条来自应用的名称、通用名称、关键字、注释、桌面动作和翻译。
+// 查询中的每个词都要匹配，依次尝试完全匹配、前缀匹配、子串匹配和允许拼写错误的模糊匹配。
+
+const (
+	matchExact     = 1.0
+	matchPrefix    = 0.85
+	matchSubstring = 0.6
+	matchFuzzy     = 0.5
+	// 每个拼写错误降低的分数
+	fuzzyTypoPenalty = 0.1
+
+	// 启动次数对分数的提升，分数乘以 1 + launchBoost * ln(1 + 启动次数)
+	launchBoost = 0.2
+
+	searchResultMaxLen = 42
+)
+
+type searchField struct {
+	text   string
+	weight float64
+}
+
+type searchDoc struct {
+	id     string
+	fields []searchField
+}
+
+type searchPosting struct {
+	doc    int
+	weight float64
+}
+
+type searchIndex struct {
+	ids      []string
+	postings map[string][]searchPosting
+	// 所有词条，已排序，用于前缀和模糊匹配
+	vocab []string
+}
+
+type searchHit struct {
+	id    string
+	score float64
+}
+
+// tokenize 把文本转换为小写的词，除了按空格和标点分出的词，
+// 还把去掉空格后的整个文本作为一个词，这样可以搜索 "googlechrome"
+func tokenize(text string) []string {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if text == "" {
+		return nil
+	}
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	joined := strings.Join(words, "")
+	if len(words) > 1 && joined != "" {
+		words = append(words, joined)
+	}
+	return words
+}
+
+func newSearchIndex(docs []*searchDoc) *searchIndex {
+	idx := &searchIndex{
+		ids:      make([]string, len(docs)),
+		postings: make(map[string][]searchPosting),
+	}
+	for i, doc := range docs {
+		idx.ids[i] = doc.id
+		// 同一个文档中的词只保留最高的权重
+		weights := make(map[string]float64)
+		for _, field := range doc.fields {
+			for _, term := range tokenize(field.text) {
+				if field.weight > weights[term] {
+					weights[term] = field.weight
+				}
+			}
+		}
+		for term, weight := range weights {
+			idx.postings[term] = append(idx.postings[term], searchPosting{doc: i, weight: weight})
+		}
+	}
+	idx.vocab = make([]string, 0, len(idx.postings))
+	for term := range idx.postings {
+		idx.vocab = append(idx.vocab, term)
+	}
+	sort.Strings(idx.vocab)
+	return idx
+}
+
+// maxTypos 返回查询词允许的拼写错误个数，太短的词不做模糊匹配
+func maxTypos(query []rune) int {
+	switch n := len(query); {
+	case n < 3:
+		return 0
+	case n < 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// prefixEditDistance 返回 query 和 term 的某个前缀之间最小的编辑距离（相邻字符交换算一次），
+// 超过 max 时返回 max+1
+func prefixEditDistance(query, term []rune, max int) int {
+	n, m := len(query), len(term)
+	if m > n+max {
+		m = n + max
+	}
+	prev2 := make([]int, m+1)
+	prev := make([]int, m+1)
+	cur := make([]int, m+1)
+	for j := 0; j <= m; j++ {
+		prev[j] = j
+	}
+	for i := 1; i <= n; i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= m; j++ {
+			cost := 1
+			if query[i-1] == term[j-1] {
+				cost = 0
+			}
+			v := prev[j-1] + cost
+			if prev[j]+1 < v {
+				v = prev[j] + 1
+			}
+			if cur[j-1]+1 < v {
+				v = cur[j-1] + 1
+			}
+			if i > 1 && j > 1 && query[i-1] == term[j-2] && query[i-2] == term[j-1] &&
+				prev2[j-2]+1 < v {
+				v = prev2[j-2] + 1
+			}
+			cur[j] = v
+			if v < rowMin {
+				rowMin = v
+			}
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	result := max + 1
+	for j := 0; j <= m; j++ {
+		if prev[j] < result {
+			result = prev[j]
+		}
+	}
+	return result
+}
+
+// matchTerm 返回和查询词匹配的词条及匹配程度，fuzzy 为 false 时不做模糊匹配
+func (idx *searchIndex) matchTerm(query string, fuzzy bool) map[string]float64 {
+	result := make(map[string]float64)
+	if _, ok := idx.postings[query]; ok {
+		result[query] = matchExact
+	}
+
+	// 前缀匹配
+	start := sort.SearchStrings(idx.vocab, query)
+	for i := start; i < len(idx.vocab) && strings.HasPrefix(idx.vocab[i], query); i++ {
+		term := idx.vocab[i]
+		if term != query {
+			result[term] = matchPrefix
+		}
+	}
+
+	queryRunes := []rune(query)
+	typos := maxTypos(queryRunes)
+	for _, term := range idx.vocab {
+		if _, ok := result[term]; ok {
+			continue
+		}
+		if strings.Contains(term, query) {
+			result[term] = matchSubstring
+			continue
+		}
+		if !fuzzy || typos == 0 {
+			continue
+		}
+		termRunes := []rune(term)
+		if len(termRunes)+typos < len(queryRunes) {
+			continue
+		}
+		dist := prefixEditDistance(queryRunes, termRunes, typos)
+		if dist <= typos {
+			result[term] = matchFuzzy - fuzzyTypoPenalty*float64(dist-1)
+		}
+	}
+	return result
+}
+
+// search 返回按分数从高到低排序的结果，launchCounts 是应用的启动次数，
+// 最多返回 searchResultMaxLen 个结果
+func (idx *searchIndex) search(key string, fuzzy bool, launchCounts map[string]uint64) []searchHit {
+	queries := tokenize(key)
+	if len(queries) > 1 {
+		// 去掉 tokenize 添加的整个文本
+		queries = queries[:len(queries)-1]
+	}
+	if len(queries) == 0 {
+		return nil
+	}
+
+	var scores map[int]float64
+	for _, query := range queries {
+		termScores := make(map[int]float64)
+		for term, quality := range idx.matchTerm(query, fuzzy) {
+			for _, p := range idx.postings[term] {
+				score := quality * p.weight
+				if score > termScores[p.doc] {
+					termScores[p.doc] = score
+				}
+			}
+		}
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		// 每个查询词都要匹配
+		for doc, score := range scores {
+			termScore, ok := termScores[doc]
+			if !ok {
+				delete(scores, doc)
+				continue
+			}
+			scores[doc] = score + termScore
+		}
+	}
+
+	hits := make([]searchHit, 0, len(scores))
+	for doc, score := range scores {
+		id := idx.ids[doc]
+		if count := launchCounts[id]; count > 0 {
+			score *= 1 + launchBoost*math.Log1p(float64(count))
+		}
+		hits = append(hits, searchHit{id: id, score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].id < hits[j].id
+	})
+	if len(hits) > searchResultMaxLen {
+		hits = hits[:searchResultMaxLen]
+	}
+	return hits
+}
+
+func getSearchHitIds(hits []searchHit) []string {
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.id
+	}
+	return ids
+}
@@ -0,0 +1,184 @@
+package launcher
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"pkg.deepin.io/lib/appinfo/desktopappinfo"
+)
+
+func Test_tokenize(t *testing.T) {
+	assert.Nil(t, tokenize("  "))
+	assert.Equal(t, []string{"firefox"}, tokenize("Firefox"))
+	assert.Equal(t, []string{"google", "chrome", "googlechrome"}, tokenize("Google Chrome"))
+	assert.Equal(t, []string{"new", "private", "window", "newprivatewindow"}, tokenize("New Private-Window"))
+	assert.Equal(t, []string{"网页浏览器"}, tokenize("网页浏览器"))
+}
+
+func Test_prefixEditDistance(t *testing.T) {
+	dist := func(query, term string, max int) int {
+		return prefixEditDistance([]rune(query), []rune(term), max)
+	}
+	assert.Equal(t, 0, dist("fire", "firefox", 2))
+	assert.Equal(t, 1, dist("fierfox", "firefox", 2))
+	assert.Equal(t, 1, dist("firefix", "firefox", 2))
+	assert.Equal(t, 1, dist("frefox", "firefox", 2))
+	assert.Equal(t, 2, dist("fjrefix", "firefox", 2))
+	assert.Equal(t, 3, dist("abcdef", "firefox", 2))
+}
+
+func newTestSearchIndex() *searchIndex {
+	return newSearchIndex([]*searchDoc{
+		{id: "firefox", fields: []searchField{
+			{"Firefox Web Browser", nameScore},
+			{"Web Browser", genericNameScore},
+			{"Internet", keywordScore},
+			{"New Private Window", actionScore},
+		}},
+		{id: "deepin-terminal", fields: []searchField{
+			{"Terminal", nameScore},
+			{"shell", keywordScore},
+			{"Use the command line", commentScore},
+		}},
+		{id: "deepin-editor", fields: []searchField{
+			{"Text Editor", nameScore},
+			{"文本编辑器", translationScore},
+			{"Edit text files", commentScore},
+		}},
+		{id: "gedit", fields: []searchField{
+			{"gedit", nameScore},
+			{"Text Editor", genericNameScore},
+		}},
+	})
+}
+
+func Test_searchIndex(t *testing.T) {
+	idx := newTestSearchIndex()
+
+	assert.Nil(t, idx.search("", true, nil))
+	assert.Equal(t, []string{"firefox"}, getSearchHitIds(idx.search("fire", false, nil)))
+	assert.Equal(t, []string{"firefox"}, getSearchHitIds(idx.search("private window", false, nil)))
+	assert.Equal(t, []string{"deepin-terminal"}, getSearchHitIds(idx.search("shell", false, nil)))
+	assert.Equal(t, []string{"deepin-editor"}, getSearchHitIds(idx.search("编辑", false, nil)))
+	assert.Equal(t, []string{"deepin-terminal"}, getSearchHitIds(idx.search("command", false, nil)))
+
+	// 所有查询词都要匹配
+	assert.Len(t, idx.search("text shell", true, nil), 0)
+
+	// 拼写错误只在模糊匹配时找到
+	assert.Len(t, idx.search("fierfox", false, nil), 0)
+	assert.Equal(t, []string{"firefox"}, getSearchHitIds(idx.search("fierfox", true, nil)))
+	assert.Equal(t, []string{"deepin-terminal"}, getSearchHitIds(idx.search("termnal", true, nil)))
+
+	// 名称的权重比通用名称高
+	assert.Equal(t, []string{"deepin-editor", "gedit"}, getSearchHitIds(idx.search("editor", true, nil)))
+	// 启动次数多的应用靠前
+	launchCounts := map[string]uint64{"gedit": 20}
+	assert.Equal(t, []string{"gedit", "deepin-editor"},
+		getSearchHitIds(idx.search("editor", true, launchCounts)))
+}
+
+func Test_searchIndexMaxLen(t *testing.T) {
+	var docs []*searchDoc
+	for i := 0; i < searchResultMaxLen*2; i++ {
+		docs = append(docs, &searchDoc{
+			id:     fmt.Sprintf("app%d", i),
+			fields: []searchField{{fmt.Sprintf("App %d", i), nameScore}},
+		})
+	}
+	idx := newSearchIndex(docs)
+	assert.Len(t, idx.search("app", true, nil), searchResultMaxLen)
+}
+
+var benchmarkWords = []string{
+	"audio", "browser", "calculator", "calendar", "camera", "chat", "clock", "compiler",
+	"contacts", "designer", "disk", "document", "download", "editor", "email", "file",
+	"game", "image", "manager", "map", "monitor", "music", "network", "notes", "office",
+	"player", "presentation", "printer", "reader", "recorder", "scanner", "screenshot",
+	"settings", "spreadsheet", "store", "system", "terminal", "translator", "video", "viewer",
+}
+
+// writeBenchmarkDesktopFiles 在 dir 中生成 n 个 desktop 文件
+func writeBenchmarkDesktopFiles(b *testing.B, dir string, n int) []string {
+	files := make([]string, n)
+	for i := 0; i < n; i++ {
+		w1 := benchmarkWords[i%len(benchmarkWords)]
+		w2 := benchmarkWords[(i/len(benchmarkWords))%len(benchmarkWords)]
+		content := fmt.Sprintf(`[Desktop Entry]
+Type=Application
+Name=%s %s %d
+Name[zh_CN]=应用 %d
+GenericName=%s
+Comment=A %s for %s
+Keywords=%s;%s;
+Exec=/usr/bin/true %%F
+Icon=application-x-executable
+Categories=Utility;
+Actions=new-window;
+
+[Desktop Action new-window]
+Name=New %s Window
+Exec=/usr/bin/true --new-window
+`, w1, w2, i, i, w1, w1, w2, w2, w1, w1)
+		files[i] = filepath.Join(dir, fmt.Sprintf("app%d.desktop", i))
+		err := ioutil.WriteFile(files[i], []byte(content), 0644)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	return files
+}
+
+func loadBenchmarkSearchDocs(b *testing.B, n int) []*searchDoc {
+	dir, err := ioutil.TempDir("", "launcher-search")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var docs []*searchDoc
+	for _, file := range writeBenchmarkDesktopFiles(b, dir, n) {
+		appInfo, err := desktopappinfo.NewDesktopAppInfoFromFile(file)
+		if err != nil {
+			b.Fatal(err)
+		}
+		item := NewItemWithDesktopAppInfo(appInfo)
+		item.ID = appInfo.GetId()
+		item.setPinyin(true)
+		docs = append(docs, item.getSearchDoc(false))
+	}
+	return docs
+}
+
+func BenchmarkNewSearchIndex(b *testing.B) {
+	docs := loadBenchmarkSearchDocs(b, 5000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		newSearchIndex(docs)
+	}
+}
+
+func benchmarkSearch(b *testing.B, key string, fuzzy bool) {
+	idx := newSearchIndex(loadBenchmarkSearchDocs(b, 5000))
+	launchCounts := map[string]uint64{"app1": 10, "app100": 3}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx.search(key, fuzzy, launchCounts)
+	}
+}
+
+func BenchmarkSearchPrefix(b *testing.B) {
+	benchmarkSearch(b, "brow", false)
+}
+
+func BenchmarkSearchMultiWords(b *testing.B) {
+	benchmarkSearch(b, "music player", false)
+}
+
+func BenchmarkSearchFuzzy(b *testing.B) {
+	benchmarkSearch(b, "calcluator", true)
+}
@@ -0,0 +1,303 @@
+package launcher
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"pkg.deepin.io/lib/xdg/basedir"
+)
+
+const (
+	uninstallBackendDeb     = "deb"
+	uninstallBackendFlatpak = "flatpak"
+	uninstallBackendLocal   = "local"
+	uninstallBackendWine    = "wine"
+)
+
+// UninstallReport 是卸载前的预览，列出卸载时会删除的软件包和文件
+type UninstallReport struct {
+	Backend string
+	// deb 软件包或者 flatpak 应用的 ref
+	Packages []string
+	// 因为依赖关系会一起删除的软件包
+	Dependencies []string
+	Files        []string
+}
+
+// uninstallProgressFunc 报告卸载进度，progress 的范围是 0 ~ 1
+type uninstallProgressFunc func(progress float64)
+
+type uninstallBackend interface {
+	Name() string
+	// DryRun 返回卸载时会删除的内容，不做任何修改
+	DryRun() (*UninstallReport, error)
+	// Uninstall 卸载应用，失败时不能留下删除了一半的文件
+	Uninstall(progress uninstallProgressFunc) error
+}
+
+// debBackend 通过 lastore 卸载系统软件包
+type debBackend struct {
+	m       *Manager
+	jobName string
+	pkg     string
+}
+
+func (b *debBackend) Name() string {
+	return uninstallBackendDeb
+}
+
+func (b *debBackend) DryRun() (*UninstallReport, error) {
+	report := &UninstallReport{
+		Backend:  b.Name(),
+		Packages: []string{b.pkg},
+	}
+
+	out, err := exec.Command("apt-get", "-s", "remove", b.pkg).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate removing %q: %v", b.pkg, err)
+	}
+	for _, pkg := range parseAptSimulateRemove(out) {
+		if pkg != b.pkg {
+			report.Dependencies = append(report.Dependencies, pkg)
+		}
+	}
+
+	for _, pkg := range report.Packages {
+		files, err := listPkgFiles(pkg)
+		if err != nil {
+			return nil, err
+		}
+		report.Files = append(report.Files, files...)
+	}
+	return report, nil
+}
+
+func (b *debBackend) Uninstall(progress uninstallProgressFunc) error {
+	return b.m.uninstallSystemPackage(b.jobName, b.pkg, progress)
+}
+
+// parseAptSimulateRemove 从 apt-get -s remove 的输出中解析出会删除的软件包，
+// 删除的行形如 "Remv pkg [version]"
+func parseAptSimulateRemove(out []byte) []string {
+	var result []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "Remv" {
+			result = append(result, fields[1])
+		}
+	}
+	return result
+}
+
+// listPkgFiles 返回软件包中的普通文件，不包括目录
+func listPkgFiles(pkg string) ([]string, error) {
+	out, err := exec.Command("dpkg", "-L", pkg).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files of %q: %v", pkg, err)
+	}
+	var result []string
+	for _, file := range parseDpkgList(out) {
+		info, err := os.Lstat(file)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		result = append(result, file)
+	}
+	return result, nil
+}
+
+func parseDpkgList(out []byte) []string {
+	var result []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "/") || line == "/." {
+			continue
+		}
+		result = append(result, line)
+	}
+	return result
+}
+
+// flatpakBackend 卸载用户或系统安装的 flatpak 应用
+type flatpakBackend struct {
+	m    *Manager
+	item *Item
+	info *flatpakAppInfo
+	user bool
+}
+
+func (b *flatpakBackend) Name() string {
+	return uninstallBackendFlatpak
+}
+
+func (b *flatpakBackend) ref() string {
+	return fmt.Sprintf("app/%s/%s/%s", b.info.name, b.info.arch, b.info.branch)
+}
+
+func (b *flatpakBackend) installation() string {
+	if b.user {
+		return "--user"
+	}
+	return "--system"
+}
+
+func (b *flatpakBackend) DryRun() (*UninstallReport, error) {
+	report := &UninstallReport{
+		Backend:  b.Name(),
+		Packages: []string{b.ref()},
+	}
+	out, err := exec.Command(flatpakBin, b.installation(), "info", "--show-location", b.ref()).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location of %q: %v", b.ref(), err)
+	}
+	location := strings.TrimSpace(string(out))
+	if location != "" {
+		report.Files = append(report.Files, location)
+	}
+	return report, nil
+}
+
+func (b *flatpakBackend) Uninstall(progress uninstallProgressFunc) error {
+	logger.Debug("uninstall flatpak app", b.item.Path, b.ref())
+	cmd := exec.Command(flatpakBin, b.installation(), "uninstall", b.ref())
+	err := cmd.Run()
+	go b.m.notifyUninstallDone(b.item, err == nil)
+	if err == nil {
+		progress(1)
+	}
+	return err
+}
+
+// localBackend 删除用户自己安装的应用，包括 desktop 文件、AppImage 文件和图标
+type localBackend struct {
+	m     *Manager
+	item  *Item
+	files []string
+}
+
+func newLocalBackend(m *Manager, item *Item, executable, icon string) *localBackend {
+	b := &localBackend{
+		m:     m,
+		item:  item,
+		files: []string{item.Path},
+	}
+	if isAppImage(executable) && isUserFile(executable) {
+		b.files = append(b.files, executable)
+	}
+	if filepath.IsAbs(icon) && isUserFile(icon) {
+		b.files = append(b.files, icon)
+	}
+	return b
+}
+
+func isAppImage(file string) bool {
+	return strings.HasSuffix(strings.ToLower(file), ".appimage")
+}
+
+// isUserFile 判断 file 是否在用户的主目录中
+func isUserFile(file string) bool {
+	homeDir := basedir.GetUserHomeDir()
+	return homeDir != "" && strings.HasPrefix(filepath.Clean(file), homeDir+"/")
+}
+
+func (b *localBackend) Name() string {
+	return uninstallBackendLocal
+}
+
+func (b *localBackend) DryRun() (*UninstallReport, error) {
+	report := &UninstallReport{Backend: b.Name()}
+	for _, file := range b.files {
+		_, err := os.Lstat(file)
+		if err == nil {
+			report.Files = append(report.Files, file)
+		}
+	}
+	return report, nil
+}
+
+func (b *localBackend) Uninstall(progress uninstallProgressFunc) error {
+	err := removeFiles(b.files, progress)
+	go b.m.notifyUninstallDone(b.item, err == nil)
+	return err
+}
+
+const uninstallBackupSuffix = ".dde-uninstall"
+
+// removeFiles 删除 files，先把文件重命名为备份，全部成功后再删除备份，
+// 有一个文件失败时把已经重命名的文件恢复
+func removeFiles(files []string, progress uninstallProgressFunc) error {
+	var renamed []string
+	rollback := func() {
+		for _, file := range renamed {
+			err := os.Rename(file+uninstallBackupSuffix, file)
+			if err != nil {
+				logger.Warning("failed to restore file:", err)
+			}
+		}
+	}
+
+	for i, file := range files {
+		_, err := os.Lstat(file)
+		if err != nil {
+			if os.IsNotExist(err) && i > 0 {
+				// 除了 desktop 文件外，其他文件不存在时跳过
+				continue
+			}
+			rollback()
+			return err
+		}
+		logger.Debugf("remove file %q", file)
+		err = os.Rename(file, file+uninstallBackupSuffix)
+		if err != nil {
+			rollback()
+			return err
+		}
+		renamed = append(renamed, file)
+		progress(float64(i+1) / float64(len(files)))
+	}
+
+	for _, file := range renamed {
+		err := os.Remove(file + uninstallBackupSuffix)
+		if err != nil {
+			logger.Warning(err)
+		}
+	}
+	return nil
+}
+
+// wineBackend 通过 deepin-wine 的脚本卸载 wine 应用
+type wineBackend struct {
+	m    *Manager
+	item *Item
+}
+
+const deepinWineUninstallScript = "/opt/deepinwine/tools/uninstall.sh"
+
+func (b *wineBackend) Name() string {
+	return uninstallBackendWine
+}
+
+func (b *wineBackend) DryRun() (*UninstallReport, error) {
+	return &UninstallReport{
+		Backend: b.Name(),
+		Files:   []string{b.item.Path},
+	}, nil
+}
+
+func (b *wineBackend) Uninstall(progress uninstallProgressFunc) error {
+	logger.Debug("uninstall deepin wine app", b.item.Path)
+	cmd := exec.Command(deepinWineUninstallScript, b.item.Path)
+	err := cmd.Run()
+	go b.m.notifyUninstallDone(b.item, err == nil)
+	if err == nil {
+		progress(1)
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package launcher
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseAptSimulateRemove(t *testing.T) {
+	out := []byte(`NOTE: This is only a simulation!
+      apt-get needs root privileges for real execution.
+Reading package lists...
+The following packages will be REMOVED:
+  deepin-music deepin-music-plugin
+0 upgraded, 0 newly installed, 2 to remove and 0 not upgraded.
+Remv deepin-music-plugin [1.0.1]
+Remv deepin-music [6.0.0]
+`)
+	assert.Equal(t, []string{"deepin-music-plugin", "deepin-music"}, parseAptSimulateRemove(out))
+	assert.Nil(t, parseAptSimulateRemove(nil))
+}
+
+func Test_parseDpkgList(t *testing.T) {
+	out := []byte(`/.
+/usr
+/usr/bin/deepin-music
+/usr/share/applications/deepin-music.desktop
+diverted by foo to: /usr/bin/foo.real
+`)
+	assert.Equal(t, []string{"/usr", "/usr/bin/deepin-music",
+		"/usr/share/applications/deepin-music.desktop"}, parseDpkgList(out))
+}
+
+func Test_isAppImage(t *testing.T) {
+	assert.True(t, isAppImage("/home/u/Applications/krita.AppImage"))
+	assert.True(t, isAppImage("/home/u/app.appimage"))
+	assert.False(t, isAppImage("/usr/bin/krita"))
+}
+
+func Test_removeFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "launcher-uninstall")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	desktopFile := filepath.Join(dir, "app.desktop")
+	appImage := filepath.Join(dir, "app.AppImage")
+	icon := filepath.Join(dir, "app.png")
+	for _, file := range []string{desktopFile, appImage, icon} {
+		err = ioutil.WriteFile(file, []byte(file), 0644)
+		require.NoError(t, err)
+	}
+
+	// 备份的位置被一个非空目录占用，重命名图标失败，前面删除的文件要恢复
+	err = os.MkdirAll(filepath.Join(icon+uninstallBackupSuffix, "x"), 0755)
+	require.NoError(t, err)
+	var progress []float64
+	err = removeFiles([]string{desktopFile, appImage, icon}, func(p float64) {
+		progress = append(progress, p)
+	})
+	assert.Error(t, err)
+	for _, file := range []string{desktopFile, appImage, icon} {
+		content, err := ioutil.ReadFile(file)
+		assert.NoError(t, err)
+		assert.Equal(t, file, string(content))
+	}
+
+	err = os.RemoveAll(icon + uninstallBackupSuffix)
+	require.NoError(t, err)
+	progress = nil
+	err = removeFiles([]string{desktopFile, appImage, icon, filepath.Join(dir, "no-such-file")},
+		func(p float64) {
+			progress = append(progress, p)
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0.25, 0.5, 0.75}, progress)
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 0)
+
+	// desktop 文件不存在时失败
+	err = removeFiles([]string{desktopFile}, func(float64) {})
+	assert.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package screensaver
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 供同一进程内的其他模块（如 session/power 的抑制器汇总）查询和强制释放 Inhibit。
+
+var (
+	_ss   *ScreenSaver
+	_ssMu sync.Mutex
+)
+
+func setScreenSaver(ss *ScreenSaver) {
+	_ssMu.Lock()
+	_ss = ss
+	_ssMu.Unlock()
+}
+
+func getScreenSaver() *ScreenSaver {
+	_ssMu.Lock()
+	defer _ssMu.Unlock()
+	return _ss
+}
+
+// InhibitorInfo 描述一个通过 Inhibit 方法抑制 Idle 计时器的请求
+type InhibitorInfo struct {
+	Cookie    uint32
+	Sender    string
+	Name      string
+	Reason    string
+	StartTime time.Time
+}
+
+// ListInhibitors 返回当前所有的 Idle 抑制请求，按 cookie 排序，模块未启动时返回 nil
+func ListInhibitors() []InhibitorInfo {
+	ss := getScreenSaver()
+	if ss == nil {
+		return nil
+	}
+
+	ss.mu.Lock()
+	result := make([]InhibitorInfo, 0, len(ss.inhibitors))
+	for _, inhibitor := range ss.inhibitors {
+		result = append(result, InhibitorInfo{
+			Cookie:    inhibitor.cookie,
+			Sender:    string(inhibitor.sender),
+			Name:      inhibitor.name,
+			Reason:    inhibitor.reason,
+			StartTime: inhibitor.startTime,
+		})
+	}
+	ss.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Cookie < result[j].Cookie
+	})
+	return result
+}
+
+// ForceUnInhibit 不检查调用者，直接取消 cookie 对应的抑制
+func ForceUnInhibit(cookie uint32) error {
+	ss := getScreenSaver()
+	if ss == nil {
+		return errors.New("screensaver is not running")
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	inhibitor, ok := ss.inhibitors[cookie]
+	if !ok {
+		return errors.New("invalid cookie")
+	}
+	logger.Infof("force un-inhibit %q, sender: %s", inhibitor.name, inhibitor.sender)
+	ss.unInhibit(cookie)
+	return nil
+}
@@ -65,6 +65,7 @@ func (m *Module) Start() error {
 	if err != nil {
 		return err
 	}
+	setScreenSaver(m.sSaver)
 
 	err = service.Export(dbusPath, m.sSaver)
 	if err != nil {
@@ -110,6 +111,7 @@ func (m *Module) Stop() error {
 	if err != nil {
 		logger.Warning(err)
 	}
+	setScreenSaver(nil)
 	m.sSaver.destroy()
 	m.sSaver = nil
 
@@ -23,6 +23,7 @@ import (
 	"errors"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/godbus/dbus"
 	ofdbus "github.com/linuxdeepin/go-dbus-factory/org.freedesktop.dbus"
@@ -37,10 +38,11 @@ import (
 var logger = log.NewLogger("daemon/screensaver")
 
 type inhibitor struct {
-	sender dbus.Sender
-	cookie uint32
-	name   string
-	reason string
+	sender    dbus.Sender
+	cookie    uint32
+	name      string
+	reason    string
+	startTime time.Time
 }
 
 type ScreenSaver struct {
@@ -99,10 +101,11 @@ func (ss *ScreenSaver) Inhibit(sender dbus.Sender, name, reason string) (uint32,
 	ss.counter++
 
 	ss.inhibitors[ss.counter] = inhibitor{
-		cookie: ss.counter,
-		name:   name,
-		reason: reason,
-		sender: sender,
+		cookie:    ss.counter,
+		name:      name,
+		reason:    reason,
+		sender:    sender,
+		startTime: time.Now(),
 	}
 
 	if len(ss.inhibitors) == 1 {
@@ -0,0 +1,341 @@
+package power
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus"
+	x "github.com/linuxdeepin/go-x11-client"
+	"github.com/linuxdeepin/go-x11-client/util/wm/ewmh"
+	"pkg.deepin.io/dde/daemon/screensaver"
+	"pkg.deepin.io/lib/procfs"
+)
+
+// 汇总阻止空闲、锁屏、待机和合盖操作的来源：
+// screensaver 模块的 Inhibit 请求、logind 的 inhibitor 和全屏白名单应用。
+
+const (
+	inhibitSourceScreenSaver = "screensaver"
+	inhibitSourceLogind      = "logind"
+	inhibitSourceFullscreen  = "fullscreen"
+)
+
+const (
+	inhibitWhatIdle    = "idle"
+	inhibitWhatLock    = "lock"
+	inhibitWhatSuspend = "suspend"
+	inhibitWhatLid     = "lid"
+)
+
+const (
+	dockServiceName = "com.deepin.dde.daemon.Dock"
+	dockPath        = "/com/deepin/dde/daemon/Dock"
+	dockInterface   = "com.deepin.dde.daemon.Dock"
+)
+
+type InhibitorInfo struct {
+	Id     string
+	Source string
+	// 被阻止的操作，取值为 idle、lock、suspend、lid，logind 中其他类型保持原样
+	What []string
+	// block 或 delay，非 logind 来源为 block
+	Mode  string
+	AppId string
+	Who   string
+	Why   string
+	Pid   uint32
+	// Unix 时间戳，logind 不提供开始时间，使用首次发现的时间
+	StartTime int64
+	// 是否可以通过 ForceReleaseInhibitor 释放
+	Releasable bool
+}
+
+type inhibitorRegistry struct {
+	mu sync.Mutex
+	// key 为 inhibitor id，记录没有开始时间的 inhibitor 首次被发现的时间
+	firstSeen map[string]time.Time
+}
+
+func newInhibitorRegistry() *inhibitorRegistry {
+	return &inhibitorRegistry{
+		firstSeen: make(map[string]time.Time),
+	}
+}
+
+// track 返回 id 首次被发现的时间，并清除已不存在的记录
+func (r *inhibitorRegistry) track(ids []string, now time.Time) map[string]time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make(map[string]time.Time, len(ids))
+	for _, id := range ids {
+		t, ok := r.firstSeen[id]
+		if !ok {
+			t = now
+		}
+		result[id] = t
+	}
+	r.firstSeen = result
+
+	copied := make(map[string]time.Time, len(result))
+	for id, t := range result {
+		copied[id] = t
+	}
+	return copied
+}
+
+func getScreenSaverInhibitorId(cookie uint32) string {
+	return fmt.Sprintf("%s:%d", inhibitSourceScreenSaver, cookie)
+}
+
+func getLogindInhibitorId(pid uint32, what, mode string) string {
+	return fmt.Sprintf("%s:%d:%s:%s", inhibitSourceLogind, pid, what, mode)
+}
+
+func getFullscreenInhibitorId(win x.Window) string {
+	return fmt.Sprintf("%s:%d", inhibitSourceFullscreen, win)
+}
+
+// parseInhibitorId 把 id 拆分为来源和来源内的 key
+func parseInhibitorId(id string) (source, key string, err error) {
+	parts := strings.SplitN(id, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid inhibitor id %q", id)
+	}
+	switch parts[0] {
+	case inhibitSourceScreenSaver, inhibitSourceLogind, inhibitSourceFullscreen:
+		return parts[0], parts[1], nil
+	}
+	return "", "", fmt.Errorf("unknown inhibitor source %q", parts[0])
+}
+
+// convertLogindWhat 把 logind 的 what（冒号分隔）转换为统一的操作名
+func convertLogindWhat(what string) []string {
+	var result []string
+	add := func(v string) {
+		for _, item := range result {
+			if item == v {
+				return
+			}
+		}
+		result = append(result, v)
+	}
+	for _, item := range strings.Split(what, ":") {
+		switch item {
+		case "":
+			continue
+		case "sleep", "handle-suspend-key", "handle-hibernate-key":
+			add(inhibitWhatSuspend)
+		case "idle":
+			add(inhibitWhatIdle)
+		case "handle-lid-switch":
+			add(inhibitWhatLid)
+		default:
+			add(item)
+		}
+	}
+	return result
+}
+
+func sortInhibitors(inhibitors []InhibitorInfo) {
+	sort.SliceStable(inhibitors, func(i, j int) bool {
+		a, b := inhibitors[i], inhibitors[j]
+		if a.StartTime != b.StartTime {
+			return a.StartTime < b.StartTime
+		}
+		return a.Id < b.Id
+	})
+}
+
+func (m *Manager) listInhibitors() []InhibitorInfo {
+	var result []InhibitorInfo
+	var untimed []int
+
+	for _, item := range screensaver.ListInhibitors() {
+		info := InhibitorInfo{
+			Id:         getScreenSaverInhibitorId(item.Cookie),
+			Source:     inhibitSourceScreenSaver,
+			What:       []string{inhibitWhatIdle, inhibitWhatLock},
+			Mode:       "block",
+			Who:        item.Name,
+			Why:        item.Reason,
+			StartTime:  item.StartTime.Unix(),
+			Releasable: true,
+		}
+		pid, err := m.service.GetConnPID(item.Sender)
+		if err == nil {
+			info.Pid = pid
+		} else {
+			logger.Debug(err)
+		}
+		result = append(result, info)
+	}
+
+	logindInhibitors, err := m.helper.LoginManager.ListInhibitors(0)
+	if err != nil {
+		logger.Warning("failed to list logind inhibitors:", err)
+	}
+	selfPid := uint32(os.Getpid())
+	for _, item := range logindInhibitors {
+		untimed = append(untimed, len(result))
+		result = append(result, InhibitorInfo{
+			Id:     getLogindInhibitorId(item.PID, item.What, item.Mode),
+			Source: inhibitSourceLogind,
+			What:   convertLogindWhat(item.What),
+			Mode:   item.Mode,
+			Who:    item.Who,
+			Why:    item.Why,
+			Pid:    item.PID,
+			// 只有本进程持有的 fd 可以释放
+			Releasable: item.PID == selfPid,
+		})
+	}
+
+	psp := m.getPowerSavePlan()
+	if psp != nil {
+		win, pid, matched, err := psp.getFullscreenInhibitWindow()
+		if err != nil {
+			logger.Debug(err)
+		} else if win != 0 && !psp.isFullscreenWindowIgnored(win) {
+			untimed = append(untimed, len(result))
+			result = append(result, InhibitorInfo{
+				Id:         getFullscreenInhibitorId(win),
+				Source:     inhibitSourceFullscreen,
+				What:       []string{inhibitWhatIdle, inhibitWhatLock},
+				Mode:       "block",
+				Who:        matched,
+				Why:        "fullscreen window in fullscreen-workaround-app-list",
+				Pid:        pid,
+				AppId:      m.getWindowAppId(win),
+				Releasable: true,
+			})
+		}
+	}
+
+	ids := make([]string, len(untimed))
+	for i, idx := range untimed {
+		ids[i] = result[idx].Id
+	}
+	firstSeen := m.inhibitorRegistry.track(ids, time.Now())
+	for _, idx := range untimed {
+		result[idx].StartTime = firstSeen[result[idx].Id].Unix()
+	}
+
+	for i := range result {
+		if result[i].AppId == "" {
+			result[i].AppId = m.getPidAppId(result[i].Pid)
+		}
+	}
+
+	sortInhibitors(result)
+	return result
+}
+
+func (m *Manager) forceReleaseInhibitor(id string) error {
+	source, key, err := parseInhibitorId(id)
+	if err != nil {
+		return err
+	}
+
+	switch source {
+	case inhibitSourceScreenSaver:
+		cookie, err := strconv.ParseUint(key, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid inhibitor id %q", id)
+		}
+		return screensaver.ForceUnInhibit(uint32(cookie))
+
+	case inhibitSourceLogind:
+		return m.releaseLogindInhibitor(id)
+
+	case inhibitSourceFullscreen:
+		win, err := strconv.ParseUint(key, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid inhibitor id %q", id)
+		}
+		psp := m.getPowerSavePlan()
+		if psp == nil {
+			return errors.New("power save plan is not running")
+		}
+		psp.ignoreFullscreenWindow(x.Window(win))
+		return nil
+	}
+	return fmt.Errorf("invalid inhibitor id %q", id)
+}
+
+// releaseLogindInhibitor 关闭本进程持有的 logind inhibitor fd，其他进程的 fd 无法释放
+func (m *Manager) releaseLogindInhibitor(id string) error {
+	selfPid := uint32(os.Getpid())
+	sleepId := getLogindInhibitorId(selfPid, "sleep", "delay")
+	logindId := getLogindInhibitorId(selfPid, "handle-power-key:handle-lid-switch", "block")
+
+	switch id {
+	case sleepId:
+		if m.inhibitor == nil {
+			return errors.New("sleep inhibitor is not running")
+		}
+		return m.inhibitor.unblock()
+	case logindId:
+		m.permitLogind()
+		return nil
+	}
+	return fmt.Errorf("inhibitor %q is not held by this process", id)
+}
+
+func (m *Manager) getPowerSavePlan() *powerSavePlan {
+	psp, _ := m.submodules[submodulePSP].(*powerSavePlan)
+	return psp
+}
+
+// getWindowAppId 从任务栏获取窗口所属应用的 id，与任务栏的识别结果保持一致
+func (m *Manager) getWindowAppId(win x.Window) string {
+	if win == 0 {
+		return ""
+	}
+	obj := m.service.Conn().Object(dockServiceName, dockPath)
+	var appId string
+	err := obj.Call(dockInterface+".GetWindowAppId", dbus.FlagNoAutoStart,
+		uint32(win)).Store(&appId)
+	if err != nil {
+		logger.Debug("failed to get window app id from dock:", err)
+		return ""
+	}
+	return appId
+}
+
+// getPidAppId 优先用进程的窗口通过任务栏识别应用，没有窗口时使用可执行文件名
+func (m *Manager) getPidAppId(pid uint32) string {
+	if pid == 0 {
+		return ""
+	}
+
+	conn := m.helper.xConn
+	clientList, err := ewmh.GetClientList(conn).Reply(conn)
+	if err == nil {
+		for _, win := range clientList {
+			winPid, err := ewmh.GetWMPid(conn, win).Reply(conn)
+			if err != nil || winPid != pid {
+				continue
+			}
+			appId := m.getWindowAppId(win)
+			if appId != "" {
+				return appId
+			}
+		}
+	} else {
+		logger.Debug(err)
+	}
+
+	exe, err := procfs.Process(pid).Exe()
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(exe)
+}
@@ -0,0 +1,59 @@
+package power
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_convertLogindWhat(t *testing.T) {
+	Convey("convertLogindWhat", t, func(c C) {
+		c.So(convertLogindWhat("sleep"), ShouldResemble, []string{"suspend"})
+		c.So(convertLogindWhat("handle-power-key:handle-lid-switch"), ShouldResemble,
+			[]string{"handle-power-key", "lid"})
+		c.So(convertLogindWhat("sleep:idle:handle-suspend-key"), ShouldResemble,
+			[]string{"suspend", "idle"})
+		c.So(convertLogindWhat(""), ShouldBeNil)
+	})
+}
+
+func Test_parseInhibitorId(t *testing.T) {
+	Convey("parseInhibitorId", t, func(c C) {
+		source, key, err := parseInhibitorId(getScreenSaverInhibitorId(3))
+		c.So(err, ShouldBeNil)
+		c.So(source, ShouldEqual, inhibitSourceScreenSaver)
+		c.So(key, ShouldEqual, "3")
+
+		source, key, err = parseInhibitorId(getLogindInhibitorId(100, "sleep", "delay"))
+		c.So(err, ShouldBeNil)
+		c.So(source, ShouldEqual, inhibitSourceLogind)
+		c.So(key, ShouldEqual, "100:sleep:delay")
+
+		_, _, err = parseInhibitorId("fullscreen:")
+		c.So(err, ShouldNotBeNil)
+		_, _, err = parseInhibitorId("unknown:1")
+		c.So(err, ShouldNotBeNil)
+	})
+}
+
+func Test_inhibitorRegistryTrack(t *testing.T) {
+	Convey("inhibitorRegistry track", t, func(c C) {
+		r := newInhibitorRegistry()
+		t0 := time.Unix(1000, 0)
+		t1 := time.Unix(2000, 0)
+
+		seen := r.track([]string{"a", "b"}, t0)
+		c.So(seen["a"], ShouldEqual, t0)
+		c.So(seen["b"], ShouldEqual, t0)
+
+		seen = r.track([]string{"a", "c"}, t1)
+		c.So(seen["a"], ShouldEqual, t0)
+		c.So(seen["c"], ShouldEqual, t1)
+		c.So(seen, ShouldNotContainKey, "b")
+
+		// b 消失后再次出现，视为新的 inhibitor
+		seen = r.track([]string{"b"}, t1)
+		c.So(seen["b"], ShouldEqual, t1)
+	})
+}
@@ -47,6 +47,13 @@ type Manager struct {
 	inhibitor            *sleepInhibitor
 	inhibitFd            dbus.UnixFD
 	systemPower          *systemPower.Power
+	inhibitorRegistry    *inhibitorRegistry
+	powerConsumers       *powerConsumersSampler
+
+	// 保护 warnLevelCountTicker，宽限期可以通过 DismissWarnLevelAction 取消
+	warnLevelTickerMu sync.Mutex
+	// 警告动作降低亮度前的亮度，电量恢复后还原
+	warnDimBrightness map[string]float64
 
 	PropsMu sync.RWMutex
 	// 是否有盖子，一般笔记本电脑才有
@@ -136,7 +143,12 @@ type Manager struct {
 
 	// nolint
 	methods *struct {
-		SetPrepareSuspend func() `in:"suspendState"`
+		SetPrepareSuspend      func() `in:"suspendState"`
+		ListInhibitors         func() `out:"inhibitors"`
+		ForceReleaseInhibitor  func() `in:"id"`
+		ListSleepHooks         func() `out:"hooks"`
+		GetPowerConsumers      func() `in:"period" out:"consumers"`
+		DismissWarnLevelAction func()
 	}
 }
 
@@ -153,6 +165,9 @@ func newManager(service *dbusutil.Service) (*Manager, error) {
 	m.sessionSigLoop = dbusutil.NewSignalLoop(sessionBus, 10)
 	m.systemSigLoop = dbusutil.NewSignalLoop(systemBus, 10)
 	m.inhibitFd = -1
+	m.inhibitorRegistry = newInhibitorRegistry()
+	m.powerConsumers = newPowerConsumersSampler(&procfsProcessSource{dir: "/proc"},
+		&dockWindowAppSource{m: m})
 	m.prepareSuspend = suspendStateUnknown
 
 	m.syncConfig = dsync.NewConfig("power", &syncConfig{m: m}, m.sessionSigLoop, dbusPath, logger)
@@ -241,6 +256,7 @@ func (m *Manager) init() {
 	}
 
 	m.helper.initSignalExt(m.systemSigLoop, m.sessionSigLoop)
+	m.initPowerProfileHandler()
 
 	// init sleep inhibitor
 	m.inhibitor = newSleepInhibitor(m.helper.LoginManager, m.helper.Daemon)
@@ -323,6 +339,7 @@ func (m *Manager) init() {
 	m.initSubmodules()
 	m.startSubmodules()
 	m.inhibitLogind()
+	m.powerConsumers.start()
 }
 
 func (m *Manager) isX11SessionActive() (bool, error) {
@@ -330,6 +347,7 @@ func (m *Manager) isX11SessionActive() (bool, error) {
 }
 
 func (m *Manager) destroy() {
+	m.powerConsumers.stop()
 	m.destroySubmodules()
 	m.releaseAmbientLight()
 	m.permitLogind()
@@ -24,7 +24,7 @@ import (
 
 	dbus "github.com/godbus/dbus"
 	"pkg.deepin.io/dde/api/soundutils"
-	. "pkg.deepin.io/lib/gettext"
+	"pkg.deepin.io/dde/daemon/session/power/sleephook"
 )
 
 // nolint
@@ -84,12 +84,17 @@ func (m *Manager) initOnBatteryChangedHandler() {
 	}
 }
 
+// handleBeforeSuspend 在 logind 的 delay inhibitor 期间执行，先锁屏，
+// 待机前的钩子只能使用剩下的时间，否则可能锁屏界面还没有显示就待机了
 func (m *Manager) handleBeforeSuspend() {
+	start := time.Now()
 	m.setPrepareSuspend(suspendStatePrepare)
 	logger.Debug("before sleep")
 	if m.SleepLock.Get() {
 		m.lockWaitShow(5*time.Second, false)
 	}
+	budget := getInhibitDelayMax() - sleepHooksDelayMargin - time.Since(start)
+	runSleepHooksWithin(sleephook.StagePreSuspend, budget)
 }
 
 func (m *Manager) handleWakeup() {
@@ -126,6 +131,7 @@ func (m *Manager) handleWakeup() {
 	}()
 
 	playSound(soundutils.EventWakeup)
+	go runSleepHooks(sleephook.StagePostResume)
 }
 
 func (m *Manager) handleBatteryDisplayUpdate() {
@@ -185,57 +191,45 @@ func (m *Manager) handleBatteryDisplayUpdate() {
 	}
 }
 
-func (m *Manager) disableWarnLevelCountTicker() {
+func (m *Manager) disableWarnLevelCountTicker() bool {
+	m.warnLevelTickerMu.Lock()
+	defer m.warnLevelTickerMu.Unlock()
 	if m.warnLevelCountTicker != nil {
 		m.warnLevelCountTicker.Stop()
 		m.warnLevelCountTicker = nil
+		return true
 	}
+	return false
 }
 
+// handleWarnLevelChanged 按照警告级别的动作策略处理，默认只发通知，Action 级别 5 秒后待机
 func (m *Manager) handleWarnLevelChanged(level WarnLevel) {
 	logger.Debug("handleWarnLevelChanged")
 	m.disableWarnLevelCountTicker()
 
-	switch level {
-	case WarnLevelAction:
-		playSound(soundutils.EventBatteryLow)
-		m.sendNotify(iconBatteryLow, "",
-			Tr("Battery critically low"))
-
-		m.warnLevelCountTicker = newCountTicker(time.Second, func(count int) {
-			if count == 3 {
-				// after 3 seconds, lock and then show dde low power
-				go func() {
-					if m.SleepLock.Get() {
-						m.lockWaitShow(5*time.Second, false)
-					}
-					doShowDDELowPower()
-				}()
-			} else if count == 5 {
-				// after 5 seconds, force suspend
-				m.disableWarnLevelCountTicker()
-				m.doSuspend()
-			}
-		})
-
-	case WarnLevelCritical:
-		playSound(soundutils.EventBatteryLow)
-		m.sendNotify(iconBatteryLow, "",
-			Tr("Battery low, please plug in"))
-
-	case WarnLevelDanger:
-		playSound(soundutils.EventBatteryLow)
-		m.sendNotify(iconBatteryLow, "",
-			Tr("Battery low, please plug in"))
-
-	case WarnLevelLow:
-		playSound(soundutils.EventBatteryLow)
-		m.sendNotify(iconBatteryLow, "",
-			Tr("Battery low, please plug in"))
-
-	case WarnLevelNone:
+	if level == WarnLevelNone {
 		logger.Debug("Power sufficient")
 		doCloseDDELowPower()
 		// 由 低电量 到 电量充足，必然需要有线电源插入
+		m.restoreWarnDim()
+		return
+	}
+
+	action := m.warnLevelConfig.getAction(level)
+	if action == nil {
+		return
+	}
+	playSound(soundutils.EventBatteryLow)
+	if action.Notify {
+		m.sendNotify(iconBatteryLow, "", getWarnLevelNotifyText(level, action))
+	}
+	if action.PowerSave {
+		m.enableWarnPowerSave()
+	}
+	if action.DimPercent > 0 {
+		m.doWarnDim(action.DimPercent)
+	}
+	if action.Action != warnActionNone {
+		m.startWarnLevelAction(level, action)
 	}
 }
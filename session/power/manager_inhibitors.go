@@ -0,0 +1,30 @@
+package power
+
+import (
+	"encoding/json"
+
+	"github.com/godbus/dbus"
+	"pkg.deepin.io/lib/dbusutil"
+)
+
+// ListInhibitors 返回当前阻止空闲、锁屏、待机或合盖操作的所有来源，结果为 InhibitorInfo 数组的 JSON，
+// 包括 screensaver 的 Inhibit 请求、logind 的 inhibitor 和全屏白名单应用。
+func (m *Manager) ListInhibitors() (string, *dbus.Error) {
+	data, err := json.Marshal(m.listInhibitors())
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return string(data), nil
+}
+
+// ForceReleaseInhibitor 强制释放 id 对应的抑制，id 来自 ListInhibitors 的结果。
+// 其他进程持有的 logind inhibitor 无法释放。
+func (m *Manager) ForceReleaseInhibitor(sender dbus.Sender, id string) *dbus.Error {
+	logger.Infof("sender %s force release inhibitor %q", sender, id)
+	err := m.forceReleaseInhibitor(id)
+	if err != nil {
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
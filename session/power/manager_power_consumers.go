@@ -0,0 +1,53 @@
+package power
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/godbus/dbus"
+	"github.com/linuxdeepin/go-x11-client/util/wm/ewmh"
+	"pkg.deepin.io/lib/dbusutil"
+)
+
+// dockWindowAppSource 通过任务栏的窗口识别获取有窗口的进程所属的应用
+type dockWindowAppSource struct {
+	m *Manager
+}
+
+func (src *dockWindowAppSource) GetWindowApps() map[uint32]string {
+	conn := src.m.helper.xConn
+	clientList, err := ewmh.GetClientList(conn).Reply(conn)
+	if err != nil {
+		logger.Warning(err)
+		return nil
+	}
+	result := make(map[uint32]string, len(clientList))
+	for _, win := range clientList {
+		pid, err := ewmh.GetWMPid(conn, win).Reply(conn)
+		if err != nil {
+			continue
+		}
+		if _, ok := result[pid]; ok {
+			continue
+		}
+		appId := src.m.getWindowAppId(win)
+		if appId != "" {
+			result[pid] = appId
+		}
+	}
+	return result
+}
+
+// GetPowerConsumers 返回最近 period 秒内各应用的 CPU 时间、唤醒次数、I/O 字节数和估算的耗电占比，
+// 结果为 AppPowerUsage 数组的 JSON，按耗电占比从大到小排序，最多保留 24 小时的数据
+func (m *Manager) GetPowerConsumers(period uint32) (string, *dbus.Error) {
+	if period == 0 {
+		period = uint32(powerConsumersMaxAge / time.Second)
+	}
+	consumers := m.powerConsumers.getConsumers(time.Duration(period)*time.Second, time.Now())
+	data, err := json.Marshal(consumers)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return string(data), nil
+}
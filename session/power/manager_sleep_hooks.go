@@ -0,0 +1,75 @@
+package power
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/godbus/dbus"
+	"pkg.deepin.io/dde/daemon/session/power/sleephook"
+	"pkg.deepin.io/lib/dbusutil"
+)
+
+const (
+	login1ServiceName = "org.freedesktop.login1"
+	login1Path        = "/org/freedesktop/login1"
+	login1Interface   = "org.freedesktop.login1.Manager"
+
+	// logind 的默认值
+	defaultInhibitDelayMax = 5 * time.Second
+	// 给 delay inhibitor 剩下的时间，钩子执行完后还要释放 inhibitor
+	sleepHooksDelayMargin = time.Second
+)
+
+// runSleepHooks 执行待机前或唤醒后的钩子并记录结果
+func runSleepHooks(stage string) {
+	logger.Info("run sleep hooks", stage)
+	logSleepHookStatuses(stage, sleephook.Run(stage))
+}
+
+// runSleepHooksWithin 与 runSleepHooks 相同，但所有钩子一共最多执行 budget 的时间
+func runSleepHooksWithin(stage string, budget time.Duration) {
+	logger.Info("run sleep hooks", stage, "within", budget)
+	logSleepHookStatuses(stage, sleephook.RunWithin(stage, budget))
+}
+
+func logSleepHookStatuses(stage string, statuses []sleephook.Status) {
+	for _, status := range statuses {
+		if status.LastResult == sleephook.ResultSuccess {
+			logger.Infof("sleep hook %s/%s (%s) done in %dms", stage, status.Name,
+				status.Source, status.LastDuration)
+		} else {
+			logger.Warningf("sleep hook %s/%s (%s) %s in %dms: %s", stage, status.Name,
+				status.Source, status.LastResult, status.LastDuration, status.LastError)
+		}
+	}
+}
+
+// getInhibitDelayMax 返回 logind 等待 delay inhibitor 的最长时间
+func getInhibitDelayMax() time.Duration {
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		logger.Warning(err)
+		return defaultInhibitDelayMax
+	}
+	obj := systemBus.Object(login1ServiceName, login1Path)
+	v, err := obj.GetProperty(login1Interface + ".InhibitDelayMaxUSec")
+	if err != nil {
+		logger.Warning(err)
+		return defaultInhibitDelayMax
+	}
+	usec, ok := v.Value().(uint64)
+	if !ok {
+		return defaultInhibitDelayMax
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// ListSleepHooks 返回所有待机前和唤醒后的钩子及其最后一次执行的结果，
+// 结果为 sleephook.Status 数组的 JSON，钩子由其他模块注册或来自用户脚本目录
+func (m *Manager) ListSleepHooks() (string, *dbus.Error) {
+	data, err := json.Marshal(sleephook.List())
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,148 @@
+package power
+
+import (
+	"errors"
+	"os/exec"
+	"time"
+
+	"github.com/godbus/dbus"
+	"pkg.deepin.io/lib/dbusutil"
+	. "pkg.deepin.io/lib/gettext"
+)
+
+func getWarnLevelNotifyText(level WarnLevel, action *warnAction) string {
+	if action.NotifyText != "" {
+		return action.NotifyText
+	}
+	if level == WarnLevelAction {
+		return Tr("Battery critically low")
+	}
+	return Tr("Battery low, please plug in")
+}
+
+// startWarnLevelAction 开始宽限期，结束时执行动作。
+// 会离开会话的动作在结束前 2 秒锁屏并显示低电量界面。
+func (m *Manager) startWarnLevelAction(level WarnLevel, action *warnAction) {
+	grace := int(action.GracePeriod)
+	if grace == 0 {
+		m.doWarnLevelAction(action)
+		return
+	}
+	logger.Infof("warn level %v: %s in %d seconds", level, action.Action, grace)
+
+	showCount := grace - 2
+	if showCount < 0 {
+		showCount = 0
+	}
+	ticker := newCountTicker(time.Second, func(count int) {
+		if count == showCount && action.isSleepAction() {
+			go func() {
+				if m.SleepLock.Get() {
+					m.lockWaitShow(5*time.Second, false)
+				}
+				doShowDDELowPower()
+			}()
+		}
+		if count == grace {
+			if m.disableWarnLevelCountTicker() {
+				m.doWarnLevelAction(action)
+			}
+		}
+	})
+	m.warnLevelTickerMu.Lock()
+	m.warnLevelCountTicker = ticker
+	m.warnLevelTickerMu.Unlock()
+}
+
+func (m *Manager) doWarnLevelAction(action *warnAction) {
+	logger.Info("do warn level action", action.Action)
+	switch action.Action {
+	case warnActionSuspend:
+		m.doSuspend()
+	case warnActionHibernate:
+		m.doHibernate()
+	case warnActionHybridSleep:
+		m.doHybridSleep()
+	case warnActionShutdown:
+		m.doShutdown()
+	case warnActionCommand:
+		go func() {
+			output, err := exec.Command("/bin/sh", "-c", action.Command).CombinedOutput()
+			if err != nil {
+				logger.Warningf("failed to run warn level command %q: %v, %s", action.Command, err, output)
+			}
+		}()
+	}
+}
+
+// doHybridSleep 混合睡眠，session manager 不支持，直接调用 logind
+func (m *Manager) doHybridSleep() {
+	loginManager := m.helper.LoginManager
+	can, err := loginManager.CanHybridSleep(0)
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+	if can != "yes" {
+		logger.Info("can not hybrid sleep:", can)
+		return
+	}
+
+	logger.Debug("hybrid sleep")
+	err = loginManager.HybridSleep(0, false)
+	if err != nil {
+		logger.Warning("failed to hybrid sleep:", err)
+	}
+}
+
+func (m *Manager) enableWarnPowerSave() {
+	enabled, err := m.systemPower.PowerSavingModeEnabled().Get(0)
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+	if enabled {
+		return
+	}
+	logger.Info("enable power saving mode for low battery")
+	err = m.systemPower.PowerSavingModeEnabled().Set(0, true)
+	if err != nil {
+		logger.Warning("failed to enable power saving mode:", err)
+	}
+}
+
+// doWarnDim 按百分比降低亮度，多个级别都降低时都以第一次降低前的亮度为准计算和还原
+func (m *Manager) doWarnDim(percent uint32) {
+	if m.warnDimBrightness == nil {
+		brightnessTable, err := m.helper.Display.Brightness().Get(0)
+		if err != nil {
+			logger.Warning(err)
+			return
+		}
+		m.warnDimBrightness = brightnessTable
+	}
+	dimTable := make(map[string]float64, len(m.warnDimBrightness))
+	for output, brightness := range m.warnDimBrightness {
+		dimTable[output] = brightness * float64(100-percent) / 100
+	}
+	m.setDisplayBrightness(dimTable)
+}
+
+func (m *Manager) restoreWarnDim() {
+	if m.warnDimBrightness == nil {
+		return
+	}
+	m.setDisplayBrightness(m.warnDimBrightness)
+	m.warnDimBrightness = nil
+}
+
+// DismissWarnLevelAction 取消宽限期中等待执行的低电量动作，
+// 电量继续降低到下一个警告级别时仍会执行该级别的动作
+func (m *Manager) DismissWarnLevelAction() *dbus.Error {
+	if !m.disableWarnLevelCountTicker() {
+		return dbusutil.ToError(errors.New("no pending warn level action"))
+	}
+	logger.Info("warn level action dismissed")
+	doCloseDDELowPower()
+	return nil
+}
@@ -0,0 +1,360 @@
+package power
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 按应用统计耗电情况：定时采样所有进程的 CPU 时间、唤醒次数（上下文切换次数）和 I/O 字节数，
+// 把进程归到它或它的祖先进程的窗口所属的应用，没有窗口的进程使用进程名。
+
+const (
+	powerConsumersSampleInterval = time.Minute
+	powerConsumersMaxAge         = 24 * time.Hour
+
+	// 估算耗电比例时，一次唤醒和 1MiB I/O 相当于多少 CPU 秒
+	wakeupCpuSeconds = 0.0005
+	ioMiBCpuSeconds  = 0.01
+
+	// /proc/[pid]/stat 中 CPU 时间的单位，即 USER_HZ
+	clockTicksPerSecond = 100
+)
+
+type processSample struct {
+	Pid     uint32
+	PPid    uint32
+	Comm    string
+	CpuTime time.Duration
+	Wakeups uint64
+	IOBytes uint64
+}
+
+// processSource 采集所有进程的累计资源使用情况，测试时可以替换
+type processSource interface {
+	Sample() (map[uint32]*processSample, error)
+}
+
+// windowAppSource 返回有窗口的进程所属的应用，key 为 pid
+type windowAppSource interface {
+	GetWindowApps() map[uint32]string
+}
+
+// AppPowerUsage 是一个应用在一段时间内的资源使用，Share 为估算的耗电占比（百分比）
+type AppPowerUsage struct {
+	AppId   string
+	CpuTime float64 // 秒
+	Wakeups uint64
+	IOBytes uint64
+	Share   float64
+}
+
+func (u *AppPowerUsage) score() float64 {
+	return u.CpuTime + float64(u.Wakeups)*wakeupCpuSeconds +
+		float64(u.IOBytes)/(1<<20)*ioMiBCpuSeconds
+}
+
+type powerConsumersRecord struct {
+	time  time.Time
+	usage map[string]*AppPowerUsage
+}
+
+type powerConsumersSampler struct {
+	mu         sync.Mutex
+	procSource processSource
+	winSource  windowAppSource
+	prev       map[uint32]*processSample
+	records    []powerConsumersRecord
+	quit       chan struct{}
+}
+
+func newPowerConsumersSampler(procSource processSource, winSource windowAppSource) *powerConsumersSampler {
+	return &powerConsumersSampler{
+		procSource: procSource,
+		winSource:  winSource,
+	}
+}
+
+func (s *powerConsumersSampler) start() {
+	s.quit = make(chan struct{})
+	ticker := time.NewTicker(powerConsumersSampleInterval)
+	go func() {
+		s.sample(time.Now())
+		for {
+			select {
+			case now := <-ticker.C:
+				s.sample(now)
+			case <-s.quit:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+func (s *powerConsumersSampler) stop() {
+	if s.quit != nil {
+		close(s.quit)
+		s.quit = nil
+	}
+}
+
+// sample 采集一次，与上次采样的差值记为这段时间的使用，第一次采样只作为基准
+func (s *powerConsumersSampler) sample(now time.Time) {
+	samples, err := s.procSource.Sample()
+	if err != nil {
+		logger.Warning("failed to sample processes:", err)
+		return
+	}
+
+	s.mu.Lock()
+	prev := s.prev
+	s.prev = samples
+	s.mu.Unlock()
+	if prev == nil {
+		return
+	}
+
+	deltas := diffProcessSamples(prev, samples)
+	if len(deltas) == 0 {
+		return
+	}
+	usage := groupProcessUsage(deltas, samples, s.winSource.GetWindowApps())
+
+	s.mu.Lock()
+	s.records = append(s.records, powerConsumersRecord{time: now, usage: usage})
+	idx := 0
+	for idx < len(s.records) && now.Sub(s.records[idx].time) > powerConsumersMaxAge {
+		idx++
+	}
+	s.records = s.records[idx:]
+	s.mu.Unlock()
+}
+
+// getConsumers 返回最近 period 时间内各应用的使用情况，按耗电占比从大到小排序
+func (s *powerConsumersSampler) getConsumers(period time.Duration, now time.Time) []*AppPowerUsage {
+	total := make(map[string]*AppPowerUsage)
+	s.mu.Lock()
+	for _, record := range s.records {
+		if now.Sub(record.time) > period {
+			continue
+		}
+		for appId, u := range record.usage {
+			t, ok := total[appId]
+			if !ok {
+				t = &AppPowerUsage{AppId: appId}
+				total[appId] = t
+			}
+			t.CpuTime += u.CpuTime
+			t.Wakeups += u.Wakeups
+			t.IOBytes += u.IOBytes
+		}
+	}
+	s.mu.Unlock()
+
+	result := make([]*AppPowerUsage, 0, len(total))
+	var totalScore float64
+	for _, u := range total {
+		totalScore += u.score()
+		result = append(result, u)
+	}
+	for _, u := range result {
+		if totalScore > 0 {
+			u.Share = u.score() / totalScore * 100
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Share != result[j].Share {
+			return result[i].Share > result[j].Share
+		}
+		return result[i].AppId < result[j].AppId
+	})
+	return result
+}
+
+// diffProcessSamples 计算两次采样间每个进程的使用，新进程使用它的累计值
+func diffProcessSamples(prev, cur map[uint32]*processSample) map[uint32]*processSample {
+	result := make(map[uint32]*processSample)
+	for pid, c := range cur {
+		d := *c
+		if p, ok := prev[pid]; ok && p.Comm == c.Comm &&
+			p.CpuTime <= c.CpuTime && p.Wakeups <= c.Wakeups && p.IOBytes <= c.IOBytes {
+			d.CpuTime -= p.CpuTime
+			d.Wakeups -= p.Wakeups
+			d.IOBytes -= p.IOBytes
+		}
+		if d.CpuTime == 0 && d.Wakeups == 0 && d.IOBytes == 0 {
+			continue
+		}
+		result[pid] = &d
+	}
+	return result
+}
+
+// getProcessApp 沿着父进程查找有窗口的进程，找不到时使用进程名
+func getProcessApp(pid uint32, samples map[uint32]*processSample, windowApps map[uint32]string) string {
+	p := samples[pid]
+	// 防止 ppid 出现环
+	for i := 0; i < 64 && pid > 1; i++ {
+		if appId, ok := windowApps[pid]; ok && appId != "" {
+			return appId
+		}
+		parent, ok := samples[pid]
+		if !ok {
+			break
+		}
+		pid = parent.PPid
+	}
+	if p == nil {
+		return ""
+	}
+	return p.Comm
+}
+
+func groupProcessUsage(deltas, samples map[uint32]*processSample,
+	windowApps map[uint32]string) map[string]*AppPowerUsage {
+	result := make(map[string]*AppPowerUsage)
+	for pid, d := range deltas {
+		appId := getProcessApp(pid, samples, windowApps)
+		if appId == "" {
+			continue
+		}
+		u, ok := result[appId]
+		if !ok {
+			u = &AppPowerUsage{AppId: appId}
+			result[appId] = u
+		}
+		u.CpuTime += d.CpuTime.Seconds()
+		u.Wakeups += d.Wakeups
+		u.IOBytes += d.IOBytes
+	}
+	return result
+}
+
+// procfsProcessSource 从 /proc 读取当前用户可访问的进程信息
+type procfsProcessSource struct {
+	dir string
+}
+
+func (src *procfsProcessSource) Sample() (map[uint32]*processSample, error) {
+	fileInfos, err := ioutil.ReadDir(src.dir)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint32]*processSample)
+	for _, fileInfo := range fileInfos {
+		pid, err := strconv.ParseUint(fileInfo.Name(), 10, 32)
+		if err != nil || !fileInfo.IsDir() {
+			continue
+		}
+		s, err := src.readProcess(uint32(pid))
+		if err != nil {
+			// 进程可能已经退出或者没有权限
+			continue
+		}
+		result[s.Pid] = s
+	}
+	return result, nil
+}
+
+func (src *procfsProcessSource) readProcess(pid uint32) (*processSample, error) {
+	dir := filepath.Join(src.dir, strconv.FormatUint(uint64(pid), 10))
+	content, err := ioutil.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return nil, err
+	}
+	s, err := parseProcStat(content)
+	if err != nil {
+		return nil, err
+	}
+	s.Pid = pid
+
+	content, err = ioutil.ReadFile(filepath.Join(dir, "status"))
+	if err == nil {
+		s.Wakeups = parseProcStatusCtxtSwitches(content)
+	}
+	// 其他用户的进程没有权限读取 io
+	content, err = ioutil.ReadFile(filepath.Join(dir, "io"))
+	if err == nil {
+		s.IOBytes = parseProcIO(content)
+	} else if !os.IsPermission(err) && !os.IsNotExist(err) {
+		logger.Debug(err)
+	}
+	return s, nil
+}
+
+// parseProcStat 解析 /proc/[pid]/stat，进程名可能包含空格和括号，所以从最后一个 ')' 开始分割
+func parseProcStat(content []byte) (*processSample, error) {
+	start := bytes.IndexByte(content, '(')
+	end := bytes.LastIndexByte(content, ')')
+	if start < 0 || end < start {
+		return nil, errors.New("invalid stat")
+	}
+	comm := string(content[start+1 : end])
+	// 从 state 开始的字段，ppid 是第 4 个字段，utime 和 stime 是第 14、15 个字段
+	fields := strings.Fields(string(content[end+1:]))
+	if len(fields) < 13 {
+		return nil, errors.New("invalid stat")
+	}
+	ppid, err := strconv.ParseUint(fields[1], 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &processSample{
+		PPid:    uint32(ppid),
+		Comm:    comm,
+		CpuTime: time.Duration(utime+stime) * time.Second / clockTicksPerSecond,
+	}, nil
+}
+
+func parseProcStatusCtxtSwitches(content []byte) uint64 {
+	var result uint64
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "voluntary_ctxt_switches:") ||
+			strings.HasPrefix(line, "nonvoluntary_ctxt_switches:") {
+			parts := strings.SplitN(line, ":", 2)
+			v, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 64)
+			if err == nil {
+				result += v
+			}
+		}
+	}
+	return result
+}
+
+func parseProcIO(content []byte) uint64 {
+	var result uint64
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		switch parts[0] {
+		case "read_bytes", "write_bytes":
+			v, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 64)
+			if err == nil {
+				result += v
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,98 @@
+package power
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type fakeProcessSource struct {
+	samples []map[uint32]*processSample
+}
+
+func (src *fakeProcessSource) Sample() (map[uint32]*processSample, error) {
+	result := src.samples[0]
+	src.samples = src.samples[1:]
+	return result, nil
+}
+
+type fakeWindowAppSource map[uint32]string
+
+func (src fakeWindowAppSource) GetWindowApps() map[uint32]string {
+	return src
+}
+
+func TestPowerConsumersSampler(t *testing.T) {
+	Convey("powerConsumersSampler", t, func(c C) {
+		src := &fakeProcessSource{samples: []map[uint32]*processSample{
+			{
+				100: {Pid: 100, PPid: 1, Comm: "browser", CpuTime: 10 * time.Second},
+				101: {Pid: 101, PPid: 100, Comm: "renderer", CpuTime: 5 * time.Second},
+				200: {Pid: 200, PPid: 1, Comm: "indexer", IOBytes: 1 << 20},
+			},
+			{
+				100: {Pid: 100, PPid: 1, Comm: "browser", CpuTime: 12 * time.Second, Wakeups: 100},
+				101: {Pid: 101, PPid: 100, Comm: "renderer", CpuTime: 8 * time.Second},
+				200: {Pid: 200, PPid: 1, Comm: "indexer", IOBytes: 101 << 20},
+				300: {Pid: 300, PPid: 1, Comm: "idle"},
+			},
+		}}
+		s := newPowerConsumersSampler(src, fakeWindowAppSource{100: "google-chrome"})
+		t0 := time.Unix(10000, 0)
+		s.sample(t0)
+		c.So(s.getConsumers(time.Hour, t0), ShouldBeEmpty)
+
+		s.sample(t0.Add(time.Minute))
+		consumers := s.getConsumers(time.Hour, t0.Add(time.Minute))
+		c.So(consumers, ShouldHaveLength, 2)
+		c.So(consumers[0].AppId, ShouldEqual, "google-chrome")
+		c.So(consumers[0].CpuTime, ShouldAlmostEqual, 5)
+		c.So(consumers[0].Wakeups, ShouldEqual, 100)
+		c.So(consumers[1].AppId, ShouldEqual, "indexer")
+		c.So(consumers[1].IOBytes, ShouldEqual, 100<<20)
+		c.So(consumers[0].Share+consumers[1].Share, ShouldAlmostEqual, 100)
+
+		c.So(s.getConsumers(time.Second, t0.Add(2*time.Hour)), ShouldBeEmpty)
+	})
+}
+
+func Test_diffProcessSamples(t *testing.T) {
+	Convey("diffProcessSamples", t, func(c C) {
+		prev := map[uint32]*processSample{
+			1: {Pid: 1, Comm: "a", CpuTime: time.Second},
+			2: {Pid: 2, Comm: "b", CpuTime: time.Second},
+		}
+		cur := map[uint32]*processSample{
+			1: {Pid: 1, Comm: "a", CpuTime: 3 * time.Second},
+			// pid 被重用
+			2: {Pid: 2, Comm: "c", CpuTime: 2 * time.Second},
+			3: {Pid: 3, Comm: "d"},
+		}
+		deltas := diffProcessSamples(prev, cur)
+		c.So(deltas, ShouldHaveLength, 2)
+		c.So(deltas[1].CpuTime, ShouldEqual, 2*time.Second)
+		c.So(deltas[2].CpuTime, ShouldEqual, 2*time.Second)
+	})
+}
+
+func Test_parseProcFiles(t *testing.T) {
+	Convey("parse proc files", t, func(c C) {
+		stat := []byte("1234 (Web Content (x)) S 1000 1234 1000 0 -1 4194560 " +
+			"100 0 0 0 250 50 0 0 20 0 30 0 12345 0 0\n")
+		s, err := parseProcStat(stat)
+		c.So(err, ShouldBeNil)
+		c.So(s.Comm, ShouldEqual, "Web Content (x)")
+		c.So(s.PPid, ShouldEqual, 1000)
+		c.So(s.CpuTime, ShouldEqual, 3*time.Second)
+
+		_, err = parseProcStat([]byte("1234 bad"))
+		c.So(err, ShouldNotBeNil)
+
+		status := []byte("Name:\tbash\nvoluntary_ctxt_switches:\t10\nnonvoluntary_ctxt_switches:\t5\n")
+		c.So(parseProcStatusCtxtSwitches(status), ShouldEqual, 15)
+
+		io := []byte("rchar: 100\nwchar: 200\nread_bytes: 4096\nwrite_bytes: 8192\n")
+		c.So(parseProcIO(io), ShouldEqual, 12288)
+	})
+}
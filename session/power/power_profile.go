@@ -0,0 +1,146 @@
+package power
+
+import (
+	"encoding/json"
+
+	"github.com/godbus/dbus"
+	"pkg.deepin.io/lib/dbusutil"
+	"pkg.deepin.io/lib/dbusutil/gsprop"
+)
+
+// system power 中电源配置的屏幕和待机设置保存在用户的 gsettings 中，
+// 所以由 session power 在会话开始时和收到 PowerProfileApplied 信号后应用。
+
+const (
+	sysPowerServiceName = "com.deepin.system.Power"
+	sysPowerPath        = "/com/deepin/system/Power"
+	sysPowerInterface   = sysPowerServiceName
+)
+
+// powerProfile 是 system power 中 PowerProfile 的 session 部分
+type powerProfile struct {
+	Name             string
+	ScreenBlackDelay *int32
+	LockDelay        *int32
+	SleepDelay       *int32
+	LidClosedAction  *int32
+	Trigger          string
+}
+
+// useBatterySettings 判断配置应该写入电池还是电源的设置
+func (p *powerProfile) useBatterySettings(onBattery bool) bool {
+	switch p.Trigger {
+	case "ac":
+		return false
+	case "battery", "battery-below":
+		return true
+	}
+	return onBattery
+}
+
+// initPowerProfileHandler 应用当前使用的电源配置，并监听 system power 应用电源配置，
+// 当前配置被修改或者重新应用时也会收到信号
+func (m *Manager) initPowerProfileHandler() {
+	err := dbusutil.NewMatchRuleBuilder().
+		ExtSignal(sysPowerPath, sysPowerInterface, "PowerProfileApplied").
+		Sender(sysPowerServiceName).Build().
+		AddTo(m.systemSigLoop.Conn())
+	if err != nil {
+		logger.Warning(err)
+	} else {
+		m.systemSigLoop.AddHandler(&dbusutil.SignalRule{
+			Path: sysPowerPath,
+			Name: sysPowerInterface + ".PowerProfileApplied",
+		}, func(sig *dbus.Signal) {
+			var name string
+			err := dbus.Store(sig.Body, &name)
+			if err != nil {
+				logger.Warning(err)
+				return
+			}
+			m.handlePowerProfileApplied(name)
+		})
+	}
+
+	go func() {
+		name, err := getActivePowerProfileName()
+		if err != nil {
+			logger.Warning("failed to get active power profile:", err)
+			return
+		}
+		m.handlePowerProfileApplied(name)
+	}()
+}
+
+func getActivePowerProfileName() (string, error) {
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return "", err
+	}
+	obj := systemBus.Object(sysPowerServiceName, sysPowerPath)
+	v, err := obj.GetProperty(sysPowerInterface + ".ActiveProfile")
+	if err != nil {
+		return "", err
+	}
+	name, _ := v.Value().(string)
+	return name, nil
+}
+
+func (m *Manager) handlePowerProfileApplied(name string) {
+	if name == "" {
+		return
+	}
+	logger.Info("apply power profile:", name)
+	profile, err := m.getPowerProfile(name)
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+
+	m.PropsMu.RLock()
+	onBattery := m.OnBattery
+	m.PropsMu.RUnlock()
+	m.applyPowerProfile(profile, profile.useBatterySettings(onBattery))
+}
+
+func (m *Manager) getPowerProfile(name string) (*powerProfile, error) {
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return nil, err
+	}
+	obj := systemBus.Object(sysPowerServiceName, sysPowerPath)
+	var data string
+	err = obj.Call(sysPowerInterface+".GetPowerProfile", 0, name).Store(&data)
+	if err != nil {
+		return nil, err
+	}
+	var profile powerProfile
+	err = json.Unmarshal([]byte(data), &profile)
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (m *Manager) applyPowerProfile(profile *powerProfile, onBattery bool) {
+	setInt := func(prop *gsprop.Int, value *int32) {
+		if value != nil {
+			prop.Set(*value)
+		}
+	}
+	if onBattery {
+		setInt(&m.BatteryScreenBlackDelay, profile.ScreenBlackDelay)
+		setInt(&m.BatteryLockDelay, profile.LockDelay)
+		setInt(&m.BatterySleepDelay, profile.SleepDelay)
+		if profile.LidClosedAction != nil {
+			m.BatteryLidClosedAction.Set(*profile.LidClosedAction)
+		}
+	} else {
+		setInt(&m.LinePowerScreenBlackDelay, profile.ScreenBlackDelay)
+		setInt(&m.LinePowerLockDelay, profile.LockDelay)
+		setInt(&m.LinePowerSleepDelay, profile.SleepDelay)
+		if profile.LidClosedAction != nil {
+			m.LinePowerLidClosedAction.Set(*profile.LidClosedAction)
+		}
+	}
+}
@@ -57,6 +57,8 @@ type powerSavePlan struct {
 	atomNetWMStateFullscreen    x.Atom
 	atomNetWMStateFocused       x.Atom
 	fullscreenWorkaroundAppList []string
+	// 通过 ForceReleaseInhibitor 释放的全屏窗口
+	ignoredFullscreenWin x.Window
 
 	brightnessSave         gsprop.String
 	multiBrightnessWithPsm *multiBrightnessWithPsm
@@ -542,41 +544,71 @@ func (psp *powerSavePlan) screenBlack() {
 }
 
 func (psp *powerSavePlan) shouldPreventIdle() (bool, error) {
+	win, _, _, err := psp.getFullscreenInhibitWindow()
+	if err != nil {
+		return false, err
+	}
+	if win == 0 {
+		psp.ignoredFullscreenWin = 0
+		return false, nil
+	}
+	if win == psp.ignoredFullscreenWin {
+		logger.Debug("fullscreen window inhibitor has been released", win)
+		return false, nil
+	}
+	return true, nil
+}
+
+// getFullscreenInhibitWindow 返回获得焦点且全屏的白名单应用窗口、进程 pid 和匹配的应用，不存在时返回的窗口为 0
+func (psp *powerSavePlan) getFullscreenInhibitWindow() (x.Window, uint32, string, error) {
 	conn := psp.manager.helper.xConn
 	activeWin, err := ewmh.GetActiveWindow(conn).Reply(conn)
 	if err != nil {
-		return false, err
+		return 0, 0, "", err
 	}
 
 	isFullscreenAndFocused, err := psp.isWindowFullScreenAndFocused(activeWin)
 	if err != nil {
-		return false, err
+		return 0, 0, "", err
 	}
 
 	if !isFullscreenAndFocused {
-		return false, nil
+		return 0, 0, "", nil
 	}
 
 	pid, err := ewmh.GetWMPid(conn, activeWin).Reply(conn)
 	if err != nil {
-		return false, err
+		return 0, 0, "", err
 	}
 
 	p := procfs.Process(pid)
 	cmdline, err := p.Cmdline()
 	if err != nil {
-		return false, err
+		return 0, 0, "", err
 	}
 
 	for _, arg := range cmdline {
 		for _, app := range psp.fullscreenWorkaroundAppList {
 			if strings.Contains(arg, app) {
 				logger.Debugf("match %q", app)
-				return true, nil
+				return activeWin, pid, app, nil
 			}
 		}
 	}
-	return false, nil
+	return 0, 0, "", nil
+}
+
+// ignoreFullscreenWindow 使窗口不再阻止 Idle，直到它不再是获得焦点的全屏窗口
+func (psp *powerSavePlan) ignoreFullscreenWindow(win x.Window) {
+	psp.mu.Lock()
+	psp.ignoredFullscreenWin = win
+	psp.mu.Unlock()
+}
+
+func (psp *powerSavePlan) isFullscreenWindowIgnored(win x.Window) bool {
+	psp.mu.Lock()
+	defer psp.mu.Unlock()
+	return psp.ignoredFullscreenWin == win
 }
 
 // 开始 Idle
@@ -20,6 +20,9 @@
 package power
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -99,6 +102,62 @@ func Test_getWarnLevel(t *testing.T) {
 	})
 }
 
+func TestWarnLevelActionPolicy(t *testing.T) {
+	Convey("warnLevelActionPolicy check", t, func(c C) {
+		policy := getDefaultWarnLevelActionPolicy()
+		c.So(policy.check(), ShouldBeNil)
+		c.So(policy.get(WarnLevelAction).Action, ShouldEqual, warnActionSuspend)
+		c.So(policy.get(WarnLevelAction).GracePeriod, ShouldEqual, 5)
+		c.So(policy.get(WarnLevelNone), ShouldBeNil)
+
+		policy.Danger.DimPercent = 30
+		policy.Critical.Action = warnActionHybridSleep
+		policy.Critical.GracePeriod = 60
+		c.So(policy.check(), ShouldBeNil)
+
+		policy.Critical.Action = "reboot"
+		c.So(policy.check(), ShouldNotBeNil)
+		policy.Critical.Action = warnActionCommand
+		c.So(policy.check(), ShouldNotBeNil)
+		policy.Critical.Command = "systemctl suspend"
+		c.So(policy.check(), ShouldBeNil)
+		policy.Critical.Action = warnActionHibernate
+		c.So(policy.check(), ShouldNotBeNil)
+		policy.Critical.Command = ""
+
+		policy.Low.DimPercent = maxWarnActionDimPercent + 1
+		c.So(policy.check(), ShouldNotBeNil)
+		policy.Low.DimPercent = 0
+		policy.Low.GracePeriod = maxWarnActionGracePeriod + 1
+		c.So(policy.check(), ShouldNotBeNil)
+		policy.Low.GracePeriod = 0
+
+		policy.Action = nil
+		c.So(policy.check(), ShouldNotBeNil)
+	})
+
+	Convey("warnLevelActionPolicy load and save", t, func(c C) {
+		_, err := parseWarnLevelActionPolicy([]byte(`{"Low":{"Notify":true}}`))
+		c.So(err, ShouldNotBeNil)
+		_, err = parseWarnLevelActionPolicy([]byte(`invalid`))
+		c.So(err, ShouldNotBeNil)
+
+		dir, err := ioutil.TempDir("", "warn-level-actions")
+		c.So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		file := filepath.Join(dir, "power/warn-level-actions.json")
+
+		policy := getDefaultWarnLevelActionPolicy()
+		policy.Critical.PowerSave = true
+		policy.Critical.NotifyText = "plug in now"
+		c.So(policy.save(file), ShouldBeNil)
+
+		loaded, err := loadWarnLevelActionPolicy(file)
+		c.So(err, ShouldBeNil)
+		c.So(loaded, ShouldResemble, policy)
+	})
+}
+
 func TestMetaTasksMin(t *testing.T) {
 	Convey("metaTasks.min", t, func(c C) {
 		tasks := metaTasks{
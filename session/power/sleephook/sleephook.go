@@ -0,0 +1,342 @@
+// Package sleephook 管理待机前和唤醒后执行的钩子，
+// 钩子可以由其他模块注册，也可以是用户放在配置目录中的脚本，按 Order 从小到大依次执行。
+package sleephook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"unicode"
+
+	"pkg.deepin.io/lib/xdg/basedir"
+)
+
+const (
+	StagePreSuspend = "pre-suspend"
+	StagePostResume = "post-resume"
+)
+
+const (
+	SourceModule = "module"
+	SourceScript = "script"
+)
+
+const (
+	ResultSuccess = "success"
+	ResultFailed  = "failed"
+	ResultTimeout = "timeout"
+	// 前面的钩子用完了 RunWithin 的时间，没有执行
+	ResultSkipped = "skipped"
+)
+
+const (
+	DefaultOrder   = 50
+	DefaultTimeout = 2 * time.Second
+	// 待机前的钩子在 logind 的 delay inhibitor 期间执行，不能太久
+	MaxTimeout = 10 * time.Second
+)
+
+// ScriptDir 中的 pre-suspend 和 post-resume 子目录存放用户脚本，
+// 文件名开头的数字作为 Order，如 10-vpn，脚本的第一个参数是阶段名
+var ScriptDir = filepath.Join(basedir.GetUserConfigDir(), "deepin/dde-daemon/sleep-hooks")
+
+// Hook 是一个待机前或唤醒后执行的动作，Fn 应该在 ctx 结束时尽快返回
+type Hook struct {
+	Name    string
+	Stage   string
+	Order   int
+	Timeout time.Duration
+	Fn      func(ctx context.Context) error
+}
+
+// Status 是钩子的信息和最后一次执行的结果，LastRun 为 0 表示还没有执行过
+type Status struct {
+	Name   string
+	Stage  string
+	Order  int
+	Source string
+	// 时间单位为毫秒，LastRun 为 Unix 时间戳
+	Timeout      int64
+	LastRun      int64
+	LastDuration int64
+	LastResult   string
+	LastError    string
+}
+
+type entry struct {
+	hook   Hook
+	source string
+}
+
+type Registry struct {
+	mu        sync.Mutex
+	hooks     map[string]*entry
+	statuses  map[string]*Status
+	scriptDir string
+	runMu     sync.Mutex
+}
+
+func NewRegistry(scriptDir string) *Registry {
+	return &Registry{
+		hooks:     make(map[string]*entry),
+		statuses:  make(map[string]*Status),
+		scriptDir: scriptDir,
+	}
+}
+
+var defaultRegistry = NewRegistry(ScriptDir)
+
+func getKey(stage, name string) string {
+	return stage + "/" + name
+}
+
+func checkStage(stage string) error {
+	if stage != StagePreSuspend && stage != StagePostResume {
+		return fmt.Errorf("invalid stage %q", stage)
+	}
+	return nil
+}
+
+func normalizeTimeout(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return DefaultTimeout
+	}
+	if timeout > MaxTimeout {
+		return MaxTimeout
+	}
+	return timeout
+}
+
+// Register 注册一个钩子，同一阶段已有同名的钩子时替换它
+func (r *Registry) Register(hook Hook) error {
+	if hook.Name == "" {
+		return errors.New("hook name is empty")
+	}
+	if hook.Fn == nil {
+		return errors.New("hook function is nil")
+	}
+	err := checkStage(hook.Stage)
+	if err != nil {
+		return err
+	}
+	hook.Timeout = normalizeTimeout(hook.Timeout)
+
+	r.mu.Lock()
+	r.hooks[getKey(hook.Stage, hook.Name)] = &entry{hook: hook, source: SourceModule}
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Registry) Unregister(stage, name string) {
+	key := getKey(stage, name)
+	r.mu.Lock()
+	delete(r.hooks, key)
+	delete(r.statuses, key)
+	r.mu.Unlock()
+}
+
+// getScriptOrder 把文件名开头的数字作为 Order
+func getScriptOrder(name string) int {
+	end := 0
+	for end < len(name) && unicode.IsDigit(rune(name[end])) {
+		end++
+	}
+	order, err := strconv.Atoi(name[:end])
+	if err != nil {
+		return DefaultOrder
+	}
+	return order
+}
+
+func (r *Registry) loadScripts(stage string) []*entry {
+	if r.scriptDir == "" {
+		return nil
+	}
+	dir := filepath.Join(r.scriptDir, stage)
+	fileInfos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var result []*entry
+	for _, fileInfo := range fileInfos {
+		if fileInfo.IsDir() || fileInfo.Mode().Perm()&0111 == 0 {
+			continue
+		}
+		file := filepath.Join(dir, fileInfo.Name())
+		result = append(result, &entry{
+			hook: Hook{
+				Name:    fileInfo.Name(),
+				Stage:   stage,
+				Order:   getScriptOrder(fileInfo.Name()),
+				Timeout: DefaultTimeout,
+				Fn: func(ctx context.Context) error {
+					output, err := exec.CommandContext(ctx, file, stage).CombinedOutput()
+					if err != nil && len(output) > 0 {
+						return fmt.Errorf("%v: %s", err, output)
+					}
+					return err
+				},
+			},
+			source: SourceScript,
+		})
+	}
+	return result
+}
+
+func (r *Registry) getEntries(stage string) []*entry {
+	r.mu.Lock()
+	var result []*entry
+	for _, e := range r.hooks {
+		if e.hook.Stage == stage {
+			result = append(result, e)
+		}
+	}
+	r.mu.Unlock()
+
+	result = append(result, r.loadScripts(stage)...)
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i].hook, result[j].hook
+		if a.Order != b.Order {
+			return a.Order < b.Order
+		}
+		return a.Name < b.Name
+	})
+	return result
+}
+
+func runHook(hook Hook) (result string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hook.Timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				done <- fmt.Errorf("panic: %v", v)
+			}
+		}()
+		done <- hook.Fn(ctx)
+	}()
+
+	select {
+	case err = <-done:
+		if err != nil {
+			return ResultFailed, err
+		}
+		return ResultSuccess, nil
+	case <-ctx.Done():
+		return ResultTimeout, ctx.Err()
+	}
+}
+
+// Run 按顺序执行阶段 stage 的所有钩子，返回本次执行的结果。
+// 某个钩子失败或超时不影响后面的钩子。
+func (r *Registry) Run(stage string) []Status {
+	return r.run(stage, time.Time{})
+}
+
+// RunWithin 与 Run 相同，但所有钩子一共最多执行 budget 的时间，
+// 每个钩子的超时不超过剩余的时间，时间用完后跳过剩下的钩子
+func (r *Registry) RunWithin(stage string, budget time.Duration) []Status {
+	return r.run(stage, time.Now().Add(budget))
+}
+
+// run 在 deadline 前执行钩子，deadline 为零值时不限制
+func (r *Registry) run(stage string, deadline time.Time) []Status {
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+
+	var result []Status
+	for _, e := range r.getEntries(stage) {
+		hook := e.hook
+		start := time.Now()
+		status := getStatus(e)
+		status.LastRun = start.Unix()
+		var res string
+		var err error
+		if !deadline.IsZero() {
+			remaining := deadline.Sub(start)
+			if remaining < hook.Timeout {
+				hook.Timeout = remaining
+			}
+		}
+		if hook.Timeout <= 0 {
+			res = ResultSkipped
+			err = errors.New("no time left")
+		} else {
+			res, err = runHook(hook)
+		}
+		status.LastDuration = int64(time.Since(start) / time.Millisecond)
+		status.LastResult = res
+		if err != nil {
+			status.LastError = err.Error()
+		}
+
+		r.mu.Lock()
+		s := status
+		r.statuses[getKey(stage, e.hook.Name)] = &s
+		r.mu.Unlock()
+		result = append(result, status)
+	}
+	return result
+}
+
+func getStatus(e *entry) Status {
+	return Status{
+		Name:    e.hook.Name,
+		Stage:   e.hook.Stage,
+		Order:   e.hook.Order,
+		Source:  e.source,
+		Timeout: int64(e.hook.Timeout / time.Millisecond),
+	}
+}
+
+// List 返回两个阶段所有的钩子和它们最后一次执行的结果
+func (r *Registry) List() []Status {
+	var result []Status
+	for _, stage := range []string{StagePreSuspend, StagePostResume} {
+		for _, e := range r.getEntries(stage) {
+			status := getStatus(e)
+			r.mu.Lock()
+			last, ok := r.statuses[getKey(stage, e.hook.Name)]
+			r.mu.Unlock()
+			if ok && last.Source == e.source {
+				status.LastRun = last.LastRun
+				status.LastDuration = last.LastDuration
+				status.LastResult = last.LastResult
+				status.LastError = last.LastError
+			}
+			result = append(result, status)
+		}
+	}
+	return result
+}
+
+// Register 在默认的 Registry 中注册钩子，供其他模块使用
+func Register(hook Hook) error {
+	return defaultRegistry.Register(hook)
+}
+
+func Unregister(stage, name string) {
+	defaultRegistry.Unregister(stage, name)
+}
+
+func Run(stage string) []Status {
+	return defaultRegistry.Run(stage)
+}
+
+func RunWithin(stage string, budget time.Duration) []Status {
+	return defaultRegistry.RunWithin(stage, budget)
+}
+
+func List() []Status {
+	return defaultRegistry.List()
+}
@@ -0,0 +1,124 @@
+package sleephook
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRegistryRun(t *testing.T) {
+	Convey("Registry Run", t, func(c C) {
+		r := NewRegistry("")
+		var order []string
+		add := func(name string, o int, fn func(ctx context.Context) error) {
+			err := r.Register(Hook{
+				Name:  name,
+				Stage: StagePreSuspend,
+				Order: o,
+				Fn: func(ctx context.Context) error {
+					order = append(order, name)
+					return fn(ctx)
+				},
+				Timeout: 50 * time.Millisecond,
+			})
+			c.So(err, ShouldBeNil)
+		}
+		add("vpn", 10, func(ctx context.Context) error { return nil })
+		add("media", 5, func(ctx context.Context) error { return errors.New("no player") })
+		add("share", 20, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		c.So(r.Register(Hook{Name: "x", Stage: "shutdown",
+			Fn: func(ctx context.Context) error { return nil }}), ShouldNotBeNil)
+		c.So(r.Register(Hook{Name: "x", Stage: StagePostResume}), ShouldNotBeNil)
+
+		statuses := r.Run(StagePreSuspend)
+		c.So(order, ShouldResemble, []string{"media", "vpn", "share"})
+		c.So(statuses, ShouldHaveLength, 3)
+		c.So(statuses[0].LastResult, ShouldEqual, ResultFailed)
+		c.So(statuses[0].LastError, ShouldEqual, "no player")
+		c.So(statuses[1].LastResult, ShouldEqual, ResultSuccess)
+		c.So(statuses[2].LastResult, ShouldEqual, ResultTimeout)
+
+		list := r.List()
+		c.So(list, ShouldHaveLength, 3)
+		c.So(list[1].Name, ShouldEqual, "vpn")
+		c.So(list[1].LastResult, ShouldEqual, ResultSuccess)
+		c.So(list[1].Timeout, ShouldEqual, 50)
+
+		r.Unregister(StagePreSuspend, "vpn")
+		c.So(r.List(), ShouldHaveLength, 2)
+	})
+}
+
+func TestRegistryRunWithin(t *testing.T) {
+	Convey("Registry RunWithin", t, func(c C) {
+		r := NewRegistry("")
+		wait := func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		for i, name := range []string{"a", "b", "c"} {
+			err := r.Register(Hook{
+				Name:    name,
+				Stage:   StagePreSuspend,
+				Order:   i,
+				Fn:      wait,
+				Timeout: time.Second,
+			})
+			c.So(err, ShouldBeNil)
+		}
+
+		start := time.Now()
+		statuses := r.RunWithin(StagePreSuspend, 100*time.Millisecond)
+		c.So(time.Since(start), ShouldBeLessThan, 500*time.Millisecond)
+		c.So(statuses, ShouldHaveLength, 3)
+		c.So(statuses[0].LastResult, ShouldEqual, ResultTimeout)
+		c.So(statuses[1].LastResult, ShouldEqual, ResultSkipped)
+		c.So(statuses[2].LastResult, ShouldEqual, ResultSkipped)
+	})
+}
+
+func TestRegistryScripts(t *testing.T) {
+	Convey("Registry scripts", t, func(c C) {
+		dir, err := ioutil.TempDir("", "sleep-hooks")
+		c.So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		stageDir := filepath.Join(dir, StagePostResume)
+		c.So(os.MkdirAll(stageDir, 0755), ShouldBeNil)
+		c.So(ioutil.WriteFile(filepath.Join(stageDir, "10-ok"),
+			[]byte("#!/bin/sh\ntest \"$1\" = post-resume\n"), 0755), ShouldBeNil)
+		c.So(ioutil.WriteFile(filepath.Join(stageDir, "fail"),
+			[]byte("#!/bin/sh\necho oops\nexit 1\n"), 0755), ShouldBeNil)
+		c.So(ioutil.WriteFile(filepath.Join(stageDir, "README"),
+			[]byte("not executable"), 0644), ShouldBeNil)
+
+		r := NewRegistry(dir)
+		statuses := r.Run(StagePostResume)
+		c.So(statuses, ShouldHaveLength, 2)
+		c.So(statuses[0].Name, ShouldEqual, "10-ok")
+		c.So(statuses[0].Source, ShouldEqual, SourceScript)
+		c.So(statuses[0].LastResult, ShouldEqual, ResultSuccess)
+		c.So(statuses[1].Name, ShouldEqual, "fail")
+		c.So(statuses[1].Order, ShouldEqual, DefaultOrder)
+		c.So(statuses[1].LastResult, ShouldEqual, ResultFailed)
+		c.So(statuses[1].LastError, ShouldContainSubstring, "oops")
+	})
+}
+
+func Test_getScriptOrder(t *testing.T) {
+	Convey("getScriptOrder", t, func(c C) {
+		c.So(getScriptOrder("10-vpn"), ShouldEqual, 10)
+		c.So(getScriptOrder("99"), ShouldEqual, 99)
+		c.So(getScriptOrder("vpn"), ShouldEqual, DefaultOrder)
+	})
+}
@@ -0,0 +1,164 @@
+package power
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"pkg.deepin.io/lib/xdg/basedir"
+)
+
+// 进入警告级别后，宽限期结束时执行的动作
+const (
+	warnActionNone        = ""
+	warnActionSuspend     = "suspend"
+	warnActionHibernate   = "hibernate"
+	warnActionHybridSleep = "hybrid-sleep"
+	warnActionShutdown    = "shutdown"
+	warnActionCommand     = "command"
+)
+
+const (
+	maxWarnActionGracePeriod = 600
+	maxWarnActionDimPercent  = 90
+)
+
+var warnLevelActionsFile = filepath.Join(basedir.GetUserConfigDir(),
+	"deepin/dde-daemon/power/warn-level-actions.json")
+
+// warnAction 是进入某个警告级别时执行的动作
+type warnAction struct {
+	// 是否发送通知，NotifyText 为空时使用默认的文字
+	Notify     bool
+	NotifyText string
+	// 开启节能模式
+	PowerSave bool
+	// 按百分比降低当前亮度，0 表示不降低，电量恢复后还原
+	DimPercent uint32
+	// Action 在宽限期结束后执行，宽限期内可以通过 DismissWarnLevelAction 取消，
+	// Action 为 command 时执行 Command
+	Action  string
+	Command string
+	// 宽限期，单位为秒
+	GracePeriod uint32
+}
+
+func (a *warnAction) check() error {
+	switch a.Action {
+	case warnActionNone, warnActionSuspend, warnActionHibernate,
+		warnActionHybridSleep, warnActionShutdown:
+		if a.Command != "" {
+			return errors.New("command is only allowed for action command")
+		}
+	case warnActionCommand:
+		if a.Command == "" {
+			return errors.New("command is empty")
+		}
+	default:
+		return fmt.Errorf("invalid action %q", a.Action)
+	}
+	if a.DimPercent > maxWarnActionDimPercent {
+		return fmt.Errorf("dim percent %d is out of range [0, %d]", a.DimPercent, maxWarnActionDimPercent)
+	}
+	if a.GracePeriod > maxWarnActionGracePeriod {
+		return fmt.Errorf("grace period %d is out of range [0, %d]", a.GracePeriod, maxWarnActionGracePeriod)
+	}
+	return nil
+}
+
+// isSleepAction 判断动作执行后是否会离开会话，这种动作执行前会锁屏并显示低电量界面
+func (a *warnAction) isSleepAction() bool {
+	switch a.Action {
+	case warnActionSuspend, warnActionHibernate, warnActionHybridSleep, warnActionShutdown:
+		return true
+	}
+	return false
+}
+
+// warnLevelActionPolicy 是各个警告级别的动作
+type warnLevelActionPolicy struct {
+	Low      *warnAction
+	Danger   *warnAction
+	Critical *warnAction
+	Action   *warnAction
+}
+
+// getDefaultWarnLevelActionPolicy 返回默认的策略，前三个级别只发通知，
+// Action 级别通知后 5 秒待机
+func getDefaultWarnLevelActionPolicy() *warnLevelActionPolicy {
+	return &warnLevelActionPolicy{
+		Low:      &warnAction{Notify: true},
+		Danger:   &warnAction{Notify: true},
+		Critical: &warnAction{Notify: true},
+		Action: &warnAction{
+			Notify:      true,
+			Action:      warnActionSuspend,
+			GracePeriod: 5,
+		},
+	}
+}
+
+func (p *warnLevelActionPolicy) check() error {
+	levels := []WarnLevel{WarnLevelLow, WarnLevelDanger, WarnLevelCritical, WarnLevelAction}
+	for _, level := range levels {
+		a := p.get(level)
+		if a == nil {
+			return fmt.Errorf("action of warn level %v is missing", level)
+		}
+		err := a.check()
+		if err != nil {
+			return fmt.Errorf("warn level %v: %v", level, err)
+		}
+	}
+	return nil
+}
+
+func (p *warnLevelActionPolicy) get(level WarnLevel) *warnAction {
+	switch level {
+	case WarnLevelLow:
+		return p.Low
+	case WarnLevelDanger:
+		return p.Danger
+	case WarnLevelCritical:
+		return p.Critical
+	case WarnLevelAction:
+		return p.Action
+	}
+	return nil
+}
+
+func parseWarnLevelActionPolicy(data []byte) (*warnLevelActionPolicy, error) {
+	var p warnLevelActionPolicy
+	err := json.Unmarshal(data, &p)
+	if err != nil {
+		return nil, err
+	}
+	err = p.check()
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func loadWarnLevelActionPolicy(file string) (*warnLevelActionPolicy, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return parseWarnLevelActionPolicy(content)
+}
+
+func (p *warnLevelActionPolicy) save(file string) error {
+	err := os.MkdirAll(filepath.Dir(file), 0755)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, content, 0644)
+}
@@ -20,10 +20,14 @@
 package power
 
 import (
+	"encoding/json"
+	"os"
+	"sync"
 	"time"
 
 	dbus "github.com/godbus/dbus"
 	gio "pkg.deepin.io/gir/gio-2.0"
+	"pkg.deepin.io/lib/dbusutil"
 	"pkg.deepin.io/lib/dbusutil/gsprop"
 	"pkg.deepin.io/lib/gsettings"
 )
@@ -69,13 +73,26 @@ type WarnLevelConfigManager struct {
 	settings    *gio.Settings
 	changeTimer *time.Timer
 	changeCb    func()
+
+	actionPolicyMu   sync.Mutex
+	actionPolicy     *warnLevelActionPolicy
+	actionPolicyFile string
+
+	// nolint
+	methods *struct {
+		GetActionPolicy   func() `out:"policy"`
+		SetActionPolicy   func() `in:"policy"`
+		ResetActionPolicy func()
+	}
 }
 
 func NewWarnLevelConfigManager(gs *gio.Settings) *WarnLevelConfigManager {
 
 	m := &WarnLevelConfigManager{
-		settings: gs,
+		settings:         gs,
+		actionPolicyFile: warnLevelActionsFile,
 	}
+	m.loadActionPolicy()
 
 	m.UsePercentageForPolicy.Bind(gs, settingKeyUsePercentageForPolicy)
 	m.LowTime.Bind(gs, settingKeyLowTime)
@@ -172,6 +189,70 @@ func (m *WarnLevelConfigManager) Reset() *dbus.Error {
 	return nil
 }
 
+func (m *WarnLevelConfigManager) loadActionPolicy() {
+	policy, err := loadWarnLevelActionPolicy(m.actionPolicyFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warning("failed to load warn level action policy:", err)
+		}
+		policy = getDefaultWarnLevelActionPolicy()
+	}
+	m.actionPolicy = policy
+}
+
+// getAction 返回警告级别 level 的动作
+func (m *WarnLevelConfigManager) getAction(level WarnLevel) *warnAction {
+	m.actionPolicyMu.Lock()
+	defer m.actionPolicyMu.Unlock()
+	a := m.actionPolicy.get(level)
+	if a == nil {
+		return nil
+	}
+	action := *a
+	return &action
+}
+
+// GetActionPolicy 返回各警告级别的动作，结果为 JSON，
+// 包含 Low、Danger、Critical 和 Action 四个级别的动作
+func (m *WarnLevelConfigManager) GetActionPolicy() (string, *dbus.Error) {
+	m.actionPolicyMu.Lock()
+	data, err := json.Marshal(m.actionPolicy)
+	m.actionPolicyMu.Unlock()
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return string(data), nil
+}
+
+// SetActionPolicy 设置各警告级别的动作，policy 的格式与 GetActionPolicy 的结果相同，
+// 下次警告级别改变时生效
+func (m *WarnLevelConfigManager) SetActionPolicy(policy string) *dbus.Error {
+	p, err := parseWarnLevelActionPolicy([]byte(policy))
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	m.actionPolicyMu.Lock()
+	defer m.actionPolicyMu.Unlock()
+	err = p.save(m.actionPolicyFile)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	m.actionPolicy = p
+	return nil
+}
+
+// ResetActionPolicy 恢复默认的动作：只发通知，Action 级别 5 秒后待机
+func (m *WarnLevelConfigManager) ResetActionPolicy() *dbus.Error {
+	m.actionPolicyMu.Lock()
+	defer m.actionPolicyMu.Unlock()
+	err := os.Remove(m.actionPolicyFile)
+	if err != nil && !os.IsNotExist(err) {
+		return dbusutil.ToError(err)
+	}
+	m.actionPolicy = getDefaultWarnLevelActionPolicy()
+	return nil
+}
+
 func (*WarnLevelConfigManager) GetInterfaceName() string {
 	return dbusInterface + ".WarnLevelConfig"
 }
@@ -20,7 +20,10 @@
 package power
 
 import (
+	"errors"
+	"io/ioutil"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -59,14 +62,26 @@ type Battery struct {
 	TimeToEmpty uint64
 	TimeToFull  uint64
 	UpdateTime  int64
+	// 充电循环次数，内核不提供时为估算值
+	CycleCount uint32
+
+	// 充电阈值，通过 Manager 的 SetChargeThreshold 设置
+	ChargeThresholdSupported bool
+	ChargeStartThreshold     uint32
+	ChargeEndThreshold       uint32
+	// 是否正在临时取消充电阈值充满一次
+	FullChargeOnce bool
 
 	batteryHistory []float64
+	history        *batteryHistory
 
 	refreshDone func()
 
 	// nolint
 	methods *struct {
-		Debug func() `in:"cmd"`
+		Debug            func() `in:"cmd"`
+		GetHistory       func() `in:"window,resolution" out:"history"`
+		GetHealthHistory func() `out:"history"`
 	}
 }
 
@@ -214,6 +229,11 @@ func (bat *Battery) _refresh(info *battery.BatteryInfo, setTimeToFull bool) {
 		time.Duration(info.TimeToFull)*time.Second,
 		info.TimeToFull)
 
+	var cycleCount uint32
+	if isPresent {
+		cycleCount = bat.recordHistory(info, updateTime)
+	}
+
 	/* lie to full */
 	bat.appendToHistory(info.Percentage)
 	if info.Percentage > 97.0 && bat.getHistoryLength() >= 10 && bat.calcHistoryVariance() < 0.3 {
@@ -237,6 +257,7 @@ func (bat *Battery) _refresh(info *battery.BatteryInfo, setTimeToFull bool) {
 	bat.setPropVoltage(info.Voltage)
 	bat.setPropPercentage(info.Percentage)
 	bat.setPropCapacity(info.Capacity)
+	bat.setPropCycleCount(cycleCount)
 	bat.setPropStatus(info.Status)
 	bat.setPropTimeToEmpty(info.TimeToEmpty)
 	if setTimeToFull {
@@ -252,6 +273,75 @@ func (bat *Battery) _refresh(info *battery.BatteryInfo, setTimeToFull bool) {
 	}
 }
 
+// recordHistory 保存电池的记录，返回充电循环次数
+func (bat *Battery) recordHistory(info *battery.BatteryInfo, now int64) uint32 {
+	// 记录的 id 需要电池的序列号，在第一次刷新时创建
+	bat.PropsMu.Lock()
+	if bat.history == nil {
+		id := getBatteryHistoryId(bat.SysfsPath, info.SerialNumber)
+		bat.history = newBatteryHistory(batteryHistoryDir, id)
+	}
+	history := bat.history
+	bat.PropsMu.Unlock()
+
+	sysCycleCount := bat.getSysCycleCount()
+	history.add(BatteryHistorySample{
+		Time:       now,
+		Percentage: info.Percentage,
+		EnergyRate: info.EnergyRate,
+		Voltage:    info.Voltage,
+		Status:     uint32(info.Status),
+	}, info, sysCycleCount)
+	return history.GetCycleCount(sysCycleCount)
+}
+
+// getHistory 返回电池的记录，还没有刷新过时返回 nil
+func (bat *Battery) getHistory() *batteryHistory {
+	bat.PropsMu.RLock()
+	defer bat.PropsMu.RUnlock()
+	return bat.history
+}
+
+func (bat *Battery) getSysCycleCount() uint32 {
+	content, err := ioutil.ReadFile(filepath.Join(bat.SysfsPath, "cycle_count"))
+	if err != nil {
+		return 0
+	}
+	count, err := strconv.ParseUint(strings.TrimSpace(string(content)), 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint32(count)
+}
+
+// GetHistory 返回最近 window 秒内的电池记录，每 resolution 秒合并为一条
+func (bat *Battery) GetHistory(window, resolution int64) ([]BatteryHistorySample, *dbus.Error) {
+	if window <= 0 || resolution <= 0 {
+		return nil, dbusutil.ToError(errors.New("invalid window or resolution"))
+	}
+	if window/resolution > batteryHistoryMaxPoints {
+		return nil, dbusutil.ToError(errors.New("resolution is too small"))
+	}
+	history := bat.getHistory()
+	if history == nil {
+		return nil, nil
+	}
+	samples, err := history.GetSamples(time.Now().Unix() - window)
+	if err != nil {
+		return nil, dbusutil.ToError(err)
+	}
+	return downsampleBatteryHistory(samples, resolution), nil
+}
+
+// GetHealthHistory 返回电池健康度的记录，每天一条
+func (bat *Battery) GetHealthHistory() ([]BatteryHealthSample, *dbus.Error) {
+	history := bat.getHistory()
+	if history == nil {
+		return nil, nil
+	}
+	return history.GetHealthSamples(), nil
+}
+
 func (bat *Battery) Refresh() {
 	dev := bat.newDevice()
 	if dev != nil {
@@ -296,4 +386,10 @@ func (bat *Battery) destroy() {
 		close(bat.exit)
 		bat.exit = nil
 	}
+	if history := bat.getHistory(); history != nil {
+		err := history.Flush()
+		if err != nil {
+			logger.Warning("failed to save battery history:", err)
+		}
+	}
 }
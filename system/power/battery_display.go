@@ -107,6 +107,8 @@ func (m *Manager) refreshBatteryDisplay() {
 	energyCapacity = rightPercentage(energyFullTotal / energyFullDesignTotal * 100.0)
 	m.setPropBatteryCapacity(energyCapacity)
 	m.PropsMu.Unlock()
+	// 调用者持有 batteriesMu，应用配置可能需要重新加载 laptop-mode，不能在这里等待
+	go m.updatePowerProfileByTrigger()
 
 	logger.Debugf("BatteryCapacity %.1f%%", energyCapacity)
 	logger.Debugf("percentage: %.1f%%", percentage)
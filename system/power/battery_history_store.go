@@ -0,0 +1,293 @@
+package power
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"pkg.deepin.io/dde/api/powersupply/battery"
+)
+
+var batteryHistoryDir = "/var/lib/dde-daemon/power/history"
+
+const (
+	// 积累多少条记录后写入文件
+	batteryHistoryFlushCount = 10
+	// 记录文件超过这个大小后轮转，只保留一个旧文件
+	batteryHistoryMaxFileSize = 1024 * 1024
+	// 健康记录的间隔和最多保留的条数
+	batteryHealthInterval = 24 * time.Hour
+	batteryHealthMaxLen   = 730
+
+	batteryHistoryMaxPoints = 10000
+)
+
+// BatteryHistorySample 是一条电池电量的记录
+type BatteryHistorySample struct {
+	Time       int64
+	Percentage float64
+	EnergyRate float64
+	Voltage    float64
+	Status     uint32
+}
+
+// BatteryHealthSample 是一条电池健康度的记录，Capacity 是满电能量和设计能量的百分比
+type BatteryHealthSample struct {
+	Time             int64
+	EnergyFull       float64
+	EnergyFullDesign float64
+	Capacity         float64
+	CycleCount       uint32
+}
+
+type batteryHealthData struct {
+	Samples []BatteryHealthSample
+	// 估算的充电循环次数，累计放电的百分比除以 100
+	EstimatedCycles float64
+	LastPercentage  float64
+}
+
+// batteryHistory 把电池的记录保存在 batteryHistoryDir 中，
+// 每个电池有一个记录文件和一个健康度文件
+type batteryHistory struct {
+	mu         sync.Mutex
+	file       string
+	healthFile string
+	pending    []BatteryHistorySample
+	health     batteryHealthData
+}
+
+func newBatteryHistory(dir, id string) *batteryHistory {
+	h := &batteryHistory{
+		file:       filepath.Join(dir, id+".log"),
+		healthFile: filepath.Join(dir, id+"-health.json"),
+	}
+	content, err := ioutil.ReadFile(h.healthFile)
+	if err == nil {
+		err = json.Unmarshal(content, &h.health)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		logger.Warning("failed to load battery health:", err)
+	}
+	return h
+}
+
+// getBatteryHistoryId 返回电池记录文件的名称，包含序列号以区分更换过的电池
+func getBatteryHistoryId(sysfsPath, serialNumber string) string {
+	id := getValidName(filepath.Base(sysfsPath))
+	serialNumber = strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return -1
+	}, serialNumber)
+	if serialNumber != "" {
+		id += "_" + serialNumber
+	}
+	return id
+}
+
+// add 添加一条记录，sysCycleCount 是内核提供的循环次数，为 0 时使用估算值
+func (h *batteryHistory) add(sample BatteryHistorySample, info *battery.BatteryInfo, sysCycleCount uint32) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.pending = append(h.pending, sample)
+
+	last := h.health.LastPercentage
+	if sample.Percentage < last &&
+		battery.Status(sample.Status) == battery.StatusDischarging {
+		h.health.EstimatedCycles += (last - sample.Percentage) / 100
+	}
+	h.health.LastPercentage = sample.Percentage
+
+	healthChanged := false
+	samples := h.health.Samples
+	if info.EnergyFullDesign > 0 && (len(samples) == 0 ||
+		sample.Time-samples[len(samples)-1].Time >= int64(batteryHealthInterval/time.Second)) {
+		h.health.Samples = append(samples, BatteryHealthSample{
+			Time:             sample.Time,
+			EnergyFull:       info.EnergyFull,
+			EnergyFullDesign: info.EnergyFullDesign,
+			Capacity:         info.Capacity,
+			CycleCount:       h.getCycleCount(sysCycleCount),
+		})
+		if len(h.health.Samples) > batteryHealthMaxLen {
+			h.health.Samples = h.health.Samples[len(h.health.Samples)-batteryHealthMaxLen:]
+		}
+		healthChanged = true
+	}
+
+	if len(h.pending) >= batteryHistoryFlushCount || healthChanged {
+		err := h.flush()
+		if err != nil {
+			logger.Warning("failed to save battery history:", err)
+		}
+	}
+}
+
+func (h *batteryHistory) getCycleCount(sysCycleCount uint32) uint32 {
+	if sysCycleCount > 0 {
+		return sysCycleCount
+	}
+	return uint32(h.health.EstimatedCycles)
+}
+
+func (h *batteryHistory) GetCycleCount(sysCycleCount uint32) uint32 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.getCycleCount(sysCycleCount)
+}
+
+func (h *batteryHistory) Flush() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.flush()
+}
+
+func (h *batteryHistory) flush() error {
+	err := os.MkdirAll(filepath.Dir(h.file), 0755)
+	if err != nil {
+		return err
+	}
+
+	if len(h.pending) > 0 {
+		info, err := os.Stat(h.file)
+		if err == nil && info.Size() > batteryHistoryMaxFileSize {
+			err = os.Rename(h.file, h.file+".1")
+			if err != nil {
+				return err
+			}
+		}
+
+		f, err := os.OpenFile(h.file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return err
+		}
+		w := bufio.NewWriter(f)
+		for _, s := range h.pending {
+			_, err = fmt.Fprintf(w, "%d %.2f %.3f %.3f %d\n",
+				s.Time, s.Percentage, s.EnergyRate, s.Voltage, s.Status)
+			if err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = w.Flush()
+		}
+		closeErr := f.Close()
+		if err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		h.pending = nil
+	}
+
+	content, err := json.Marshal(&h.health)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(h.healthFile, content, 0644)
+}
+
+func readBatteryHistoryFile(file string, start int64) ([]BatteryHistorySample, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var result []BatteryHistorySample
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var s BatteryHistorySample
+		_, err := fmt.Sscanf(scanner.Text(), "%d %g %g %g %d",
+			&s.Time, &s.Percentage, &s.EnergyRate, &s.Voltage, &s.Status)
+		if err != nil || s.Time < start {
+			continue
+		}
+		result = append(result, s)
+	}
+	return result, scanner.Err()
+}
+
+// GetSamples 返回 start 之后的所有记录，包括还没有写入文件的
+func (h *batteryHistory) GetSamples(start int64) ([]BatteryHistorySample, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var result []BatteryHistorySample
+	for _, file := range []string{h.file + ".1", h.file} {
+		samples, err := readBatteryHistoryFile(file, start)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, samples...)
+	}
+	for _, s := range h.pending {
+		if s.Time >= start {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+func (h *batteryHistory) GetHealthSamples() []BatteryHealthSample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	result := make([]BatteryHealthSample, len(h.health.Samples))
+	copy(result, h.health.Samples)
+	return result
+}
+
+// downsampleBatteryHistory 把记录按 resolution 秒分组，每组取平均值，状态取最后一条记录的
+func downsampleBatteryHistory(samples []BatteryHistorySample, resolution int64) []BatteryHistorySample {
+	if resolution <= 1 {
+		return samples
+	}
+	var result []BatteryHistorySample
+	var sum BatteryHistorySample
+	var count int
+	var bucket int64
+	appendBucket := func() {
+		if count == 0 {
+			return
+		}
+		n := float64(count)
+		result = append(result, BatteryHistorySample{
+			Time:       bucket * resolution,
+			Percentage: sum.Percentage / n,
+			EnergyRate: sum.EnergyRate / n,
+			Voltage:    sum.Voltage / n,
+			Status:     sum.Status,
+		})
+	}
+
+	for _, s := range samples {
+		b := s.Time / resolution
+		if count > 0 && b != bucket {
+			appendBucket()
+			sum = BatteryHistorySample{}
+			count = 0
+		}
+		bucket = b
+		sum.Percentage += s.Percentage
+		sum.EnergyRate += s.EnergyRate
+		sum.Voltage += s.Voltage
+		sum.Status = s.Status
+		count++
+	}
+	appendBucket()
+	return result
+}
@@ -0,0 +1,91 @@
+package power
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"pkg.deepin.io/dde/api/powersupply/battery"
+)
+
+func Test_getBatteryHistoryId(t *testing.T) {
+	Convey("getBatteryHistoryId", t, func(c C) {
+		c.So(getBatteryHistoryId("/sys/devices/LNXSYSTM:00/PNP0C0A:00/power_supply/BAT0", ""), ShouldEqual, "BAT0")
+		c.So(getBatteryHistoryId("/sys/class/power_supply/BAT0", "12 34/5"), ShouldEqual, "BAT0_12345")
+	})
+}
+
+func Test_downsampleBatteryHistory(t *testing.T) {
+	Convey("downsampleBatteryHistory", t, func(c C) {
+		samples := []BatteryHistorySample{
+			{Time: 600, Percentage: 50, Voltage: 12, Status: uint32(battery.StatusDischarging)},
+			{Time: 660, Percentage: 49, Voltage: 11, Status: uint32(battery.StatusDischarging)},
+			{Time: 1200, Percentage: 48, EnergyRate: 10, Status: uint32(battery.StatusCharging)},
+		}
+		c.So(downsampleBatteryHistory(samples, 1), ShouldResemble, samples)
+		c.So(downsampleBatteryHistory(nil, 600), ShouldBeNil)
+		c.So(downsampleBatteryHistory(samples, 600), ShouldResemble, []BatteryHistorySample{
+			{Time: 600, Percentage: 49.5, Voltage: 11.5, Status: uint32(battery.StatusDischarging)},
+			{Time: 1200, Percentage: 48, EnergyRate: 10, Status: uint32(battery.StatusCharging)},
+		})
+	})
+}
+
+func Test_batteryHistory(t *testing.T) {
+	Convey("batteryHistory", t, func(c C) {
+		dir, err := ioutil.TempDir("", "battery-history")
+		c.So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		info := &battery.BatteryInfo{EnergyFull: 40, EnergyFullDesign: 50, Capacity: 80}
+		h := newBatteryHistory(dir, "BAT0")
+		for i := 0; i < batteryHistoryFlushCount+5; i++ {
+			status := battery.StatusDischarging
+			percentage := float64(100 - i*10)
+			if i >= 10 {
+				// 充电后再放电
+				status = battery.StatusCharging
+				percentage = float64(i * 7)
+			}
+			if i == batteryHistoryFlushCount+4 {
+				status = battery.StatusDischarging
+				percentage = 50
+			}
+			h.add(BatteryHistorySample{
+				Time:       int64(1000 + i*60),
+				Percentage: percentage,
+				Status:     uint32(status),
+			}, info, 0)
+		}
+		// 一共放电 90% + 41%
+		c.So(h.GetCycleCount(0), ShouldEqual, 1)
+		c.So(h.GetCycleCount(300), ShouldEqual, 300)
+		c.So(h.GetHealthSamples(), ShouldResemble, []BatteryHealthSample{
+			{Time: 1000, EnergyFull: 40, EnergyFullDesign: 50, Capacity: 80, CycleCount: 0},
+		})
+
+		samples, err := h.GetSamples(0)
+		c.So(err, ShouldBeNil)
+		c.So(samples, ShouldHaveLength, batteryHistoryFlushCount+5)
+		c.So(samples[1].Percentage, ShouldEqual, 90)
+		c.So(samples[len(samples)-1].Percentage, ShouldEqual, 50)
+
+		samples, err = h.GetSamples(1000 + 60*12)
+		c.So(err, ShouldBeNil)
+		c.So(samples, ShouldHaveLength, 3)
+
+		// 写入文件后重新加载，轮转的旧文件也要读取
+		c.So(h.Flush(), ShouldBeNil)
+		err = ioutil.WriteFile(h.file+".1", []byte("900 100.00 0.000 12.000 2\nbad line\n"), 0644)
+		c.So(err, ShouldBeNil)
+
+		h = newBatteryHistory(dir, "BAT0")
+		samples, err = h.GetSamples(0)
+		c.So(err, ShouldBeNil)
+		c.So(samples, ShouldHaveLength, batteryHistoryFlushCount+6)
+		c.So(samples[0].Time, ShouldEqual, 900)
+		c.So(h.GetCycleCount(0), ShouldEqual, 1)
+		c.So(h.GetHealthSamples(), ShouldHaveLength, 1)
+	})
+}
@@ -0,0 +1,152 @@
+package power
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	chargeStartThresholdFile = "charge_control_start_threshold"
+	chargeEndThresholdFile   = "charge_control_end_threshold"
+
+	chargeThresholdConfigFile = "/var/lib/dde-daemon/power/charge_thresholds.json"
+)
+
+// ChargeThreshold 是电池的充电阈值，电量低于 Start 时开始充电，达到 End 时停止充电
+type ChargeThreshold struct {
+	Start uint32
+	End   uint32
+}
+
+var errChargeThresholdNotSupported = errors.New("charge threshold is not supported")
+
+func (t ChargeThreshold) check() error {
+	if t.End == 0 || t.End > 100 {
+		return fmt.Errorf("invalid end threshold %d", t.End)
+	}
+	if t.Start >= t.End {
+		return fmt.Errorf("start threshold %d is not less than end threshold %d", t.Start, t.End)
+	}
+	return nil
+}
+
+// chargeThresholdSysfs 读写 power_supply 目录中电池的充电阈值文件，测试时可以换成假的目录
+type chargeThresholdSysfs struct {
+	dir string
+}
+
+var defaultChargeThresholdSysfs = &chargeThresholdSysfs{dir: "/sys/class/power_supply"}
+
+func (s *chargeThresholdSysfs) file(name, file string) string {
+	return filepath.Join(s.dir, name, file)
+}
+
+func (s *chargeThresholdSysfs) hasFile(name, file string) bool {
+	_, err := os.Stat(s.file(name, file))
+	return err == nil
+}
+
+// IsSupported 判断电池是否支持充电阈值，有些电池只支持停止充电的阈值
+func (s *chargeThresholdSysfs) IsSupported(name string) bool {
+	return s.hasFile(name, chargeEndThresholdFile)
+}
+
+func (s *chargeThresholdSysfs) readValue(name, file string) (uint32, error) {
+	content, err := ioutil.ReadFile(s.file(name, file))
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseUint(strings.TrimSpace(string(content)), 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(value), nil
+}
+
+func (s *chargeThresholdSysfs) writeValue(name, file string, value uint32) error {
+	return ioutil.WriteFile(s.file(name, file), []byte(strconv.FormatUint(uint64(value), 10)), 0644)
+}
+
+func (s *chargeThresholdSysfs) Get(name string) (ChargeThreshold, error) {
+	var t ChargeThreshold
+	if !s.IsSupported(name) {
+		return t, errChargeThresholdNotSupported
+	}
+	var err error
+	t.End, err = s.readValue(name, chargeEndThresholdFile)
+	if err != nil {
+		return t, err
+	}
+	if s.hasFile(name, chargeStartThresholdFile) {
+		t.Start, err = s.readValue(name, chargeStartThresholdFile)
+		if err != nil {
+			return t, err
+		}
+	}
+	return t, nil
+}
+
+// Set 设置电池的充电阈值，驱动要求 start 小于 end，所以要根据旧的值决定写入的顺序
+func (s *chargeThresholdSysfs) Set(name string, t ChargeThreshold) error {
+	err := t.check()
+	if err != nil {
+		return err
+	}
+	old, err := s.Get(name)
+	if err != nil {
+		return err
+	}
+
+	hasStart := s.hasFile(name, chargeStartThresholdFile)
+	if !hasStart {
+		return s.writeValue(name, chargeEndThresholdFile, t.End)
+	}
+
+	if t.Start >= old.End {
+		// 先提高 end
+		err = s.writeValue(name, chargeEndThresholdFile, t.End)
+		if err == nil {
+			err = s.writeValue(name, chargeStartThresholdFile, t.Start)
+		}
+	} else {
+		err = s.writeValue(name, chargeStartThresholdFile, t.Start)
+		if err == nil {
+			err = s.writeValue(name, chargeEndThresholdFile, t.End)
+		}
+	}
+	return err
+}
+
+// chargeThresholdConfig 保存用户设置的每个电池的充电阈值，重启后恢复
+type chargeThresholdConfig map[string]ChargeThreshold
+
+func loadChargeThresholdConfig(file string) (chargeThresholdConfig, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var cfg chargeThresholdConfig
+	err = json.Unmarshal(content, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (cfg chargeThresholdConfig) save(file string) error {
+	err := os.MkdirAll(filepath.Dir(file), 0755)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, content, 0644)
+}
@@ -0,0 +1,82 @@
+package power
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func writeFakeChargeThreshold(c C, dir, name, file, value string) {
+	err := os.MkdirAll(filepath.Join(dir, name), 0755)
+	c.So(err, ShouldBeNil)
+	err = ioutil.WriteFile(filepath.Join(dir, name, file), []byte(value), 0644)
+	c.So(err, ShouldBeNil)
+}
+
+func Test_chargeThresholdSysfs(t *testing.T) {
+	Convey("chargeThresholdSysfs", t, func(c C) {
+		dir, err := ioutil.TempDir("", "power-supply")
+		c.So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		writeFakeChargeThreshold(c, dir, "BAT0", chargeStartThresholdFile, "0\n")
+		writeFakeChargeThreshold(c, dir, "BAT0", chargeEndThresholdFile, "100\n")
+		writeFakeChargeThreshold(c, dir, "BAT1", chargeEndThresholdFile, "100\n")
+		err = os.MkdirAll(filepath.Join(dir, "BAT2"), 0755)
+		c.So(err, ShouldBeNil)
+
+		s := &chargeThresholdSysfs{dir: dir}
+		c.So(s.IsSupported("BAT0"), ShouldBeTrue)
+		c.So(s.IsSupported("BAT1"), ShouldBeTrue)
+		c.So(s.IsSupported("BAT2"), ShouldBeFalse)
+
+		_, err = s.Get("BAT2")
+		c.So(err, ShouldEqual, errChargeThresholdNotSupported)
+
+		th, err := s.Get("BAT0")
+		c.So(err, ShouldBeNil)
+		c.So(th, ShouldResemble, ChargeThreshold{Start: 0, End: 100})
+
+		c.So(s.Set("BAT0", ChargeThreshold{Start: 40, End: 80}), ShouldBeNil)
+		th, err = s.Get("BAT0")
+		c.So(err, ShouldBeNil)
+		c.So(th, ShouldResemble, ChargeThreshold{Start: 40, End: 80})
+
+		// 新的 start 不小于旧的 end
+		c.So(s.Set("BAT0", ChargeThreshold{Start: 85, End: 95}), ShouldBeNil)
+		th, err = s.Get("BAT0")
+		c.So(err, ShouldBeNil)
+		c.So(th, ShouldResemble, ChargeThreshold{Start: 85, End: 95})
+
+		// 只支持 end
+		c.So(s.Set("BAT1", ChargeThreshold{Start: 40, End: 60}), ShouldBeNil)
+		th, err = s.Get("BAT1")
+		c.So(err, ShouldBeNil)
+		c.So(th, ShouldResemble, ChargeThreshold{Start: 0, End: 60})
+
+		c.So(s.Set("BAT0", ChargeThreshold{Start: 80, End: 80}), ShouldNotBeNil)
+		c.So(s.Set("BAT0", ChargeThreshold{Start: 0, End: 101}), ShouldNotBeNil)
+		c.So(s.Set("BAT0", ChargeThreshold{Start: 0, End: 0}), ShouldNotBeNil)
+	})
+}
+
+func Test_chargeThresholdConfig(t *testing.T) {
+	Convey("chargeThresholdConfig", t, func(c C) {
+		dir, err := ioutil.TempDir("", "charge-threshold")
+		c.So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		file := filepath.Join(dir, "power", "charge_thresholds.json")
+		_, err = loadChargeThresholdConfig(file)
+		c.So(os.IsNotExist(err), ShouldBeTrue)
+
+		cfg := chargeThresholdConfig{"BAT0": {Start: 40, End: 80}}
+		c.So(cfg.save(file), ShouldBeNil)
+		cfg, err = loadChargeThresholdConfig(file)
+		c.So(err, ShouldBeNil)
+		c.So(cfg, ShouldResemble, chargeThresholdConfig{"BAT0": {Start: 40, End: 80}})
+	})
+}
@@ -62,6 +62,17 @@ type Manager struct {
 	// CPU操作接口
 	cpus *CpuHandlers
 
+	// 充电阈值
+	chargeSysfs    *chargeThresholdSysfs
+	chargeCfg      chargeThresholdConfig
+	chargeCfgMu    sync.Mutex
+	fullChargeOnce map[string]bool
+
+	// 电源配置
+	profilesCfg      *powerProfilesConfig
+	profilesMu       sync.Mutex
+	triggeredProfile string
+
 	PropsMu      sync.RWMutex
 	OnBattery    bool
 	HasLidSwitch bool
@@ -98,6 +109,9 @@ type Manager struct {
 	// 当前模式
 	Mode string
 
+	// 当前使用的电源配置
+	ActiveProfile string
+
 	// nolint
 	methods *struct {
 		GetBatteries   func() `out:"batteries"`
@@ -106,6 +120,16 @@ type Manager struct {
 		SetCpuBoost    func() `in:"enabled"`
 		SetMode        func() `in:"mode"`
 		LockCpuFreq    func() `in:"governor, lockTime"`
+
+		GetChargeThreshold func() `in:"name" out:"start,end"`
+		SetChargeThreshold func() `in:"name,start,end"`
+		SetFullChargeOnce  func() `in:"name,enabled"`
+
+		ListPowerProfiles  func() `out:"profiles"`
+		GetPowerProfile    func() `in:"name" out:"profile"`
+		SetPowerProfile    func() `in:"profile"`
+		DeletePowerProfile func() `in:"name"`
+		ApplyPowerProfile  func() `in:"name"`
 	}
 	// nolint
 	signals *struct {
@@ -123,6 +147,11 @@ type Manager struct {
 
 		LidClosed struct{}
 		LidOpened struct{}
+
+		// 每次应用电源配置后发送，包括修改当前使用的配置
+		PowerProfileApplied struct {
+			name string
+		}
 	}
 }
 
@@ -167,6 +196,7 @@ func (m *Manager) refreshAC(ac *gudev.Device) { // 拔插电源时候触发
 	m.PropsMu.Unlock()
 	// 根据OnBattery的状态,修改节能模式
 	m.updatePowerSavingMode()
+	m.updatePowerProfileByTrigger()
 }
 
 func (m *Manager) initAC(devices []*gudev.Device) {
@@ -213,6 +243,8 @@ func (m *Manager) init() error {
 	m.PowerSavingModeBrightnessDropPercent = cfg.PowerSavingModeBrightnessDropPercent // 开启节能模式时降低亮度的百分比值
 	m.Mode = cfg.Mode
 
+	m.initChargeThreshold()
+	m.initPowerProfiles()
 	m.initAC(devices)
 	m.initBatteries(devices)
 	for _, dev := range devices {
@@ -236,6 +268,8 @@ func (m *Manager) init() error {
 		logger.Warning(err)
 	}
 
+	m.restorePowerProfile()
+	m.updatePowerProfileByTrigger()
 	return nil
 }
 
@@ -323,7 +357,11 @@ func (m *Manager) addBattery(dev *gudev.Device) (*Battery, bool) {
 	m.batteries[sysfsPath] = bat
 	m.refreshBatteryDisplay()
 	m.batteriesMu.Unlock()
-	bat.setRefreshDoneCallback(m.refreshBatteryDisplay)
+	m.initBatteryChargeThreshold(bat)
+	bat.setRefreshDoneCallback(func() {
+		m.refreshBatteryDisplay()
+		m.checkFullChargeOnce(bat)
+	})
 	return bat, true
 }
 
@@ -0,0 +1,234 @@
+package power
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	dbus "github.com/godbus/dbus"
+	polkit "github.com/linuxdeepin/go-dbus-factory/org.freedesktop.policykit1"
+	"pkg.deepin.io/dde/api/powersupply/battery"
+	"pkg.deepin.io/lib/dbusutil"
+)
+
+const polkitActionSetChargeThreshold = "com.deepin.daemon.power.set-charge-threshold"
+
+// 不限制充电时的阈值
+var noChargeThreshold = ChargeThreshold{Start: 0, End: 100}
+
+func (m *Manager) initChargeThreshold() {
+	m.chargeSysfs = defaultChargeThresholdSysfs
+	m.fullChargeOnce = make(map[string]bool)
+	cfg, err := loadChargeThresholdConfig(chargeThresholdConfigFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warning("failed to load charge threshold config:", err)
+		}
+		cfg = make(chargeThresholdConfig)
+	}
+	m.chargeCfg = cfg
+}
+
+func getBatteryDeviceName(bat *Battery) string {
+	return filepath.Base(bat.SysfsPath)
+}
+
+func (m *Manager) getBatteryByDeviceName(name string) *Battery {
+	m.batteriesMu.Lock()
+	defer m.batteriesMu.Unlock()
+	for _, bat := range m.batteries {
+		if getBatteryDeviceName(bat) == name {
+			return bat
+		}
+	}
+	return nil
+}
+
+// initBatteryChargeThreshold 把保存的充电阈值写入新加入的电池
+func (m *Manager) initBatteryChargeThreshold(bat *Battery) {
+	name := getBatteryDeviceName(bat)
+	if !m.chargeSysfs.IsSupported(name) {
+		return
+	}
+
+	m.chargeCfgMu.Lock()
+	t, ok := m.chargeCfg[name]
+	m.chargeCfgMu.Unlock()
+	if ok {
+		err := m.chargeSysfs.Set(name, t)
+		if err != nil {
+			logger.Warningf("failed to restore charge threshold of %s: %v", name, err)
+		}
+	}
+	m.updateBatteryChargeThreshold(bat)
+}
+
+func (m *Manager) updateBatteryChargeThreshold(bat *Battery) {
+	name := getBatteryDeviceName(bat)
+	t, err := m.chargeSysfs.Get(name)
+	supported := err == nil
+	if err != nil && err != errChargeThresholdNotSupported {
+		logger.Warning(err)
+	}
+
+	m.chargeCfgMu.Lock()
+	fullChargeOnce := m.fullChargeOnce[name]
+	m.chargeCfgMu.Unlock()
+
+	bat.PropsMu.Lock()
+	bat.setPropChargeThresholdSupported(supported)
+	bat.setPropChargeStartThreshold(t.Start)
+	bat.setPropChargeEndThreshold(t.End)
+	bat.setPropFullChargeOnce(fullChargeOnce)
+	bat.PropsMu.Unlock()
+}
+
+func (m *Manager) getChargeThresholdBattery(name string) (*Battery, error) {
+	bat := m.getBatteryByDeviceName(name)
+	if bat == nil {
+		return nil, fmt.Errorf("battery %q not found", name)
+	}
+	if !m.chargeSysfs.IsSupported(name) {
+		return nil, errChargeThresholdNotSupported
+	}
+	return bat, nil
+}
+
+func (m *Manager) setChargeThreshold(name string, t ChargeThreshold) error {
+	err := t.check()
+	if err != nil {
+		return err
+	}
+	bat, err := m.getChargeThresholdBattery(name)
+	if err != nil {
+		return err
+	}
+
+	m.chargeCfgMu.Lock()
+	// 正在充满一次时，等充满后再应用新的阈值
+	if !m.fullChargeOnce[name] {
+		err = m.chargeSysfs.Set(name, t)
+	}
+	if err == nil {
+		m.chargeCfg[name] = t
+		err = m.chargeCfg.save(chargeThresholdConfigFile)
+	}
+	m.chargeCfgMu.Unlock()
+
+	m.updateBatteryChargeThreshold(bat)
+	return err
+}
+
+// setFullChargeOnce 临时取消充电阈值，把电池充满一次，充满后恢复设置的阈值
+func (m *Manager) setFullChargeOnce(name string, enabled bool) error {
+	bat, err := m.getChargeThresholdBattery(name)
+	if err != nil {
+		return err
+	}
+	return m.doSetFullChargeOnce(bat, enabled)
+}
+
+func (m *Manager) doSetFullChargeOnce(bat *Battery, enabled bool) error {
+	name := getBatteryDeviceName(bat)
+	var err error
+	m.chargeCfgMu.Lock()
+	if enabled {
+		err = m.chargeSysfs.Set(name, noChargeThreshold)
+	} else {
+		err = m.restoreChargeThreshold(name)
+	}
+	if err == nil {
+		m.fullChargeOnce[name] = enabled
+	}
+	m.chargeCfgMu.Unlock()
+
+	m.updateBatteryChargeThreshold(bat)
+	return err
+}
+
+// restoreChargeThreshold 恢复用户设置的阈值，调用者需要持有 chargeCfgMu
+func (m *Manager) restoreChargeThreshold(name string) error {
+	t, ok := m.chargeCfg[name]
+	if !ok {
+		t = noChargeThreshold
+	}
+	return m.chargeSysfs.Set(name, t)
+}
+
+// checkFullChargeOnce 在电池刷新后调用，电池充满后结束充满一次。
+// 刷新时可能持有 batteriesMu，所以这里不能再获取
+func (m *Manager) checkFullChargeOnce(bat *Battery) {
+	name := getBatteryDeviceName(bat)
+	m.chargeCfgMu.Lock()
+	if !m.fullChargeOnce[name] {
+		m.chargeCfgMu.Unlock()
+		return
+	}
+	m.chargeCfgMu.Unlock()
+
+	bat.PropsMu.RLock()
+	full := bat.Status == battery.StatusFull || bat.Percentage >= 100
+	bat.PropsMu.RUnlock()
+	if !full {
+		return
+	}
+
+	logger.Infof("battery %s is fully charged, restore charge threshold", name)
+	err := m.doSetFullChargeOnce(bat, false)
+	if err != nil {
+		logger.Warning(err)
+	}
+}
+
+func checkAuthorization(actionId string, sysBusName string) error {
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return err
+	}
+	authority := polkit.NewAuthority(systemBus)
+	subject := polkit.MakeSubject(polkit.SubjectKindSystemBusName)
+	subject.SetDetail("name", sysBusName)
+
+	ret, err := authority.CheckAuthorization(0, subject, actionId,
+		nil, polkit.CheckAuthorizationFlagsAllowUserInteraction, "")
+	if err != nil {
+		return err
+	}
+	if !ret.IsAuthorized {
+		return errors.New("not authorized")
+	}
+	return nil
+}
+
+// GetChargeThreshold 返回电池当前的充电阈值，name 是电池的设备名，如 BAT0
+func (m *Manager) GetChargeThreshold(name string) (start, end uint32, busErr *dbus.Error) {
+	if m.getBatteryByDeviceName(name) == nil {
+		return 0, 0, dbusutil.ToError(fmt.Errorf("battery %q not found", name))
+	}
+	t, err := m.chargeSysfs.Get(name)
+	if err != nil {
+		return 0, 0, dbusutil.ToError(err)
+	}
+	return t.Start, t.End, nil
+}
+
+// SetChargeThreshold 设置电池的充电阈值并保存，重启后恢复
+func (m *Manager) SetChargeThreshold(sender dbus.Sender, name string, start, end uint32) *dbus.Error {
+	err := checkAuthorization(polkitActionSetChargeThreshold, string(sender))
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	err = m.setChargeThreshold(name, ChargeThreshold{Start: start, End: end})
+	return dbusutil.ToError(err)
+}
+
+// SetFullChargeOnce 开启时临时取消充电阈值，电池充满后自动恢复
+func (m *Manager) SetFullChargeOnce(sender dbus.Sender, name string, enabled bool) *dbus.Error {
+	err := checkAuthorization(polkitActionSetChargeThreshold, string(sender))
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	err = m.setFullChargeOnce(name, enabled)
+	return dbusutil.ToError(err)
+}
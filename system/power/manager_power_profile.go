@@ -0,0 +1,247 @@
+package power
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	dbus "github.com/godbus/dbus"
+	"pkg.deepin.io/lib/dbusutil"
+)
+
+const polkitActionManagePowerProfile = "com.deepin.daemon.power.manage-power-profile"
+
+func (m *Manager) initPowerProfiles() {
+	cfg, err := loadPowerProfilesConfig(powerProfilesConfigFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warning("failed to load power profiles config:", err)
+		}
+		cfg = &powerProfilesConfig{}
+	}
+	m.profilesCfg = cfg
+	if cfg.get(cfg.Active) == nil {
+		cfg.Active = ""
+	}
+	m.ActiveProfile = cfg.Active
+}
+
+// restorePowerProfile 启动时重新应用当前的配置，CPU 等设置重启后不会保留
+func (m *Manager) restorePowerProfile() {
+	m.profilesMu.Lock()
+	profile := m.profilesCfg.get(m.profilesCfg.Active)
+	m.profilesMu.Unlock()
+	if profile == nil {
+		return
+	}
+	err := m.doApplyPowerProfile(profile)
+	if err != nil {
+		logger.Warning(err)
+	}
+}
+
+// updatePowerProfileByTrigger 在供电方式或电量变化后调用，匹配的配置改变时自动切换
+func (m *Manager) updatePowerProfileByTrigger() {
+	if !m.initDone {
+		return
+	}
+	m.PropsMu.RLock()
+	onBattery := m.OnBattery
+	percentage := m.BatteryPercentage
+	m.PropsMu.RUnlock()
+
+	m.profilesMu.Lock()
+	profile := selectPowerProfile(m.profilesCfg.Profiles, onBattery, percentage)
+	var name string
+	if profile != nil {
+		name = profile.Name
+	}
+	changed := name != m.triggeredProfile
+	m.triggeredProfile = name
+	m.profilesMu.Unlock()
+
+	if !changed || profile == nil {
+		return
+	}
+	logger.Infof("auto switch to power profile %q", name)
+	err := m.applyPowerProfile(name)
+	if err != nil {
+		logger.Warning(err)
+	}
+}
+
+func (m *Manager) applyPowerProfile(name string) error {
+	m.profilesMu.Lock()
+	profile := m.profilesCfg.get(name)
+	m.profilesMu.Unlock()
+	if profile == nil {
+		return fmt.Errorf("power profile %q not found", name)
+	}
+
+	err := m.doApplyPowerProfile(profile)
+	if err != nil {
+		return err
+	}
+
+	m.profilesMu.Lock()
+	m.profilesCfg.Active = name
+	err = m.profilesCfg.save(powerProfilesConfigFile)
+	m.profilesMu.Unlock()
+
+	m.PropsMu.Lock()
+	m.setPropActiveProfile(name)
+	m.PropsMu.Unlock()
+
+	emitErr := m.service.Emit(m, "PowerProfileApplied", name)
+	if emitErr != nil {
+		logger.Warning(emitErr)
+	}
+	return err
+}
+
+// doApplyPowerProfile 应用配置中 system power 负责的部分，
+// 屏幕和待机的设置由 session power 收到 PowerProfileApplied 信号后应用
+func (m *Manager) doApplyPowerProfile(profile *PowerProfile) error {
+	logger.Info("apply power profile", profile.Name)
+	if profile.CpuGovernor != "" {
+		if !m.isCpuGovernorSupported(profile.CpuGovernor) {
+			return fmt.Errorf("governor %q is not supported", profile.CpuGovernor)
+		}
+		err := m.doSetCpuGovernor(profile.CpuGovernor)
+		if err != nil {
+			return err
+		}
+	}
+
+	if profile.CpuBoost != nil && m.IsHighPerformanceSupported {
+		err := m.doSetCpuBoost(*profile.CpuBoost)
+		if err != nil {
+			return err
+		}
+	}
+
+	if lmtCfg := profile.getLMTConfig(); lmtCfg != 0 {
+		lmtCfgChanged, err := setLMTConfig(lmtCfg)
+		if err != nil {
+			logger.Warning("failed to set LMT config:", err)
+		}
+		if lmtCfgChanged {
+			err = reloadLaptopModeService()
+			if err != nil {
+				logger.Warning(err)
+			}
+		}
+	}
+
+	if profile.BrightnessDropPercent != 0 {
+		m.PropsMu.Lock()
+		changed := m.setPropPowerSavingModeBrightnessDropPercent(profile.BrightnessDropPercent)
+		m.PropsMu.Unlock()
+		if changed {
+			err := m.saveConfig()
+			if err != nil {
+				logger.Warning(err)
+			}
+		}
+	}
+	return nil
+}
+
+// ListPowerProfiles 返回所有电源配置，结果为 PowerProfile 数组的 JSON
+func (m *Manager) ListPowerProfiles() (string, *dbus.Error) {
+	m.profilesMu.Lock()
+	data, err := json.Marshal(m.profilesCfg.Profiles)
+	m.profilesMu.Unlock()
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return string(data), nil
+}
+
+// GetPowerProfile 返回名为 name 的电源配置的 JSON
+func (m *Manager) GetPowerProfile(name string) (string, *dbus.Error) {
+	m.profilesMu.Lock()
+	profile := m.profilesCfg.get(name)
+	var data []byte
+	var err error
+	if profile == nil {
+		err = fmt.Errorf("power profile %q not found", name)
+	} else {
+		data, err = json.Marshal(profile)
+	}
+	m.profilesMu.Unlock()
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return string(data), nil
+}
+
+// SetPowerProfile 添加或替换电源配置，profile 为 PowerProfile 的 JSON。
+// 替换的是当前使用的配置时，重新应用它。
+func (m *Manager) SetPowerProfile(sender dbus.Sender, profile string) *dbus.Error {
+	err := checkAuthorization(polkitActionManagePowerProfile, string(sender))
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+
+	var p PowerProfile
+	err = json.Unmarshal([]byte(profile), &p)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	err = p.check()
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+
+	m.profilesMu.Lock()
+	m.profilesCfg.set(&p)
+	err = m.profilesCfg.save(powerProfilesConfigFile)
+	isActive := m.profilesCfg.Active == p.Name
+	m.profilesMu.Unlock()
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+
+	if isActive {
+		err = m.applyPowerProfile(p.Name)
+		if err != nil {
+			return dbusutil.ToError(err)
+		}
+	}
+	m.updatePowerProfileByTrigger()
+	return nil
+}
+
+// DeletePowerProfile 删除电源配置，删除当前使用的配置时不会恢复之前的设置
+func (m *Manager) DeletePowerProfile(sender dbus.Sender, name string) *dbus.Error {
+	err := checkAuthorization(polkitActionManagePowerProfile, string(sender))
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+
+	m.profilesMu.Lock()
+	ok := m.profilesCfg.remove(name)
+	if ok {
+		err = m.profilesCfg.save(powerProfilesConfigFile)
+	}
+	if m.triggeredProfile == name {
+		m.triggeredProfile = ""
+	}
+	active := m.profilesCfg.Active
+	m.profilesMu.Unlock()
+	if !ok {
+		return dbusutil.ToError(fmt.Errorf("power profile %q not found", name))
+	}
+
+	m.PropsMu.Lock()
+	m.setPropActiveProfile(active)
+	m.PropsMu.Unlock()
+	return dbusutil.ToError(err)
+}
+
+// ApplyPowerProfile 切换到名为 name 的电源配置，供电方式或电量变化使其他配置匹配时会再次自动切换
+func (m *Manager) ApplyPowerProfile(name string) *dbus.Error {
+	err := m.applyPowerProfile(name)
+	return dbusutil.ToError(err)
+}
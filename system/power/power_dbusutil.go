@@ -214,6 +214,19 @@ func (v *Manager) emitPropChangedMode(value string) error {
 	return v.service.EmitPropertyChanged(v, "Mode", value)
 }
 
+func (v *Manager) setPropActiveProfile(value string) (changed bool) {
+	if v.ActiveProfile != value {
+		v.ActiveProfile = value
+		v.emitPropChangedActiveProfile(value)
+		return true
+	}
+	return false
+}
+
+func (v *Manager) emitPropChangedActiveProfile(value string) error {
+	return v.service.EmitPropertyChanged(v, "ActiveProfile", value)
+}
+
 func (v *Battery) setPropSysfsPath(value string) (changed bool) {
 	if v.SysfsPath != value {
 		v.SysfsPath = value
@@ -447,3 +460,68 @@ func (v *Battery) setPropUpdateTime(value int64) (changed bool) {
 func (v *Battery) emitPropChangedUpdateTime(value int64) error {
 	return v.service.EmitPropertyChanged(v, "UpdateTime", value)
 }
+
+func (v *Battery) setPropCycleCount(value uint32) (changed bool) {
+	if v.CycleCount != value {
+		v.CycleCount = value
+		v.emitPropChangedCycleCount(value)
+		return true
+	}
+	return false
+}
+
+func (v *Battery) emitPropChangedCycleCount(value uint32) error {
+	return v.service.EmitPropertyChanged(v, "CycleCount", value)
+}
+
+func (v *Battery) setPropChargeThresholdSupported(value bool) (changed bool) {
+	if v.ChargeThresholdSupported != value {
+		v.ChargeThresholdSupported = value
+		v.emitPropChangedChargeThresholdSupported(value)
+		return true
+	}
+	return false
+}
+
+func (v *Battery) emitPropChangedChargeThresholdSupported(value bool) error {
+	return v.service.EmitPropertyChanged(v, "ChargeThresholdSupported", value)
+}
+
+func (v *Battery) setPropChargeStartThreshold(value uint32) (changed bool) {
+	if v.ChargeStartThreshold != value {
+		v.ChargeStartThreshold = value
+		v.emitPropChangedChargeStartThreshold(value)
+		return true
+	}
+	return false
+}
+
+func (v *Battery) emitPropChangedChargeStartThreshold(value uint32) error {
+	return v.service.EmitPropertyChanged(v, "ChargeStartThreshold", value)
+}
+
+func (v *Battery) setPropChargeEndThreshold(value uint32) (changed bool) {
+	if v.ChargeEndThreshold != value {
+		v.ChargeEndThreshold = value
+		v.emitPropChangedChargeEndThreshold(value)
+		return true
+	}
+	return false
+}
+
+func (v *Battery) emitPropChangedChargeEndThreshold(value uint32) error {
+	return v.service.EmitPropertyChanged(v, "ChargeEndThreshold", value)
+}
+
+func (v *Battery) setPropFullChargeOnce(value bool) (changed bool) {
+	if v.FullChargeOnce != value {
+		v.FullChargeOnce = value
+		v.emitPropChangedFullChargeOnce(value)
+		return true
+	}
+	return false
+}
+
+func (v *Battery) emitPropChangedFullChargeOnce(value bool) error {
+	return v.service.EmitPropertyChanged(v, "FullChargeOnce", value)
+}
@@ -0,0 +1,187 @@
+package power
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const powerProfilesConfigFile = "/var/lib/dde-daemon/power/power_profiles.json"
+
+// 电源配置的自动切换条件
+const (
+	profileTriggerNone         = ""
+	profileTriggerAC           = "ac"
+	profileTriggerBattery      = "battery"
+	profileTriggerBatteryBelow = "battery-below"
+)
+
+// laptop mode tools 的设置
+const (
+	profileLaptopModeAuto     = "auto"
+	profileLaptopModeEnabled  = "enabled"
+	profileLaptopModeDisabled = "disabled"
+)
+
+const maxLidClosedAction = 4
+
+// PowerProfile 是用户定义的电源配置，组合了 CPU、屏幕和待机设置。
+// 字段为空值或 nil 时表示应用配置时不修改对应的设置。
+type PowerProfile struct {
+	Name string
+
+	// 由 system power 应用的设置
+	CpuGovernor           string
+	CpuBoost              *bool
+	LaptopMode            string
+	BrightnessDropPercent uint32
+
+	// 由 session power 应用到当前供电方式（电源或电池）的设置，单位为秒
+	ScreenBlackDelay *int32
+	LockDelay        *int32
+	SleepDelay       *int32
+	LidClosedAction  *int32
+
+	// 自动切换条件，为 battery-below 时，使用电池且电量不高于 TriggerPercentage 时切换
+	Trigger           string
+	TriggerPercentage float64
+}
+
+func (p *PowerProfile) check() error {
+	if p.Name == "" {
+		return errors.New("profile name is empty")
+	}
+	switch p.LaptopMode {
+	case "", profileLaptopModeAuto, profileLaptopModeEnabled, profileLaptopModeDisabled:
+	default:
+		return fmt.Errorf("invalid laptop mode %q", p.LaptopMode)
+	}
+	if p.BrightnessDropPercent > 100 {
+		return fmt.Errorf("invalid brightness drop percent %d", p.BrightnessDropPercent)
+	}
+	for _, delay := range []*int32{p.ScreenBlackDelay, p.LockDelay, p.SleepDelay} {
+		if delay != nil && *delay < 0 {
+			return fmt.Errorf("invalid delay %d", *delay)
+		}
+	}
+	if p.LidClosedAction != nil &&
+		(*p.LidClosedAction < 0 || *p.LidClosedAction > maxLidClosedAction) {
+		return fmt.Errorf("invalid lid closed action %d", *p.LidClosedAction)
+	}
+	switch p.Trigger {
+	case profileTriggerNone, profileTriggerAC, profileTriggerBattery:
+	case profileTriggerBatteryBelow:
+		if p.TriggerPercentage <= 0 || p.TriggerPercentage > 100 {
+			return fmt.Errorf("invalid trigger percentage %v", p.TriggerPercentage)
+		}
+	default:
+		return fmt.Errorf("invalid trigger %q", p.Trigger)
+	}
+	return nil
+}
+
+func (p *PowerProfile) getLMTConfig() int {
+	switch p.LaptopMode {
+	case profileLaptopModeAuto:
+		return lmtConfigAuto
+	case profileLaptopModeEnabled:
+		return lmtConfigEnabled
+	case profileLaptopModeDisabled:
+		return lmtConfigDisabled
+	}
+	return 0
+}
+
+// selectPowerProfile 根据供电状态选择自动切换的配置，条件越具体优先级越高：
+// 电量阈值最低的 battery-below 优先于 battery，没有匹配的配置时返回 nil
+func selectPowerProfile(profiles []*PowerProfile, onBattery bool, percentage float64) *PowerProfile {
+	var result *PowerProfile
+	for _, p := range profiles {
+		switch p.Trigger {
+		case profileTriggerAC:
+			if !onBattery && result == nil {
+				result = p
+			}
+		case profileTriggerBattery:
+			if onBattery && (result == nil || result.Trigger != profileTriggerBatteryBelow) {
+				result = p
+			}
+		case profileTriggerBatteryBelow:
+			if !onBattery || percentage > p.TriggerPercentage {
+				continue
+			}
+			if result == nil || result.Trigger != profileTriggerBatteryBelow ||
+				p.TriggerPercentage < result.TriggerPercentage {
+				result = p
+			}
+		}
+	}
+	return result
+}
+
+// powerProfilesConfig 保存用户定义的电源配置和当前使用的配置
+type powerProfilesConfig struct {
+	Profiles []*PowerProfile
+	Active   string
+}
+
+func loadPowerProfilesConfig(file string) (*powerProfilesConfig, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var cfg powerProfilesConfig
+	err = json.Unmarshal(content, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (cfg *powerProfilesConfig) save(file string) error {
+	err := os.MkdirAll(filepath.Dir(file), 0755)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, content, 0644)
+}
+
+func (cfg *powerProfilesConfig) get(name string) *PowerProfile {
+	for _, p := range cfg.Profiles {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// set 添加配置，已存在同名配置时替换它
+func (cfg *powerProfilesConfig) set(profile *PowerProfile) {
+	for idx, p := range cfg.Profiles {
+		if p.Name == profile.Name {
+			cfg.Profiles[idx] = profile
+			return
+		}
+	}
+	cfg.Profiles = append(cfg.Profiles, profile)
+}
+
+func (cfg *powerProfilesConfig) remove(name string) bool {
+	for idx, p := range cfg.Profiles {
+		if p.Name == name {
+			cfg.Profiles = append(cfg.Profiles[:idx], cfg.Profiles[idx+1:]...)
+			if cfg.Active == name {
+				cfg.Active = ""
+			}
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package power
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPowerProfileCheck(t *testing.T) {
+	Convey("PowerProfile check", t, func(c C) {
+		negative := int32(-1)
+		action := int32(5)
+
+		c.So((&PowerProfile{Name: "work"}).check(), ShouldBeNil)
+		c.So((&PowerProfile{}).check(), ShouldNotBeNil)
+		c.So((&PowerProfile{Name: "a", LaptopMode: "on"}).check(), ShouldNotBeNil)
+		c.So((&PowerProfile{Name: "a", SleepDelay: &negative}).check(), ShouldNotBeNil)
+		c.So((&PowerProfile{Name: "a", LidClosedAction: &action}).check(), ShouldNotBeNil)
+		c.So((&PowerProfile{Name: "a", Trigger: "battery-below"}).check(), ShouldNotBeNil)
+		c.So((&PowerProfile{Name: "a", Trigger: "battery-below",
+			TriggerPercentage: 20}).check(), ShouldBeNil)
+	})
+}
+
+func Test_selectPowerProfile(t *testing.T) {
+	Convey("selectPowerProfile", t, func(c C) {
+		manual := &PowerProfile{Name: "manual"}
+		ac := &PowerProfile{Name: "ac", Trigger: profileTriggerAC}
+		bat := &PowerProfile{Name: "battery", Trigger: profileTriggerBattery}
+		low := &PowerProfile{Name: "low", Trigger: profileTriggerBatteryBelow, TriggerPercentage: 30}
+		critical := &PowerProfile{Name: "critical", Trigger: profileTriggerBatteryBelow, TriggerPercentage: 10}
+		profiles := []*PowerProfile{manual, critical, low, bat, ac}
+
+		c.So(selectPowerProfile(profiles, false, 5), ShouldEqual, ac)
+		c.So(selectPowerProfile(profiles, true, 80), ShouldEqual, bat)
+		c.So(selectPowerProfile(profiles, true, 30), ShouldEqual, low)
+		c.So(selectPowerProfile(profiles, true, 8), ShouldEqual, critical)
+		c.So(selectPowerProfile([]*PowerProfile{manual}, true, 8), ShouldBeNil)
+	})
+}
+
+func TestPowerProfilesConfig(t *testing.T) {
+	Convey("powerProfilesConfig", t, func(c C) {
+		dir, err := ioutil.TempDir("", "power-profiles")
+		c.So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		file := filepath.Join(dir, "power_profiles.json")
+		cfg := &powerProfilesConfig{}
+		cfg.set(&PowerProfile{Name: "a", CpuGovernor: "powersave"})
+		cfg.set(&PowerProfile{Name: "b"})
+		cfg.set(&PowerProfile{Name: "a", CpuGovernor: "performance"})
+		cfg.Active = "a"
+		c.So(cfg.Profiles, ShouldHaveLength, 2)
+		c.So(cfg.get("a").CpuGovernor, ShouldEqual, "performance")
+		c.So(cfg.save(file), ShouldBeNil)
+
+		loaded, err := loadPowerProfilesConfig(file)
+		c.So(err, ShouldBeNil)
+		c.So(loaded, ShouldResemble, cfg)
+
+		c.So(loaded.remove("a"), ShouldBeTrue)
+		c.So(loaded.Active, ShouldEqual, "")
+		c.So(loaded.remove("a"), ShouldBeFalse)
+		c.So(loaded.get("a"), ShouldBeNil)
+	})
+}
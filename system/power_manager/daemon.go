@@ -72,6 +72,7 @@ func (d *Daemon) Stop() error {
 		return nil
 	}
 
+	d.manager.destroy()
 	d.manager = nil
 	return nil
 }
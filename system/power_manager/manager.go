@@ -21,28 +21,43 @@ package power_manager
 
 import (
 	"os"
+	"sync"
 
 	"github.com/godbus/dbus"
 	login1 "github.com/linuxdeepin/go-dbus-factory/org.freedesktop.login1"
 	"pkg.deepin.io/lib/dbusutil"
+	"pkg.deepin.io/lib/dbusutil/proxy"
 )
 
 type Manager struct {
 	service  *dbusutil.Service
+	sigLoop  *dbusutil.SignalLoop
 	objLogin *login1.Manager
 
+	// 定时唤醒
+	rtc       *rtcWakeAlarm
+	wakeups   *wakeupsConfig
+	wakeupsMu sync.Mutex
+	// 待机前设置 RTC 闹钟的 delay 锁，-1 表示没有持有
+	sleepInhibitFd int
+
 	// nolint
 	methods *struct {
 		CanShutdown  func() `out:"can"`
 		CanReboot    func() `out:"can"`
 		CanSuspend   func() `out:"can"`
 		CanHibernate func() `out:"can"`
+
+		AddWakeup    func() `in:"time,reason" out:"id"`
+		ListWakeups  func() `out:"wakeups"`
+		CancelWakeup func() `in:"id"`
 	}
 }
 
 func newManager(service *dbusutil.Service) (*Manager, error) {
 	m := &Manager{
-		service: service,
+		service:        service,
+		sleepInhibitFd: -1,
 	}
 	err := m.init()
 	if err != nil {
@@ -63,9 +78,19 @@ func (m *Manager) init() error {
 	}
 
 	m.objLogin = login1.NewManager(sysBus)
+	m.sigLoop = dbusutil.NewSignalLoop(sysBus, 10)
+	m.sigLoop.Start()
+	m.objLogin.InitSignalExt(m.sigLoop, true)
+	m.initWakeups()
 	return nil
 }
 
+func (m *Manager) destroy() {
+	m.objLogin.RemoveHandler(proxy.RemoveAllHandlers)
+	m.releaseSleepInhibitor()
+	m.sigLoop.Stop()
+}
+
 func (m *Manager) CanShutdown() (bool, *dbus.Error) {
 	str, _ := m.objLogin.CanPowerOff(0)
 	return str == "yes", nil
@@ -0,0 +1,199 @@
+package power_manager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/godbus/dbus"
+	polkit "github.com/linuxdeepin/go-dbus-factory/org.freedesktop.policykit1"
+	"pkg.deepin.io/lib/dbusutil"
+)
+
+const (
+	polkitActionAddWakeup = "com.deepin.daemon.power.add-wakeup"
+	// 每个用户最多注册的未过期的唤醒数，root 不限制
+	maxWakeupsPerUid = 16
+)
+
+func (m *Manager) initWakeups() {
+	m.rtc = defaultRTCWakeAlarm
+	cfg, err := loadWakeupsConfig(wakeupsConfigFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warning("failed to load wakeups config:", err)
+		}
+		cfg = &wakeupsConfig{}
+	}
+	if cfg.removeExpired(time.Now()) {
+		err = cfg.save(wakeupsConfigFile)
+		if err != nil {
+			logger.Warning(err)
+		}
+	}
+	m.wakeups = cfg
+
+	if !m.rtc.IsSupported() {
+		return
+	}
+	m.acquireSleepInhibitor()
+	_, err = m.objLogin.ConnectPrepareForSleep(m.handlePrepareForSleep)
+	if err != nil {
+		logger.Warning(err)
+	}
+}
+
+// acquireSleepInhibitor 持有 sleep 的 delay 锁，保证待机前设置完 RTC 闹钟
+func (m *Manager) acquireSleepInhibitor() {
+	if m.sleepInhibitFd != -1 {
+		return
+	}
+	fd, err := m.objLogin.Inhibit(0, "sleep", dbusServiceName,
+		"set rtc wake alarm", "delay")
+	if err != nil {
+		logger.Warning("failed to inhibit sleep:", err)
+		return
+	}
+	m.sleepInhibitFd = int(fd)
+}
+
+func (m *Manager) releaseSleepInhibitor() {
+	if m.sleepInhibitFd == -1 {
+		return
+	}
+	err := syscall.Close(m.sleepInhibitFd)
+	if err != nil {
+		logger.Warning("failed to close fd:", err)
+	}
+	m.sleepInhibitFd = -1
+}
+
+// handlePrepareForSleep 待机或休眠前设置最近的唤醒时间后释放 delay 锁，
+// 唤醒后清除闹钟和已过期的唤醒，再重新持有 delay 锁
+func (m *Manager) handlePrepareForSleep(before bool) {
+	now := time.Now()
+	m.wakeupsMu.Lock()
+	defer m.wakeupsMu.Unlock()
+
+	if before {
+		defer m.releaseSleepInhibitor()
+		w := m.wakeups.next(now)
+		if w == nil {
+			return
+		}
+		logger.Infof("set rtc wake alarm to %v for %q", time.Unix(w.Time, 0), w.Reason)
+		err := m.rtc.Set(w.Time)
+		if err != nil {
+			logger.Warning("failed to set rtc wake alarm:", err)
+		}
+		return
+	}
+
+	defer m.acquireSleepInhibitor()
+
+	err := m.rtc.Clear()
+	if err != nil {
+		logger.Warning("failed to clear rtc wake alarm:", err)
+	}
+	if m.wakeups.removeExpired(now) {
+		err = m.wakeups.save(wakeupsConfigFile)
+		if err != nil {
+			logger.Warning(err)
+		}
+	}
+}
+
+func checkAuthorization(actionId string, sysBusName string) error {
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return err
+	}
+	authority := polkit.NewAuthority(systemBus)
+	subject := polkit.MakeSubject(polkit.SubjectKindSystemBusName)
+	subject.SetDetail("name", sysBusName)
+
+	ret, err := authority.CheckAuthorization(0, subject, actionId,
+		nil, polkit.CheckAuthorizationFlagsAllowUserInteraction, "")
+	if err != nil {
+		return err
+	}
+	if !ret.IsAuthorized {
+		return errors.New("not authorized")
+	}
+	return nil
+}
+
+// AddWakeup 注册一个定时唤醒，wakeTime 为 Unix 时间戳，返回用于取消的 id。
+// 待机或休眠时使用最近的唤醒时间设置 RTC 闹钟。
+func (m *Manager) AddWakeup(sender dbus.Sender, wakeTime int64, reason string) (uint32, *dbus.Error) {
+	if !m.rtc.IsSupported() {
+		return 0, dbusutil.ToError(errors.New("rtc wake alarm is not supported"))
+	}
+	err := checkAuthorization(polkitActionAddWakeup, string(sender))
+	if err != nil {
+		return 0, dbusutil.ToError(err)
+	}
+	uid, err := m.service.GetConnUID(string(sender))
+	if err != nil {
+		return 0, dbusutil.ToError(err)
+	}
+
+	now := time.Now()
+	m.wakeupsMu.Lock()
+	defer m.wakeupsMu.Unlock()
+	if uid != 0 && m.wakeups.countOfUid(uid, now) >= maxWakeupsPerUid {
+		return 0, dbusutil.ToError(fmt.Errorf("too many wakeups, at most %d", maxWakeupsPerUid))
+	}
+	w, err := m.wakeups.add(wakeTime, reason, uid, now)
+	if err != nil {
+		return 0, dbusutil.ToError(err)
+	}
+	err = m.wakeups.save(wakeupsConfigFile)
+	if err != nil {
+		return 0, dbusutil.ToError(err)
+	}
+	logger.Infof("uid %d add wakeup %d at %v for %q", uid, w.Id, time.Unix(w.Time, 0), reason)
+	return w.Id, nil
+}
+
+// ListWakeups 返回所有未过期的定时唤醒，结果为 Wakeup 数组的 JSON，按时间排序
+func (m *Manager) ListWakeups() (string, *dbus.Error) {
+	now := time.Now()
+	m.wakeupsMu.Lock()
+	var wakeups []*Wakeup
+	for _, w := range m.wakeups.Wakeups {
+		if w.Time > now.Unix() {
+			wakeups = append(wakeups, w)
+		}
+	}
+	data, err := json.Marshal(wakeups)
+	m.wakeupsMu.Unlock()
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return string(data), nil
+}
+
+// CancelWakeup 取消 id 对应的定时唤醒，只有注册者和 root 可以取消
+func (m *Manager) CancelWakeup(sender dbus.Sender, id uint32) *dbus.Error {
+	uid, err := m.service.GetConnUID(string(sender))
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+
+	m.wakeupsMu.Lock()
+	defer m.wakeupsMu.Unlock()
+	w := m.wakeups.get(id)
+	if w == nil {
+		return dbusutil.ToError(fmt.Errorf("wakeup %d not found", id))
+	}
+	if uid != 0 && uid != w.Uid {
+		return dbusutil.ToError(errors.New("not allowed to cancel wakeup of other user"))
+	}
+	m.wakeups.remove(id)
+	err = m.wakeups.save(wakeupsConfigFile)
+	return dbusutil.ToError(err)
+}
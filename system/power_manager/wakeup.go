@@ -0,0 +1,168 @@
+package power_manager
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const wakeupsConfigFile = "/var/lib/dde-daemon/power_manager/wakeups.json"
+
+// Wakeup 是一个定时唤醒，待机或休眠时把最近的唤醒时间写入 RTC 闹钟
+type Wakeup struct {
+	Id uint32
+	// Unix 时间戳
+	Time   int64
+	Reason string
+	// 注册者的 uid，只有注册者和 root 可以取消
+	Uid uint32
+}
+
+// rtcWakeAlarm 读写 RTC 的 wakealarm 文件，测试时可以换成普通文件
+type rtcWakeAlarm struct {
+	file string
+}
+
+var defaultRTCWakeAlarm = &rtcWakeAlarm{file: "/sys/class/rtc/rtc0/wakealarm"}
+
+func (r *rtcWakeAlarm) IsSupported() bool {
+	_, err := os.Stat(r.file)
+	return err == nil
+}
+
+// Get 返回已设置的闹钟时间，没有设置时返回 0
+func (r *rtcWakeAlarm) Get() (int64, error) {
+	content, err := ioutil.ReadFile(r.file)
+	if err != nil {
+		return 0, err
+	}
+	value := strings.TrimSpace(string(content))
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// Set 设置闹钟时间，已有闹钟时内核会返回 EBUSY，所以先清除
+func (r *rtcWakeAlarm) Set(t int64) error {
+	err := r.Clear()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.file, []byte(strconv.FormatInt(t, 10)), 0644)
+}
+
+func (r *rtcWakeAlarm) Clear() error {
+	return ioutil.WriteFile(r.file, []byte("0"), 0644)
+}
+
+// wakeupsConfig 保存所有注册的定时唤醒，重启后恢复
+type wakeupsConfig struct {
+	Wakeups []*Wakeup
+	LastId  uint32
+}
+
+func loadWakeupsConfig(file string) (*wakeupsConfig, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var cfg wakeupsConfig
+	err = json.Unmarshal(content, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (cfg *wakeupsConfig) save(file string) error {
+	err := os.MkdirAll(filepath.Dir(file), 0755)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, content, 0644)
+}
+
+func (cfg *wakeupsConfig) add(t int64, reason string, uid uint32, now time.Time) (*Wakeup, error) {
+	if t <= now.Unix() {
+		return nil, errors.New("wakeup time is in the past")
+	}
+	cfg.LastId++
+	w := &Wakeup{
+		Id:     cfg.LastId,
+		Time:   t,
+		Reason: reason,
+		Uid:    uid,
+	}
+	cfg.Wakeups = append(cfg.Wakeups, w)
+	sort.SliceStable(cfg.Wakeups, func(i, j int) bool {
+		return cfg.Wakeups[i].Time < cfg.Wakeups[j].Time
+	})
+	return w, nil
+}
+
+func (cfg *wakeupsConfig) get(id uint32) *Wakeup {
+	for _, w := range cfg.Wakeups {
+		if w.Id == id {
+			return w
+		}
+	}
+	return nil
+}
+
+func (cfg *wakeupsConfig) remove(id uint32) {
+	for idx, w := range cfg.Wakeups {
+		if w.Id == id {
+			cfg.Wakeups = append(cfg.Wakeups[:idx], cfg.Wakeups[idx+1:]...)
+			return
+		}
+	}
+}
+
+// countOfUid 返回用户 uid 注册的未过期的唤醒数
+func (cfg *wakeupsConfig) countOfUid(uid uint32, now time.Time) int {
+	count := 0
+	for _, w := range cfg.Wakeups {
+		if w.Uid == uid && w.Time > now.Unix() {
+			count++
+		}
+	}
+	return count
+}
+
+// removeExpired 删除已经过期的唤醒，返回是否有删除
+func (cfg *wakeupsConfig) removeExpired(now time.Time) bool {
+	var result []*Wakeup
+	for _, w := range cfg.Wakeups {
+		if w.Time > now.Unix() {
+			result = append(result, w)
+		}
+	}
+	changed := len(result) != len(cfg.Wakeups)
+	cfg.Wakeups = result
+	return changed
+}
+
+// next 返回最近的未过期的唤醒，没有时返回 nil
+func (cfg *wakeupsConfig) next(now time.Time) *Wakeup {
+	var result *Wakeup
+	for _, w := range cfg.Wakeups {
+		if w.Time <= now.Unix() {
+			continue
+		}
+		if result == nil || w.Time < result.Time {
+			result = w
+		}
+	}
+	return result
+}
@@ -0,0 +1,77 @@
+package power_manager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_rtcWakeAlarm(t *testing.T) {
+	Convey("rtcWakeAlarm", t, func(c C) {
+		dir, err := ioutil.TempDir("", "rtc")
+		c.So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		r := &rtcWakeAlarm{file: filepath.Join(dir, "wakealarm")}
+		c.So(r.IsSupported(), ShouldBeFalse)
+
+		c.So(ioutil.WriteFile(r.file, nil, 0644), ShouldBeNil)
+		c.So(r.IsSupported(), ShouldBeTrue)
+		value, err := r.Get()
+		c.So(err, ShouldBeNil)
+		c.So(value, ShouldEqual, 0)
+
+		c.So(r.Set(1700000000), ShouldBeNil)
+		value, err = r.Get()
+		c.So(err, ShouldBeNil)
+		c.So(value, ShouldEqual, 1700000000)
+
+		c.So(r.Clear(), ShouldBeNil)
+		value, err = r.Get()
+		c.So(err, ShouldBeNil)
+		c.So(value, ShouldEqual, 0)
+	})
+}
+
+func Test_wakeupsConfig(t *testing.T) {
+	Convey("wakeupsConfig", t, func(c C) {
+		now := time.Unix(1000, 0)
+		cfg := &wakeupsConfig{}
+
+		_, err := cfg.add(1000, "past", 1000, now)
+		c.So(err, ShouldNotBeNil)
+
+		w1, err := cfg.add(3000, "backup", 0, now)
+		c.So(err, ShouldBeNil)
+		w2, err := cfg.add(2000, "calendar", 1000, now)
+		c.So(err, ShouldBeNil)
+		c.So(w1.Id, ShouldNotEqual, w2.Id)
+		c.So(cfg.next(now), ShouldEqual, w2)
+		c.So(cfg.next(time.Unix(2000, 0)), ShouldEqual, w1)
+		c.So(cfg.countOfUid(1000, now), ShouldEqual, 1)
+		c.So(cfg.countOfUid(1000, time.Unix(2000, 0)), ShouldEqual, 0)
+		c.So(cfg.countOfUid(1001, now), ShouldEqual, 0)
+
+		c.So(cfg.removeExpired(time.Unix(2500, 0)), ShouldBeTrue)
+		c.So(cfg.get(w2.Id), ShouldBeNil)
+		c.So(cfg.removeExpired(time.Unix(2500, 0)), ShouldBeFalse)
+
+		cfg.remove(w1.Id)
+		c.So(cfg.next(now), ShouldBeNil)
+
+		dir, err := ioutil.TempDir("", "wakeups")
+		c.So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		file := filepath.Join(dir, "power_manager", "wakeups.json")
+		_, err = cfg.add(4000, "job", 0, now)
+		c.So(err, ShouldBeNil)
+		c.So(cfg.save(file), ShouldBeNil)
+		loaded, err := loadWakeupsConfig(file)
+		c.So(err, ShouldBeNil)
+		c.So(loaded, ShouldResemble, cfg)
+	})
+}